@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"ojm/tools"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the YAML configuration given with --config. It is an
+// alternative to the env vars, which still win when both are set
+type Config struct {
+	MoviesFolder string   `yaml:"movies_folder"`
+	ShowsFolder  string   `yaml:"shows_folder"`
+	SourceFolder string   `yaml:"source_folder"`
+	Model        string   `yaml:"model"`
+	MaxTokens    int      `yaml:"max_tokens"`
+	APIKeys      []string `yaml:"api_keys"`
+}
+
+// loadConfig reads and parses a YAML config file. Unknown keys are an error,
+// so a misspelled setting doesn't go unnoticed
+func loadConfig(path string) (*Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+
+	var config Config
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// applyToEnv sets the env vars the config covers, as the rest of the program
+// reads its settings from there. Env vars that are already set are kept
+func (c *Config) applyToEnv() {
+	values := map[string]string{
+		"JELLYFIN_MOVIES_FOLDER": c.MoviesFolder,
+		"JELLYFIN_SHOWS_FOLDER":  c.ShowsFolder,
+		"SOURCE_FOLDER":          c.SourceFolder,
+		"ANTHROPIC_MODEL":        c.Model,
+	}
+	// A single ANTHROPIC_API_KEY in the env overrides the configured keys too
+	if os.Getenv("ANTHROPIC_API_KEY") == "" {
+		values["ANTHROPIC_API_KEYS"] = strings.Join(c.APIKeys, ",")
+	}
+	if c.MaxTokens > 0 {
+		values["ANTHROPIC_MAX_TOKENS"] = strconv.Itoa(c.MaxTokens)
+	}
+
+	for name, value := range values {
+		if value != "" && os.Getenv(name) == "" {
+			os.Setenv(name, value)
+		}
+	}
+}
+
+// validateConfig checks the settings needed to start a session and returns
+// every problem found, so they can all be fixed in one go
+func validateConfig(keys []string) []string {
+	var problems []string
+	if len(keys) == 0 {
+		problems = append(problems, "no Anthropic API key, set ANTHROPIC_API_KEY or ANTHROPIC_API_KEYS")
+	}
+
+	folders := []struct {
+		envVar   string
+		required bool
+	}{
+		{"JELLYFIN_MOVIES_FOLDER", true},
+		{"JELLYFIN_SHOWS_FOLDER", true},
+		{"SOURCE_FOLDER", false},
+	}
+
+	fsys, err := tools.GetMediaFS()
+	if err != nil {
+		return append(problems, fmt.Sprintf("media folders can't be checked: %v", err))
+	}
+	for _, folder := range folders {
+		path := os.Getenv(folder.envVar)
+		if path == "" {
+			if folder.required {
+				problems = append(problems, fmt.Sprintf("%s is not set", folder.envVar))
+			}
+			continue
+		}
+		if problem := checkFolder(fsys, path); problem != "" {
+			problems = append(problems, fmt.Sprintf("%s %s", folder.envVar, problem))
+		}
+	}
+
+	return problems
+}
+
+// checkFolder describes why path isn't a readable directory, or returns ""
+func checkFolder(fsys tools.MediaFS, path string) string {
+	info, err := fsys.Stat(path)
+	if os.IsNotExist(err) {
+		return fmt.Sprintf("points to %s, which does not exist", path)
+	}
+	if err != nil {
+		return fmt.Sprintf("points to %s, which can't be accessed: %v", path, err)
+	}
+	if !info.IsDir() {
+		return fmt.Sprintf("points to %s, which is not a directory", path)
+	}
+	if _, err := fsys.ReadDir(path); err != nil {
+		return fmt.Sprintf("points to %s, which can't be read: %v", path, err)
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"ojm/tools"
+)
+
+// defaultDestructiveTools change files in the libraries. DESTRUCTIVE_TOOLS
+// replaces the list with a comma separated one
+var defaultDestructiveTools = []string{
+	"rename_jellyfin_media",
+	"copy_file",
+	"delete_file",
+	"move_across_libraries",
+	"migrate_naming",
+	"folder_loose_movies",
+	"normalize_episode_padding",
+	"quick_rename",
+	"title_case_folder",
+	"clean_episode_title",
+	"dedupe_subtitles",
+	"create_strm",
+	"write_nfo",
+	"download_artwork",
+	"group_movie_parts",
+	"batch_rename",
+	"convert_subtitle_encoding",
+	"fetch_chapters",
+	"set_sort_title",
+	"check_nfo_artwork",
+	"create_directory",
+	"generate_hash_manifest",
+	"set_library_profile",
+	"commit_queue",
+	"undo_last_operation",
+}
+
+// destructiveToolsFromEnv returns the tools that need confirmation, or nil
+// when REQUIRE_CONFIRMATION isn't enabled
+func destructiveToolsFromEnv() map[string]bool {
+	required, _ := strconv.ParseBool(os.Getenv("REQUIRE_CONFIRMATION"))
+	if !required {
+		return nil
+	}
+	return destructiveToolNames()
+}
+
+// destructiveToolNames returns the tools that change files, from
+// DESTRUCTIVE_TOOLS or the default list
+func destructiveToolNames() map[string]bool {
+	names := defaultDestructiveTools
+	if list := os.Getenv("DESTRUCTIVE_TOOLS"); list != "" {
+		names = strings.Split(list, ",")
+	}
+
+	destructive := map[string]bool{}
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			destructive[name] = true
+		}
+	}
+	return destructive
+}
+
+// needsConfirmation reports whether a tool call must be approved first
+func (a *Agent) needsConfirmation(tool tools.ToolDefinition, input json.RawMessage) bool {
+	return isDestructiveCall(a.destructiveTools, tool, input)
+}
+
+// isDestructiveCall reports whether a call to one of the destructive tools
+// changes files. Previews, like a call without apply or with dry_run,
+// run without asking
+func isDestructiveCall(destructive map[string]bool, tool tools.ToolDefinition, input json.RawMessage) bool {
+	return destructive[tool.Name] && !tool.IsPreview(input)
+}
+
+// confirmToolCall shows a tool call to the user and asks whether to run it
+func (a *Agent) confirmToolCall(name string, input json.RawMessage) bool {
+	fmt.Printf("\u001b[92mtool\u001b[0m: Claude wants to run %s(%s)\n", name, input)
+	return a.confirm("Allow this operation?")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"ojm/tools"
+)
+
+func TestIsDestructiveCall(t *testing.T) {
+	t.Setenv("DESTRUCTIVE_TOOLS", "")
+	destructive := destructiveToolNames()
+	byName := tools.IndexTools(tools.AllTools)
+
+	tests := []struct {
+		tool  string
+		input string
+		want  bool
+	}{
+		{tool: "rename_jellyfin_media", input: `{"source_path":"a","target_path":"b"}`, want: true},
+		{tool: "list_directory", input: `{}`, want: false},
+		{tool: "migrate_naming", input: `{"apply":false}`, want: false},
+		{tool: "migrate_naming", input: `{}`, want: false},
+		{tool: "migrate_naming", input: `{"apply":true}`, want: true},
+		{tool: "dedupe_subtitles", input: `{"folder_path":"a"}`, want: false},
+		{tool: "dedupe_subtitles", input: `{"folder_path":"a","remove":true}`, want: true},
+		{tool: "check_nfo_artwork", input: `{"fix":false}`, want: false},
+		{tool: "check_nfo_artwork", input: `{"fix":true}`, want: true},
+		{tool: "convert_subtitle_encoding", input: `{"dry_run":true}`, want: false},
+		{tool: "convert_subtitle_encoding", input: `{}`, want: true},
+		{tool: "set_sort_title", input: `{"dry_run":true}`, want: false},
+		{tool: "set_sort_title", input: `{"dry_run":false}`, want: true},
+		{tool: "create_directory", input: `{"type":"movies","subpath":"a"}`, want: true},
+		{tool: "generate_hash_manifest", input: `{}`, want: true},
+		{tool: "fetch_chapters", input: `{}`, want: true},
+		{tool: "set_library_profile", input: `{}`, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool+" "+tt.input, func(t *testing.T) {
+			tool, ok := byName[tt.tool]
+			if !ok {
+				t.Fatalf("no tool named %s", tt.tool)
+			}
+			if got := isDestructiveCall(destructive, tool, json.RawMessage(tt.input)); got != tt.want {
+				t.Errorf("isDestructiveCall = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDestructiveToolsExist(t *testing.T) {
+	agent := NewAgent(nil, nil, tools.AllTools)
+	for _, name := range defaultDestructiveTools {
+		if _, ok := agent.toolsByName[name]; !ok {
+			t.Errorf("destructive tool %s doesn't exist", name)
+		}
+	}
+}
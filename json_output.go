@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// toolCallRecord is the machine-readable line emitted for every tool call
+// with --json, so wrapper scripts can follow what the agent did
+type toolCallRecord struct {
+	Time    time.Time       `json:"time"`
+	Tool    string          `json:"tool"`
+	Input   json.RawMessage `json:"input"`
+	Success bool            `json:"success"`
+	Result  string          `json:"result"`
+}
+
+// reportToolCall writes a tool call to the JSON output, if enabled
+func (a *Agent) reportToolCall(name string, input json.RawMessage, result string, isError bool) {
+	if a.jsonOutput == nil {
+		return
+	}
+	if len(input) == 0 {
+		input = json.RawMessage("{}")
+	}
+	a.jsonOutput.Encode(toolCallRecord{
+		Time:    time.Now(),
+		Tool:    name,
+		Input:   input,
+		Success: !isError,
+		Result:  result,
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// apiKeys returns the configured Anthropic API keys. ANTHROPIC_API_KEYS holds
+// a comma separated list to rotate through, otherwise ANTHROPIC_API_KEY is
+// the only key
+func apiKeys() []string {
+	var keys []string
+	for _, key := range strings.Split(os.Getenv("ANTHROPIC_API_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		if key := os.Getenv("ANTHROPIC_API_KEY"); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// newClientForKey builds a client using key, or the SDK defaults when no key
+// is configured. The SDK's own retries are disabled, as the agent retries
+// with backoff itself and rotates keys on rate limits
+func newClientForKey(key string) *anthropic.Client {
+	opts := []option.RequestOption{option.WithMaxRetries(0)}
+	if key != "" {
+		opts = append(opts, option.WithAPIKey(key))
+	}
+	client := anthropic.NewClient(opts...)
+	return &client
+}
+
+// isRateLimited reports whether a request failed with 429, meaning the key
+// is rate limited
+func isRateLimited(err error) bool {
+	var apiErr *anthropic.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// rotateKey switches the agent to the next API key and rebuilds its client
+func (a *Agent) rotateKey() {
+	a.keyIndex = (a.keyIndex + 1) % len(a.keys)
+	a.client = newClientForKey(a.keys[a.keyIndex])
+	fmt.Printf("Rate limited, switching to API key %d of %d\n", a.keyIndex+1, len(a.keys))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestAPIKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   string
+		single string
+		want   []string
+	}{
+		{name: "single key", single: "a", want: []string{"a"}},
+		{name: "rotated keys", keys: "a, b,,c ", single: "d", want: []string{"a", "b", "c"}},
+		{name: "empty list falls back to the single key", keys: " , ", single: "d", want: []string{"d"}},
+		{name: "no keys"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ANTHROPIC_API_KEYS", tt.keys)
+			t.Setenv("ANTHROPIC_API_KEY", tt.single)
+			if got := apiKeys(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("apiKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	ok := fakeResponse{text: "Hello"}
+	rateLimited := fakeResponse{status: http.StatusTooManyRequests}
+	unauthorized := fakeResponse{status: http.StatusUnauthorized}
+
+	tests := []struct {
+		name      string
+		keys      []string
+		responses []fakeResponse
+		wantKeys  []string
+		wantIndex int
+		wantErr   bool
+	}{
+		{name: "no rate limit", keys: []string{"a", "b"}, responses: []fakeResponse{ok}, wantKeys: []string{"a"}},
+		{name: "rate limited key rotates to the next", keys: []string{"a", "b"}, responses: []fakeResponse{rateLimited, ok}, wantKeys: []string{"a", "b"}, wantIndex: 1},
+		{name: "rotates through every key", keys: []string{"a", "b", "c"}, responses: []fakeResponse{rateLimited, rateLimited, ok}, wantKeys: []string{"a", "b", "c"}, wantIndex: 2},
+		{name: "every key rate limited", keys: []string{"a", "b"}, responses: []fakeResponse{rateLimited, rateLimited}, wantKeys: []string{"a", "b"}, wantIndex: 1, wantErr: true},
+		{name: "single key is not rotated", keys: []string{"a"}, responses: []fakeResponse{rateLimited}, wantKeys: []string{"a"}, wantErr: true},
+		{name: "unauthorized key is not rotated", keys: []string{"a", "b"}, responses: []fakeResponse{unauthorized}, wantKeys: []string{"a"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeAnthropic(t, tt.responses...)
+			agent, _ := newTestAgent(t, nil)
+			agent.keys = tt.keys
+			agent.client = newClientForKey(tt.keys[0])
+
+			params := anthropic.MessageNewParams{
+				Model:     agent.model,
+				MaxTokens: agent.maxTokens,
+				Messages:  []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock("hi"))},
+			}
+			_, err := agent.newMessageWithRetry(context.Background(), params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := fake.requests(); !reflect.DeepEqual(got, tt.wantKeys) {
+				t.Errorf("requests used keys %v, want %v", got, tt.wantKeys)
+			}
+			if agent.keyIndex != tt.wantIndex {
+				t.Errorf("agent is on key %d, want %d", agent.keyIndex, tt.wantIndex)
+			}
+		})
+	}
+}
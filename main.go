@@ -5,12 +5,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io/fs"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
 	"text/template"
 
 	"ojm/tools"
@@ -20,48 +25,167 @@ import (
 )
 
 func main() {
+	configPath := flag.String("config", "", "read settings from this YAML file, env vars still take precedence")
+	transcriptPath := flag.String("transcript", "", "write a human-readable transcript of the conversation to this file")
+	budget := flag.Float64("budget", 0, "stop the session once the estimated cost in USD exceeds this amount (0 means no limit)")
+	batch := flag.Bool("batch", false, "treat the input paths as downloads folders and organize each of their immediate children in turn")
+	dryRun := flag.Bool("dry-run", false, "check moves and copies without performing them (also enabled by DRY_RUN=true)")
+	moviesFlag := flag.String("movies-folder", "", "the Jellyfin movies library, overriding JELLYFIN_MOVIES_FOLDER")
+	showsFlag := flag.String("shows-folder", "", "the Jellyfin shows library, overriding JELLYFIN_SHOWS_FOLDER")
+	jsonOutput := flag.Bool("json", false, "write a JSON line for every tool call to stderr")
+	plan := flag.Bool("plan", false, "have Claude present its whole plan first and ask once before changing any files (also enabled by PLAN_MODE=true)")
+	auto := flag.Bool("auto", false, "run unattended: exit once Claude stops calling tools instead of waiting for input (also enabled by AUTO_MODE=true)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [path ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// A config file makes the .env file optional
 	err := godotenv.Load()
-	if err != nil {
+	if err != nil && *configPath == "" {
 		log.Fatal("No env file found")
 	}
 
-	client := anthropic.NewClient()
+	if *configPath != "" {
+		config, err := loadConfig(*configPath)
+		if err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		config.applyToEnv()
+	}
 
-	// Collect user input
-	inputPath := getInput("Enter the path of the file or folder to organize: ")
+	envDryRun, _ := strconv.ParseBool(os.Getenv("DRY_RUN"))
+	tools.DryRun = *dryRun || envDryRun
+	if tools.DryRun {
+		fmt.Println("Dry run: moves and copies are checked but not performed")
+	}
+
+	envAuto, _ := strconv.ParseBool(os.Getenv("AUTO_MODE"))
+	envPlan, _ := strconv.ParseBool(os.Getenv("PLAN_MODE"))
+	planMode := *plan || envPlan
+
+	// The tools read the library folders from the environment, so the flags
+	// override them there
+	if *moviesFlag != "" {
+		os.Setenv("JELLYFIN_MOVIES_FOLDER", *moviesFlag)
+	}
+	if *showsFlag != "" {
+		os.Setenv("JELLYFIN_SHOWS_FOLDER", *showsFlag)
+	}
+
+	keys := apiKeys()
+	if problems := validateConfig(keys); len(problems) > 0 {
+		log.Fatalf("Configuration problems:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+
+	firstKey := ""
+	if len(keys) > 0 {
+		firstKey = keys[0]
+	}
+	client := newClientForKey(firstKey)
+
+	// The paths to organize are the arguments, or asked for when omitted
+	inputPaths := flag.Args()
+	if len(inputPaths) == 0 {
+		inputPaths = []string{getInput("Enter the path of the file or folder to organize: ")}
+	}
 
 	// Get env vars
 	moviesFolder := os.Getenv("JELLYFIN_MOVIES_FOLDER")
 	showsFolder := os.Getenv("JELLYFIN_SHOWS_FOLDER")
 
-	if moviesFolder == "" || showsFolder == "" {
-		log.Fatal("JELLYFIN_MOVIES_FOLDER and JELLYFIN_SHOWS_FOLDER environment variables must be set")
-	}
-
 	// Read Jellyfin docs
 	jellyfinDocs, err := readJellyfinDocs()
 	if err != nil {
 		log.Fatalf("Error reading Jellyfin docs: %v", err)
 	}
 
+	// In batch mode every child of the input folders gets its own prompt
+	itemPaths := inputPaths
+	if *batch {
+		itemPaths = nil
+		for _, inputPath := range inputPaths {
+			items, err := listBatchItems(inputPath)
+			if err != nil {
+				log.Fatalf("Error listing batch items: %v", err)
+			}
+			itemPaths = append(itemPaths, items...)
+		}
+	}
+
 	// Process prompt template
-	prompt, err := processPromptTemplate(inputPath, moviesFolder, showsFolder, jellyfinDocs)
-	if err != nil {
-		log.Fatalf("Error processing prompt template: %v", err)
+	prompts := make([]string, len(itemPaths))
+	for i, itemPath := range itemPaths {
+		prompts[i], err = processPromptTemplate(itemPath, moviesFolder, showsFolder, jellyfinDocs)
+		if err != nil {
+			log.Fatalf("Error processing prompt template: %v", err)
+		}
+		if planMode {
+			prompts[i] += planModeInstruction
+		}
 	}
 
-	scanner := bufio.NewScanner(os.Stdin)
+	// Ctrl-C cancels the context, which stops an API call in flight and ends
+	// the session after the current tool. A second Ctrl-C kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+
+	// Input is read in the background, so waiting for it doesn't block the
+	// shutdown
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
 	getUserMessage := func() (string, bool) {
-		if !scanner.Scan() {
+		select {
+		case line, ok := <-lines:
+			return line, ok
+		case <-ctx.Done():
 			return "", false
 		}
-		return scanner.Text(), true
 	}
 
 	toolDefinitions := tools.AllTools
-	agent := NewAgent(&client, getUserMessage, toolDefinitions)
+	agent := NewAgent(client, getUserMessage, toolDefinitions)
+	agent.budget = *budget
+	agent.auto = *auto || envAuto
+	if *jsonOutput {
+		agent.jsonOutput = json.NewEncoder(os.Stderr)
+	}
+	agent.keys = keys
+	agent.model = modelFromEnv()
+	agent.maxTokens = maxTokensFromEnv(agent.model)
+	agent.maxAttempts = maxAttemptsFromEnv()
+	agent.maxTurns = maxTurnsFromEnv()
+	agent.destructiveTools = destructiveToolsFromEnv()
+	if planMode {
+		agent.planMode = true
+		agent.planTools = destructiveToolNames()
+	}
+
+	// TRANSCRIPT_DIR keeps a JSONL history of every session for auditing
+	transcript, err := OpenTranscript(*transcriptPath, os.Getenv("TRANSCRIPT_DIR"))
+	if err != nil {
+		log.Fatalf("Error creating transcript: %v", err)
+	}
+	defer transcript.Close()
+	agent.transcript = transcript
+
+	// Several items are organized one after another, each in a fresh
+	// conversation, like a batch
+	if *batch || len(itemPaths) > 1 {
+		results := agent.RunBatch(ctx, itemPaths, prompts)
+		printBatchSummary(results)
+		return
+	}
 
-	err = agent.RunWithInitialPrompt(context.TODO(), prompt)
+	err = agent.RunWithInitialPrompt(ctx, prompts[0])
 	if err != nil {
 		fmt.Printf("Error: %+v\n", err)
 	}
@@ -74,10 +198,48 @@ func getInput(prompt string) string {
 	return strings.TrimSpace(scanner.Text())
 }
 
+// listBatchItems returns the immediate children of a downloads folder,
+// skipping hidden entries
+func listBatchItems(folder string) ([]string, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		items = append(items, filepath.Join(folder, entry.Name()))
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%s has nothing to organize", folder)
+	}
+	return items, nil
+}
+
+func printBatchSummary(results []BatchResult) {
+	fmt.Println("Batch summary:")
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Printf("  FAILED %s: %v\n", result.Item, result.Err)
+			continue
+		}
+		fmt.Printf("  OK     %s: %s\n", result.Item, result.Summary)
+	}
+}
+
 func readJellyfinDocs() (string, error) {
 	var docs strings.Builder
 
-	err := filepath.WalkDir("prompt/jellyfin-docs", func(path string, d fs.DirEntry, err error) error {
+	docsFS, err := jellyfinDocsFS()
+	if err != nil {
+		return "", err
+	}
+
+	err = fs.WalkDir(docsFS, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -87,7 +249,7 @@ func readJellyfinDocs() (string, error) {
 		}
 
 		if strings.HasSuffix(path, ".md") {
-			content, err := os.ReadFile(path)
+			content, err := fs.ReadFile(docsFS, path)
 			if err != nil {
 				return err
 			}
@@ -109,7 +271,7 @@ type PromptData struct {
 }
 
 func processPromptTemplate(inputPath, moviesFolder, showsFolder, jellyfinDocs string) (string, error) {
-	templateContent, err := os.ReadFile("prompt/main.md")
+	templateContent, err := readPromptTemplate()
 	if err != nil {
 		return "", err
 	}
@@ -135,86 +297,166 @@ func processPromptTemplate(inputPath, moviesFolder, showsFolder, jellyfinDocs st
 	return buf.String(), nil
 }
 
+const defaultModel = anthropic.ModelClaude3_7SonnetLatest
+
 type Agent struct {
-	client        *anthropic.Client
-	getUserMesage func() (string, bool)
-	tools         []tools.ToolDefinition
+	client           *anthropic.Client
+	getUserMesage    func() (string, bool)
+	tools            []tools.ToolDefinition
+	toolsByName      map[string]tools.ToolDefinition
+	transcript       *Transcript
+	model            anthropic.Model
+	maxTokens        int64
+	maxAttempts      int
+	destructiveTools map[string]bool
+	usage            Usage
+	budget           float64
+	queue            *tools.OperationQueue
+	keys             []string
+	keyIndex         int
+	auto             bool
+	jsonOutput       *json.Encoder
+	maxTurns         int
+	planMode         bool
+	planTools        map[string]bool
+	planApproved     bool
+	planSteps        []PlanStep
 }
 
 func NewAgent(client *anthropic.Client, getUserMesage func() (string, bool), toolDefs []tools.ToolDefinition) *Agent {
-	return &Agent{
+	a := &Agent{
 		client:        client,
 		getUserMesage: getUserMesage,
-		tools:         toolDefs,
+		model:         defaultModel,
+		maxTokens:     defaultMaxTokens,
+		maxAttempts:   defaultMaxAttempts,
+		maxTurns:      defaultMaxTurns,
 	}
+
+	// Operations queued by Claude are committed after a single confirmation
+	a.queue = tools.NewOperationQueue(a.confirm)
+	a.tools = append(append([]tools.ToolDefinition{}, toolDefs...), a.queue.Definitions()...)
+	a.toolsByName = tools.IndexTools(a.tools)
+
+	return a
+}
+
+// confirm asks the user a yes/no question and reports whether they agreed
+func (a *Agent) confirm(prompt string) bool {
+	fmt.Printf("%s [y/N]: ", prompt)
+
+	answer, ok := a.getUserMesage()
+	if !ok {
+		return false
+	}
+	a.transcript.User(answer)
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
 }
 
 func (a *Agent) RunWithInitialPrompt(ctx context.Context, initialPrompt string) error {
+	// The usage is reported however the session ends
+	defer a.printUsage()
+	a.resetPlan()
+
 	convo := []anthropic.MessageParam{}
 
 	// Add initial prompt as first message
 	userMsg := anthropic.NewUserMessage(anthropic.NewTextBlock(initialPrompt))
 	convo = append(convo, userMsg)
+	a.transcript.User(initialPrompt)
 
-	fmt.Println("Chat with Claude (use 'ctrl-c' to quit)")
+	if a.auto {
+		fmt.Println("Running unattended, stopping once Claude is done")
+	} else {
+		fmt.Println("Chat with Claude (use 'ctrl-c' to quit)")
+	}
 	fmt.Println("Sending initial prompt...")
 
 	// Process initial prompt
+	turns := 1
 	message, err := a.runInference(ctx, convo)
 	if err != nil {
-		return err
+		return shutdownOr(ctx, err)
 	}
 
-	convo = append(convo, message.ToParam())
-
 	toolResults := []anthropic.ContentBlockParamUnion{}
-	for _, content := range message.Content {
-		switch content.Type {
-		case "text":
-			fmt.Printf("\u001b[93mClaude\u001b[0m: %s\n", content.Text)
-		case "tool_use":
-			result := a.executeTool(content.ID, content.Name, content.Input)
-			toolResults = append(toolResults, result)
-		}
-	}
+	if isEmptyResponse(message) {
+		a.reportEmptyResponse(message)
+	} else {
+		convo = append(convo, message.ToParam())
 
-	if len(toolResults) > 0 {
-		convo = append(convo, anthropic.NewUserMessage(toolResults...))
+		toolResults, err = a.respond(ctx, message)
+		if errors.Is(err, errPlanDeclined) {
+			fmt.Println("Plan declined, nothing was changed")
+			return nil
+		}
+		if err != nil {
+			return shutdownOr(ctx, err)
+		}
+		if len(toolResults) > 0 {
+			convo = append(convo, anthropic.NewUserMessage(toolResults...))
+		}
 	}
 
 	// Continue with regular conversation loop
 	readUserInput := len(toolResults) == 0
 	for {
+		if ctx.Err() != nil {
+			return shutdownOr(ctx, nil)
+		}
+		if a.budgetExceeded() {
+			fmt.Printf("Budget of $%.2f reached, stopping before the next request\n", a.budget)
+			break
+		}
+		if a.turnLimitReached(turns) {
+			fmt.Printf("Warning: reached the limit of %d turns (MAX_TURNS), stopping before the next request\n", a.maxTurns)
+			break
+		}
+
+		// Unattended runs end where a chat would hand over to the user
+		if readUserInput && a.auto {
+			break
+		}
+
 		if readUserInput {
 			fmt.Print("\u001b[94mYou\u001b[0m: ")
 
 			userInput, ok := a.getUserMesage()
 			if !ok {
-				break
+				return shutdownOr(ctx, nil)
 			}
 
 			userMsg := anthropic.NewUserMessage(anthropic.NewTextBlock(userInput))
 			convo = append(convo, userMsg)
+			a.transcript.User(userInput)
 		}
 
+		turns++
 		message, err := a.runInference(ctx, convo)
 		if err != nil {
-			return err
+			return shutdownOr(ctx, err)
+		}
+
+		// An empty assistant message can't be sent back to the API, so
+		// drop it and hand control back to the user
+		if isEmptyResponse(message) {
+			a.reportEmptyResponse(message)
+			readUserInput = true
+			continue
 		}
 
 		convo = append(convo, message.ToParam())
 
-		toolResults := []anthropic.ContentBlockParamUnion{}
-		for _, content := range message.Content {
-			switch content.Type {
-			case "text":
-				fmt.Printf("\u001b[93mClaude\u001b[0m: %s\n", content.Text)
-			case "tool_use":
-				result := a.executeTool(content.ID, content.Name, content.Input)
-				toolResults = append(toolResults, result)
-			}
+		toolResults, err := a.respond(ctx, message)
+		if errors.Is(err, errPlanDeclined) {
+			fmt.Println("Plan declined, nothing was changed")
+			return nil
+		}
+		if err != nil {
+			return shutdownOr(ctx, err)
 		}
-
 		if len(toolResults) == 0 {
 			readUserInput = true
 			continue
@@ -227,6 +469,188 @@ func (a *Agent) RunWithInitialPrompt(ctx context.Context, initialPrompt string)
 	return nil
 }
 
+// shutdownOr returns err, unless the session was interrupted, in which case
+// the shutdown is announced and the session ends without an error
+func shutdownOr(ctx context.Context, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+	fmt.Println("\nInterrupted, shutting down")
+	return nil
+}
+
+// BatchResult is the outcome of organizing one item of a batch
+type BatchResult struct {
+	Item    string
+	Summary string
+	Err     error
+}
+
+// RunBatch organizes each item in turn, every one in a fresh conversation,
+// and moves on to the next item when one fails
+func (a *Agent) RunBatch(ctx context.Context, items []string, prompts []string) []BatchResult {
+	results := make([]BatchResult, 0, len(items))
+	for i, item := range items {
+		if ctx.Err() != nil {
+			shutdownOr(ctx, nil)
+			break
+		}
+		fmt.Printf("Organizing %s (%d/%d)\n", item, i+1, len(items))
+		a.transcript.User(prompts[i])
+
+		summary, err := a.runToCompletion(ctx, prompts[i])
+		if err != nil {
+			fmt.Printf("Error organizing %s: %v\n", item, err)
+		}
+		results = append(results, BatchResult{Item: item, Summary: summary, Err: err})
+	}
+
+	a.printUsage()
+	return results
+}
+
+// runToCompletion runs a conversation without user turns until Claude stops
+// requesting tools, and returns its final text
+func (a *Agent) runToCompletion(ctx context.Context, prompt string) (string, error) {
+	convo := []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(prompt))}
+	a.resetPlan()
+
+	for turns := 0; ; turns++ {
+		if a.budgetExceeded() {
+			return "", fmt.Errorf("budget of $%.2f reached", a.budget)
+		}
+		if a.turnLimitReached(turns) {
+			return "", fmt.Errorf("reached the limit of %d turns (MAX_TURNS)", a.maxTurns)
+		}
+
+		message, err := a.runInference(ctx, convo)
+		if err != nil {
+			return "", err
+		}
+
+		if isEmptyResponse(message) {
+			a.reportEmptyResponse(message)
+			return "", fmt.Errorf("empty response (stop reason: %s)", message.StopReason)
+		}
+
+		convo = append(convo, message.ToParam())
+
+		toolResults, err := a.respond(ctx, message)
+		if err != nil {
+			return "", err
+		}
+		if len(toolResults) == 0 {
+			var text []string
+			for _, content := range message.Content {
+				if content.Type == "text" {
+					text = append(text, strings.TrimSpace(content.Text))
+				}
+			}
+			return strings.Join(text, " "), nil
+		}
+
+		convo = append(convo, anthropic.NewUserMessage(toolResults...))
+	}
+}
+
+// Usage returns the tokens used so far in the session
+func (a *Agent) Usage() Usage {
+	return a.usage
+}
+
+func (a *Agent) printUsage() {
+	fmt.Printf("Usage: %s\n", a.usage.Summary(a.model))
+}
+
+// budgetExceeded reports whether the estimated cost so far has crossed the
+// configured budget. A budget of 0 means no limit
+func (a *Agent) budgetExceeded() bool {
+	return a.budget > 0 && a.usage.EstimatedCost(a.model) >= a.budget
+}
+
+// defaultMaxTurns caps the requests of a conversation, so a confused Claude
+// can't keep calling tools forever
+const defaultMaxTurns = 50
+
+// maxTurnsFromEnv reads MAX_TURNS. 0 means no limit
+func maxTurnsFromEnv() int {
+	turns, err := strconv.Atoi(os.Getenv("MAX_TURNS"))
+	if err != nil || turns < 0 {
+		return defaultMaxTurns
+	}
+	return turns
+}
+
+// turnLimitReached reports whether a conversation has used up its requests.
+// Only requests to Claude count, user input doesn't
+func (a *Agent) turnLimitReached(turns int) bool {
+	return a.maxTurns > 0 && turns >= a.maxTurns
+}
+
+// isEmptyResponse reports whether a message has neither text nor tool calls,
+// as happens with some refusals or an end_turn without output
+func isEmptyResponse(message *anthropic.Message) bool {
+	for _, content := range message.Content {
+		switch content.Type {
+		case "text":
+			if strings.TrimSpace(content.Text) != "" {
+				return false
+			}
+		case "tool_use":
+			return false
+		}
+	}
+	return true
+}
+
+func (a *Agent) reportEmptyResponse(message *anthropic.Message) {
+	notice := fmt.Sprintf("Claude returned an empty response (stop reason: %s). Please rephrase or give more details.", message.StopReason)
+	fmt.Printf("\u001b[93mClaude\u001b[0m: %s\n", notice)
+	a.transcript.Claude(notice)
+	if err := a.transcript.Flush(); err != nil {
+		fmt.Printf("Error writing transcript: %v\n", err)
+	}
+}
+
+// respond handles a message and returns what to send back to Claude. In
+// plan mode a plan in the message is reviewed first, so its approval
+// covers the tools requested alongside it
+func (a *Agent) respond(ctx context.Context, message *anthropic.Message) ([]anthropic.ContentBlockParamUnion, error) {
+	notice, err := a.reviewPlan(message)
+	if err != nil {
+		return nil, err
+	}
+
+	toolResults := a.handleMessage(ctx, message)
+	if notice != "" {
+		toolResults = append(toolResults, anthropic.NewTextBlock(notice))
+		a.transcript.User(notice)
+	}
+	return toolResults, nil
+}
+
+// handleMessage records Claude's text, already printed while streaming,
+// executes any requested tools and returns their results. The transcript is
+// flushed once the turn is done
+func (a *Agent) handleMessage(ctx context.Context, message *anthropic.Message) []anthropic.ContentBlockParamUnion {
+	toolResults := []anthropic.ContentBlockParamUnion{}
+	for _, content := range message.Content {
+		switch content.Type {
+		case "text":
+			a.transcript.Claude(content.Text)
+		case "tool_use":
+			result := a.executeTool(ctx, content.ID, content.Name, content.Input)
+			toolResults = append(toolResults, result)
+		}
+	}
+
+	if err := a.transcript.Flush(); err != nil {
+		fmt.Printf("Error writing transcript: %v\n", err)
+	}
+
+	return toolResults
+}
+
 func (a *Agent) runInference(ctx context.Context, conversation []anthropic.MessageParam) (*anthropic.Message, error) {
 	anthropicTools := []anthropic.ToolUnionParam{}
 
@@ -240,38 +664,60 @@ func (a *Agent) runInference(ctx context.Context, conversation []anthropic.Messa
 		})
 	}
 
-	message, err := a.client.Messages.New(ctx, anthropic.MessageNewParams{
-		Model:     anthropic.ModelClaude3_7SonnetLatest,
-		MaxTokens: int64(1024),
+	params := anthropic.MessageNewParams{
+		Model:     a.model,
+		MaxTokens: a.maxTokens,
 		Messages:  conversation,
 		Tools:     anthropicTools,
-	})
-	return message, err
+	}
+
+	message, err := a.newMessageWithRetry(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	a.usage.Add(message.Usage)
+	return message, nil
 }
 
-func (a *Agent) executeTool(id, name string, input json.RawMessage) anthropic.ContentBlockParamUnion {
-	var toolDef tools.ToolDefinition
-	var found bool
+func (a *Agent) executeTool(ctx context.Context, id, name string, input json.RawMessage) anthropic.ContentBlockParamUnion {
+	toolDef, found := a.toolsByName[name]
 
-	for _, tool := range a.tools {
-		if tool.Name == name {
-			found = true
-			toolDef = tool
-			break
-		}
-	}
+	a.transcript.ToolCall(name, input)
 
 	if !found {
+		a.transcript.ToolResult(name, "tool not found", true)
+		a.reportToolCall(name, input, "tool not found", true)
 		return anthropic.NewToolResultBlock(id, "tool not found", true)
 	}
 
+	// In plan mode the approved plan replaces confirming each call
+	if a.planMode && isDestructiveCall(a.planTools, toolDef, input) {
+		if refused := a.checkPlannedCall(name, input); refused != "" {
+			a.transcript.ToolResult(name, refused, true)
+			a.reportToolCall(name, input, refused, true)
+			return anthropic.NewToolResultBlock(id, refused, true)
+		}
+	}
+
+	if !a.planMode && a.needsConfirmation(toolDef, input) && !a.confirmToolCall(name, input) {
+		rejected := "The user rejected this operation, nothing was changed. Ask the user how to proceed or propose an alternative"
+		a.transcript.ToolResult(name, rejected, true)
+		a.reportToolCall(name, input, rejected, true)
+		return anthropic.NewToolResultBlock(id, rejected, true)
+	}
+
 	fmt.Printf("\u001b[92mtool\u001b[0m: %s(%s)\n", name, input)
-	response, err := toolDef.Function(input)
+	response, err := toolDef.Call(ctx, input)
 
 	if err != nil {
 		fmt.Printf("\u001b[92mtool\u001b[0m: error: %s\n", err.Error())
+		a.transcript.ToolResult(name, err.Error(), true)
+		a.reportToolCall(name, input, err.Error(), true)
 		return anthropic.NewToolResultBlock(id, err.Error(), true)
 	}
 
+	a.transcript.ToolResult(name, response, false)
+	a.reportToolCall(name, input, response, false)
 	return anthropic.NewToolResultBlock(id, response, false)
 }
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"ojm/tools"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// fakeResponse is one reply of the fake Messages API. A non-zero status
+// fails the request, otherwise the reply streams the text and tool call
+type fakeResponse struct {
+	status      int
+	text        string
+	toolName    string
+	toolInput   string
+	inputTokens int64
+}
+
+// fakeAnthropic serves canned replies to streaming Messages requests in
+// order and records the API key and body of each request
+type fakeAnthropic struct {
+	mu        sync.Mutex
+	responses []fakeResponse
+	keys      []string
+	bodies    []messagesRequest
+}
+
+// messagesRequest is the part of a Messages request the tests look at
+type messagesRequest struct {
+	Messages []struct {
+		Role    string            `json:"role"`
+		Content []json.RawMessage `json:"content"`
+	} `json:"messages"`
+}
+
+// newFakeAnthropic starts the fake API and points new clients at it
+func newFakeAnthropic(t *testing.T, responses ...fakeResponse) *fakeAnthropic {
+	t.Helper()
+	fake := &fakeAnthropic{responses: responses}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body messagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+
+		fake.mu.Lock()
+		fake.keys = append(fake.keys, r.Header.Get("X-Api-Key"))
+		fake.bodies = append(fake.bodies, body)
+		if len(fake.responses) == 0 {
+			fake.mu.Unlock()
+			t.Errorf("unexpected request %d to the Messages API", len(fake.keys))
+			writeAPIError(w, http.StatusBadRequest, "invalid_request_error")
+			return
+		}
+		response := fake.responses[0]
+		fake.responses = fake.responses[1:]
+		fake.mu.Unlock()
+
+		switch response.status {
+		case 0:
+			writeMessageStream(w, response)
+		case http.StatusTooManyRequests:
+			writeAPIError(w, response.status, "rate_limit_error")
+		case http.StatusUnauthorized:
+			writeAPIError(w, response.status, "authentication_error")
+		default:
+			writeAPIError(w, response.status, "api_error")
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("ANTHROPIC_BASE_URL", server.URL)
+	t.Setenv("ANTHROPIC_AUTH_TOKEN", "")
+	return fake
+}
+
+// requests returns the API keys of the requests received so far
+func (f *fakeAnthropic) requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.keys...)
+}
+
+// lastRequest returns the body of the latest request
+func (f *fakeAnthropic) lastRequest() messagesRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.bodies[len(f.bodies)-1]
+}
+
+// messageCounts returns how many messages each request carried
+func (f *fakeAnthropic) messageCounts() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	counts := make([]int, len(f.bodies))
+	for i, body := range f.bodies {
+		counts[i] = len(body.Messages)
+	}
+	return counts
+}
+
+func writeAPIError(w http.ResponseWriter, status int, errorType string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"type":"error","error":{"type":%q,"message":"fake %s"}}`, errorType, errorType)
+}
+
+func writeMessageStream(w http.ResponseWriter, response fakeResponse) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	event := func(name string, data string) {
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+	}
+
+	event("message_start", fmt.Sprintf(`{"type":"message_start","message":{"id":"msg_fake","type":"message","role":"assistant","model":"claude-3-7-sonnet-latest","content":[],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":%d,"output_tokens":0}}}`, response.inputTokens))
+
+	index := 0
+	stopReason := "end_turn"
+	if response.text != "" {
+		text, _ := json.Marshal(response.text)
+		event("content_block_start", fmt.Sprintf(`{"type":"content_block_start","index":%d,"content_block":{"type":"text","text":""}}`, index))
+		event("content_block_delta", fmt.Sprintf(`{"type":"content_block_delta","index":%d,"delta":{"type":"text_delta","text":%s}}`, index, text))
+		event("content_block_stop", fmt.Sprintf(`{"type":"content_block_stop","index":%d}`, index))
+		index++
+	}
+	if response.toolName != "" {
+		input, _ := json.Marshal(response.toolInput)
+		event("content_block_start", fmt.Sprintf(`{"type":"content_block_start","index":%d,"content_block":{"type":"tool_use","id":"toolu_%d","name":%q,"input":{}}}`, index, index, response.toolName))
+		event("content_block_delta", fmt.Sprintf(`{"type":"content_block_delta","index":%d,"delta":{"type":"input_json_delta","partial_json":%s}}`, index, input))
+		event("content_block_stop", fmt.Sprintf(`{"type":"content_block_stop","index":%d}`, index))
+		stopReason = "tool_use"
+	}
+
+	event("message_delta", fmt.Sprintf(`{"type":"message_delta","delta":{"stop_reason":%q,"stop_sequence":null},"usage":{"output_tokens":0}}`, stopReason))
+	event("message_stop", `{"type":"message_stop"}`)
+}
+
+// newTestAgent builds an unattended agent talking to the fake API, with a
+// single harmless tool that counts its calls
+func newTestAgent(t *testing.T, getUserMessage func() (string, bool)) (*Agent, *int) {
+	t.Helper()
+	calls := 0
+	echo := tools.ToolDefinition{
+		Name:        "echo",
+		Description: "Echo the input",
+		InputSchema: tools.GenerateSchema[struct{}](),
+		Function: func(input json.RawMessage) (string, error) {
+			calls++
+			return "ok", nil
+		},
+	}
+
+	agent := NewAgent(newClientForKey("test-key"), getUserMessage, []tools.ToolDefinition{echo})
+	agent.keys = []string{"test-key"}
+	agent.maxAttempts = 1
+	agent.auto = getUserMessage == nil
+	return agent, &calls
+}
+
+func TestBudgetStopsBeforeNextInference(t *testing.T) {
+	// Every reply costs $3, a million input tokens at Sonnet pricing
+	toolCall := fakeResponse{toolName: "echo", toolInput: `{}`, inputTokens: 1_000_000}
+
+	tests := []struct {
+		name         string
+		budget       float64
+		wantRequests int
+		wantCalls    int
+	}{
+		{name: "no budget", budget: 0, wantRequests: 4, wantCalls: 3},
+		{name: "budget trips after the first turn", budget: 2, wantRequests: 1, wantCalls: 1},
+		{name: "budget trips after the second turn", budget: 5, wantRequests: 2, wantCalls: 2},
+		{name: "budget reached exactly", budget: 9, wantRequests: 3, wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeAnthropic(t, toolCall, toolCall, toolCall, fakeResponse{text: "Done", inputTokens: 1_000_000})
+			agent, calls := newTestAgent(t, nil)
+			agent.budget = tt.budget
+
+			if err := agent.RunWithInitialPrompt(context.Background(), "organize"); err != nil {
+				t.Fatalf("RunWithInitialPrompt: %v", err)
+			}
+			if got := len(fake.requests()); got != tt.wantRequests {
+				t.Errorf("sent %d requests, want %d", got, tt.wantRequests)
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("ran the tool %d times, want %d", *calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestBudgetFailsBatchItem(t *testing.T) {
+	toolCall := fakeResponse{toolName: "echo", toolInput: `{}`, inputTokens: 1_000_000}
+	fake := newFakeAnthropic(t, toolCall, toolCall)
+	agent, _ := newTestAgent(t, nil)
+	agent.budget = 5
+
+	summary, err := agent.runToCompletion(context.Background(), "organize")
+	if err == nil {
+		t.Fatalf("runToCompletion = %q, want a budget error", summary)
+	}
+	if got := len(fake.requests()); got != 2 {
+		t.Errorf("sent %d requests, want 2", got)
+	}
+}
+
+func TestIsEmptyResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []anthropic.ContentBlockUnion
+		want    bool
+	}{
+		{name: "no content blocks", want: true},
+		{name: "blank text", content: []anthropic.ContentBlockUnion{{Type: "text", Text: " \n"}}, want: true},
+		{name: "text", content: []anthropic.ContentBlockUnion{{Type: "text", Text: "Done"}}, want: false},
+		{name: "tool call", content: []anthropic.ContentBlockUnion{{Type: "tool_use", Name: "echo"}}, want: false},
+		{name: "blank text and tool call", content: []anthropic.ContentBlockUnion{{Type: "text"}, {Type: "tool_use", Name: "echo"}}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEmptyResponse(&anthropic.Message{Content: tt.content}); got != tt.want {
+				t.Errorf("isEmptyResponse = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptyResponseReturnsControlToUser(t *testing.T) {
+	fake := newFakeAnthropic(t, fakeResponse{}, fakeResponse{text: "Which folder?"})
+
+	var prompts int
+	agent, _ := newTestAgent(t, func() (string, bool) {
+		prompts++
+		if prompts == 1 {
+			return "organize the downloads folder", true
+		}
+		return "", false
+	})
+
+	if err := agent.RunWithInitialPrompt(context.Background(), "organize"); err != nil {
+		t.Fatalf("RunWithInitialPrompt: %v", err)
+	}
+	if got := len(fake.requests()); got != 2 {
+		t.Errorf("sent %d requests, want 2", got)
+	}
+	if prompts != 2 {
+		t.Errorf("asked the user %d times, want 2", prompts)
+	}
+
+	// The empty reply must not be sent back, the API rejects it
+	for _, message := range fake.lastRequest().Messages {
+		if message.Role == "assistant" && len(message.Content) == 0 {
+			t.Errorf("the empty reply was sent back to the API")
+		}
+	}
+}
+
+func TestEmptyResponseFailsBatchItem(t *testing.T) {
+	fake := newFakeAnthropic(t, fakeResponse{})
+	agent, _ := newTestAgent(t, nil)
+
+	summary, err := agent.runToCompletion(context.Background(), "organize")
+	if err == nil {
+		t.Fatalf("runToCompletion = %q, want an empty response error", summary)
+	}
+	if got := len(fake.requests()); got != 1 {
+		t.Errorf("sent %d requests, want 1", got)
+	}
+}
+
+func TestListBatchItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "files and folders", entries: []string{"Movie.2009.mkv", "Show.S01/e01.mkv"}, want: []string{"Movie.2009.mkv", "Show.S01"}},
+		{name: "hidden entries are skipped", entries: []string{".DS_Store", ".partial/a.mkv", "Movie.2009.mkv"}, want: []string{"Movie.2009.mkv"}},
+		{name: "nothing to organize", entries: []string{".DS_Store"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folder := t.TempDir()
+			for _, entry := range tt.entries {
+				path := filepath.Join(folder, filepath.FromSlash(entry))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			items, err := listBatchItems(folder)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listBatchItems error = %v, want error %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, item := range items {
+				got = append(got, filepath.Base(item))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("items = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunBatchContinuesAfterFailure(t *testing.T) {
+	fake := newFakeAnthropic(t,
+		// The first item takes a tool call before Claude is done
+		fakeResponse{toolName: "echo", toolInput: `{}`},
+		fakeResponse{text: "Moved A into Movies"},
+		// The second fails outright
+		fakeResponse{status: http.StatusBadRequest},
+		fakeResponse{text: "Moved C into Shows"},
+	)
+	agent, calls := newTestAgent(t, nil)
+
+	items := []string{"/downloads/A", "/downloads/B", "/downloads/C"}
+	prompts := []string{"organize A", "organize B", "organize C"}
+	results := agent.RunBatch(context.Background(), items, prompts)
+
+	want := []struct {
+		summary string
+		failed  bool
+	}{
+		{summary: "Moved A into Movies"},
+		{failed: true},
+		{summary: "Moved C into Shows"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, result := range results {
+		if result.Item != items[i] {
+			t.Errorf("result %d is for %s, want %s", i, result.Item, items[i])
+		}
+		if (result.Err != nil) != want[i].failed || result.Summary != want[i].summary {
+			t.Errorf("result for %s = %q, %v, want %q and failed %v", result.Item, result.Summary, result.Err, want[i].summary, want[i].failed)
+		}
+	}
+
+	if *calls != 1 {
+		t.Errorf("ran the tool %d times, want 1", *calls)
+	}
+
+	// Every item starts a fresh conversation with only its own prompt
+	if got, want := fake.messageCounts(), []int{1, 3, 1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("requests carried %v messages, want %v", got, want)
+	}
+}
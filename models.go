@@ -0,0 +1,93 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// knownModels are the model constants of the SDK ANTHROPIC_MODEL is checked
+// against
+var knownModels = []anthropic.Model{
+	anthropic.ModelClaude3_7SonnetLatest,
+	anthropic.ModelClaude3_7Sonnet20250219,
+	anthropic.ModelClaude3_5HaikuLatest,
+	anthropic.ModelClaude3_5Haiku20241022,
+	anthropic.ModelClaudeSonnet4_20250514,
+	anthropic.ModelClaudeSonnet4_0,
+	anthropic.ModelClaude4Sonnet20250514,
+	anthropic.ModelClaude3_5SonnetLatest,
+	anthropic.ModelClaude3_5Sonnet20241022,
+	anthropic.ModelClaude_3_5_Sonnet_20240620,
+	anthropic.ModelClaudeOpus4_0,
+	anthropic.ModelClaudeOpus4_20250514,
+	anthropic.ModelClaude4Opus20250514,
+	anthropic.ModelClaude3OpusLatest,
+	anthropic.ModelClaude_3_Opus_20240229,
+	anthropic.ModelClaude_3_Sonnet_20240229,
+	anthropic.ModelClaude_3_Haiku_20240307,
+}
+
+// modelFromEnv returns ANTHROPIC_MODEL, or the default model when it is
+// unset. Unknown names are passed through, as the API may know newer models
+// than this SDK version
+func modelFromEnv() anthropic.Model {
+	name := os.Getenv("ANTHROPIC_MODEL")
+	if name == "" {
+		return defaultModel
+	}
+
+	model := anthropic.Model(name)
+	for _, known := range knownModels {
+		if model == known {
+			return model
+		}
+	}
+	log.Printf("Warning: ANTHROPIC_MODEL %q is not a known model, using it anyway", name)
+	return model
+}
+
+const defaultMaxTokens = 4096
+
+// modelOutputLimits holds the documented maximum output tokens, keyed by
+// model name prefix like modelPricing
+var modelOutputLimits = []struct {
+	prefix string
+	limit  int64
+}{
+	{"claude-3-7-sonnet", 64000},
+	{"claude-3-5-sonnet", 8192},
+	{"claude-3-5-haiku", 8192},
+	{"claude-3-haiku", 4096},
+	{"claude-3-sonnet", 4096},
+	{"claude-3-opus", 4096},
+	{"claude-sonnet-4", 64000},
+	{"claude-4-sonnet", 64000},
+	{"claude-opus-4", 32000},
+	{"claude-4-opus", 32000},
+}
+
+// maxTokensFromEnv returns ANTHROPIC_MAX_TOKENS, or the default when it is
+// unset or invalid, clamped to the output limit of the model
+func maxTokensFromEnv(model anthropic.Model) int64 {
+	maxTokens := int64(defaultMaxTokens)
+	if value := os.Getenv("ANTHROPIC_MAX_TOKENS"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 1 {
+			log.Printf("Warning: ignoring invalid ANTHROPIC_MAX_TOKENS %q, using %d", value, maxTokens)
+		} else {
+			maxTokens = parsed
+		}
+	}
+
+	for _, entry := range modelOutputLimits {
+		if strings.HasPrefix(string(model), entry.prefix) && maxTokens > entry.limit {
+			log.Printf("Max tokens %d exceeds the %d token limit of %s, clamping", maxTokens, entry.limit, model)
+			return entry.limit
+		}
+	}
+	return maxTokens
+}
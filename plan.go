@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// planModeInstruction is appended to the prompt in plan mode, so Claude lays
+// out every change before making any
+const planModeInstruction = `
+
+## Plan mode
+
+Before changing any files, investigate with read-only tools only. Then reply with your complete plan and stop, without calling any tool in that reply. List one step for every call of a tool that changes files, and for batch_rename one step per item, with the exact paths that call will use: source for the path read, moved or removed and target for the path written, leaving out what doesn't apply. Write the plan as JSON inside <plan></plan> tags, in this shape:
+
+<plan>
+{"summary": "one sentence on what will happen", "steps": [{"tool": "rename_jellyfin_media", "description": "what this step does", "source": "/source/path", "target": "/target/path"}]}
+</plan>
+
+Tools that change files are refused until the user has approved the plan. The user approves or declines the whole plan at once. Once approved, carry out exactly the steps of the plan. Calls that don't match a step of the approved plan are refused, to change anything else present a revised plan the same way.`
+
+// errPlanDeclined ends a conversation whose plan the user turned down
+var errPlanDeclined = errors.New("the plan was declined, nothing was changed")
+
+// planApprovedNotice, planRefusedNotice and planMismatchNotice are what
+// Claude is told about the plan and about changes outside of it
+const (
+	planApprovedNotice = "The user approved the plan. Carry it out now."
+	planRefusedNotice  = "This tool changes files and is refused until the user approves a plan. Finish investigating, then present the complete plan in <plan></plan> tags"
+	planMismatchNotice = "This call is not a step of the approved plan, or its step was already carried out, so it was refused and nothing was changed. To make this change, present a revised plan in <plan></plan> tags"
+)
+
+// Plan is the structured plan Claude presents in plan mode
+type Plan struct {
+	Summary string     `json:"summary"`
+	Steps   []PlanStep `json:"steps"`
+}
+
+type PlanStep struct {
+	Tool        string `json:"tool"`
+	Description string `json:"description"`
+	Source      string `json:"source,omitempty"`
+	Target      string `json:"target,omitempty"`
+}
+
+var planPattern = regexp.MustCompile(`(?s)<plan>(.*?)</plan>`)
+
+// parsePlan reads the plan from Claude's text. ok is false when the text has
+// no plan at all
+func parsePlan(text string) (plan Plan, ok bool, err error) {
+	match := planPattern.FindStringSubmatch(text)
+	if match == nil {
+		return Plan{}, false, nil
+	}
+
+	if err := json.Unmarshal([]byte(strings.TrimSpace(match[1])), &plan); err != nil {
+		return Plan{}, true, fmt.Errorf("the plan is not valid JSON: %v", err)
+	}
+	if len(plan.Steps) == 0 {
+		return Plan{}, true, fmt.Errorf("the plan has no steps")
+	}
+	return plan, true, nil
+}
+
+// reviewPlan looks for a plan in a message, shows it and asks the user
+// once. An approved plan replaces the previous one. It returns what to tell
+// Claude about the plan, empty when the message has none, or errPlanDeclined
+func (a *Agent) reviewPlan(message *anthropic.Message) (string, error) {
+	if !a.planMode {
+		return "", nil
+	}
+
+	var text []string
+	for _, content := range message.Content {
+		if content.Type == "text" {
+			text = append(text, content.Text)
+		}
+	}
+
+	plan, ok, err := parsePlan(strings.Join(text, "\n"))
+	if !ok {
+		return "", nil
+	}
+	if err != nil {
+		return fmt.Sprintf("The plan could not be read: %v. Present it again in the requested shape", err), nil
+	}
+
+	printPlan(plan)
+	if !a.confirm("Carry out this plan?") {
+		return "", errPlanDeclined
+	}
+	a.planApproved = true
+	a.planSteps = plan.Steps
+	return planApprovedNotice, nil
+}
+
+// resetPlan forgets the approved plan when a new conversation starts
+func (a *Agent) resetPlan() {
+	a.planApproved = false
+	a.planSteps = nil
+}
+
+// checkPlannedCall matches a call that changes files against the approved
+// plan and uses up the matching steps. Every change the call makes needs a
+// step of its own. It returns why the call is refused, or an empty string
+// when it may run
+func (a *Agent) checkPlannedCall(name string, input json.RawMessage) string {
+	if !a.planApproved {
+		return planRefusedNotice
+	}
+
+	changes, ok := callChanges(input)
+	if !ok {
+		return planMismatchNotice
+	}
+
+	remaining := append([]PlanStep{}, a.planSteps...)
+	for _, change := range changes {
+		matched := -1
+		for i, step := range remaining {
+			if planStepMatches(step, name, change) {
+				matched = i
+				break
+			}
+		}
+		if matched < 0 {
+			return planMismatchNotice
+		}
+		remaining = append(remaining[:matched], remaining[matched+1:]...)
+	}
+	a.planSteps = remaining
+	return ""
+}
+
+// plannedChange is the source and target of one change a call makes, either
+// of which may be empty
+type plannedChange struct {
+	source string
+	target string
+}
+
+// targetPathFields are the input fields that name a path a tool writes. Every
+// other path field names one it reads, moves or removes
+var targetPathFields = map[string]bool{
+	"target":          true,
+	"target_path":     true,
+	"ending_path":     true,
+	"output_path":     true,
+	"manifest_path":   true,
+	"destination":     true,
+	"destination_dir": true,
+	"directory":       true,
+}
+
+// planStepMatches reports whether a change is the given step: the same tool,
+// the same source and the same target
+func planStepMatches(step PlanStep, name string, change plannedChange) bool {
+	return step.Tool == name && cleanPlanPath(step.Source) == change.source && cleanPlanPath(step.Target) == change.target
+}
+
+// callChanges splits a tool input into the changes it makes. Each object in
+// a list, like the items of batch_rename, is a change of its own. ok is false
+// when one change names several sources or targets, which no step can cover
+func callChanges(input json.RawMessage) ([]plannedChange, bool) {
+	var decoded map[string]any
+	if err := json.Unmarshal(input, &decoded); err != nil {
+		return nil, false
+	}
+
+	var changes []plannedChange
+	var collect func(object map[string]any) bool
+	collect = func(object map[string]any) bool {
+		var change plannedChange
+		for key, value := range object {
+			switch value := value.(type) {
+			case string:
+				if !isPathField(key) || value == "" {
+					continue
+				}
+				field := &change.source
+				if targetPathFields[key] {
+					field = &change.target
+				}
+				if *field != "" {
+					return false
+				}
+				*field = cleanPlanPath(value)
+			case []any:
+				for _, item := range value {
+					if item, isObject := item.(map[string]any); isObject && !collect(item) {
+						return false
+					}
+				}
+			}
+		}
+		if change != (plannedChange{}) {
+			changes = append(changes, change)
+		}
+		return true
+	}
+	if !collect(decoded) {
+		return nil, false
+	}
+
+	// Calls without paths, like commit_queue, match steps without paths
+	if len(changes) == 0 {
+		changes = append(changes, plannedChange{})
+	}
+	return changes, true
+}
+
+func isPathField(key string) bool {
+	switch key {
+	case "path", "source", "target", "destination", "directory":
+		return true
+	}
+	return strings.HasSuffix(key, "_path") || strings.HasSuffix(key, "_dir")
+}
+
+func cleanPlanPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	return filepath.Clean(path)
+}
+
+func printPlan(plan Plan) {
+	fmt.Println("\u001b[93mPlan\u001b[0m:")
+	if plan.Summary != "" {
+		fmt.Printf("  %s\n", plan.Summary)
+	}
+	for i, step := range plan.Steps {
+		fmt.Printf("  %d. %s", i+1, step.Description)
+		switch {
+		case step.Source != "" && step.Target != "":
+			fmt.Printf(" (%s: %s -> %s)", step.Tool, step.Source, step.Target)
+		case step.Source != "" || step.Target != "":
+			fmt.Printf(" (%s: %s%s)", step.Tool, step.Source, step.Target)
+		case step.Tool != "":
+			fmt.Printf(" (%s)", step.Tool)
+		}
+		fmt.Println()
+	}
+}
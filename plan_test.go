@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParsePlan(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantOK    bool
+		wantErr   bool
+		wantSteps int
+	}{
+		{name: "no plan", text: "Let me look at the folder first", wantOK: false},
+		{name: "plan", text: "Here it is:\n<plan>\n{\"summary\":\"s\",\"steps\":[{\"tool\":\"copy_file\",\"description\":\"d\"}]}\n</plan>", wantOK: true, wantSteps: 1},
+		{name: "invalid JSON", text: "<plan>not json</plan>", wantOK: true, wantErr: true},
+		{name: "no steps", text: `<plan>{"summary":"s","steps":[]}</plan>`, wantOK: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, ok, err := parsePlan(tt.text)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("parsePlan = %v, %v, want ok %v and error %v", ok, err, tt.wantOK, tt.wantErr)
+			}
+			if len(plan.Steps) != tt.wantSteps {
+				t.Errorf("got %d steps, want %d", len(plan.Steps), tt.wantSteps)
+			}
+		})
+	}
+}
+
+func TestCheckPlannedCall(t *testing.T) {
+	steps := []PlanStep{
+		{Tool: "rename_jellyfin_media", Source: "/downloads/movie.mkv", Target: "/movies/Movie (2009)/Movie (2009).mkv"},
+		{Tool: "delete_file", Source: "/downloads/sample.mkv"},
+		{Tool: "batch_rename", Source: "/downloads/a.mkv", Target: "/movies/A.mkv"},
+		{Tool: "create_directory"},
+		{Tool: "commit_queue"},
+	}
+
+	tests := []struct {
+		name     string
+		approved bool
+		calls    []string
+		inputs   []string
+		want     []string
+	}{
+		{
+			name:   "before approval",
+			calls:  []string{"rename_jellyfin_media"},
+			inputs: []string{`{"source_path":"/downloads/movie.mkv","target_path":"/movies/Movie (2009)/Movie (2009).mkv"}`},
+			want:   []string{planRefusedNotice},
+		},
+		{
+			name:     "planned steps",
+			approved: true,
+			calls:    []string{"rename_jellyfin_media", "delete_file"},
+			inputs:   []string{`{"source_path":"/downloads/movie.mkv","target_path":"/movies/Movie (2009)/./Movie (2009).mkv"}`, `{"path":"/downloads/sample.mkv"}`},
+			want:     []string{"", ""},
+		},
+		{
+			name:     "different target",
+			approved: true,
+			calls:    []string{"rename_jellyfin_media"},
+			inputs:   []string{`{"source_path":"/downloads/movie.mkv","target_path":"/movies/Other (2009)/Other (2009).mkv"}`},
+			want:     []string{planMismatchNotice},
+		},
+		{
+			name:     "step used once",
+			approved: true,
+			calls:    []string{"delete_file", "delete_file"},
+			inputs:   []string{`{"path":"/downloads/sample.mkv"}`, `{"path":"/downloads/sample.mkv"}`},
+			want:     []string{"", planMismatchNotice},
+		},
+		{
+			name:     "tool not in the plan",
+			approved: true,
+			calls:    []string{"copy_file"},
+			inputs:   []string{`{"initial_path":"/downloads/movie.mkv","ending_path":"/movies/Movie (2009)/Movie (2009).mkv"}`},
+			want:     []string{planMismatchNotice},
+		},
+		{
+			name:     "source and target swapped",
+			approved: true,
+			calls:    []string{"rename_jellyfin_media"},
+			inputs:   []string{`{"source_path":"/movies/Movie (2009)/Movie (2009).mkv","target_path":"/downloads/movie.mkv"}`},
+			want:     []string{planMismatchNotice},
+		},
+		{
+			name:     "step without paths",
+			approved: true,
+			calls:    []string{"create_directory", "commit_queue"},
+			inputs:   []string{`{"path":"/movies/Anything"}`, `{}`},
+			want:     []string{planMismatchNotice, ""},
+		},
+		{
+			name:     "batch with an unplanned item",
+			approved: true,
+			calls:    []string{"batch_rename", "batch_rename"},
+			inputs: []string{
+				`{"items":[{"source":"/downloads/a.mkv","target":"/movies/A.mkv"},{"source":"/downloads/b.mkv","target":"/movies/B.mkv"}]}`,
+				`{"items":[{"source":"/downloads/a.mkv","target":"/movies/A.mkv"}]}`,
+			},
+			want: []string{planMismatchNotice, ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := &Agent{planMode: true, planApproved: tt.approved, planSteps: append([]PlanStep{}, steps...)}
+			for i, call := range tt.calls {
+				if got := agent.checkPlannedCall(call, json.RawMessage(tt.inputs[i])); got != tt.want[i] {
+					t.Errorf("call %d (%s) = %q, want %q", i+1, call, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
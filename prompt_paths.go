@@ -0,0 +1,72 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	defaultPromptTemplatePath = "prompt/main.md"
+	defaultJellyfinDocsDir    = "prompt/jellyfin-docs"
+)
+
+// embeddedPrompts holds the default prompt files, used when they aren't
+// found on disk, so an installed binary works from anywhere
+//
+//go:embed prompt/main.md prompt/jellyfin-docs/*.md
+var embeddedPrompts embed.FS
+
+// readPromptTemplate reads PROMPT_TEMPLATE_PATH, or prompt/main.md from disk
+// or from the binary
+func readPromptTemplate() ([]byte, error) {
+	if path, ok := promptPath("PROMPT_TEMPLATE_PATH", defaultPromptTemplatePath); ok {
+		return os.ReadFile(path)
+	}
+	return embeddedPrompts.ReadFile(defaultPromptTemplatePath)
+}
+
+// jellyfinDocsFS returns the folder the Jellyfin docs are read from,
+// JELLYFIN_DOCS_DIR, or prompt/jellyfin-docs from disk or from the binary
+func jellyfinDocsFS() (fs.FS, error) {
+	if path, ok := promptPath("JELLYFIN_DOCS_DIR", defaultJellyfinDocsDir); ok {
+		return os.DirFS(path), nil
+	}
+	return fs.Sub(embeddedPrompts, defaultJellyfinDocsDir)
+}
+
+// promptPath returns the on-disk location of a prompt file and whether there
+// is one. A path configured in envVar is always used, so a typo is an error
+// instead of silently falling back to the embedded default
+func promptPath(envVar, def string) (string, bool) {
+	if configured := os.Getenv(envVar); configured != "" {
+		return resolvePromptPath(configured), true
+	}
+	path := resolvePromptPath(def)
+	_, err := os.Stat(path)
+	return path, err == nil
+}
+
+// resolvePromptPath resolves a relative path against PROMPT_BASE_DIR when it
+// is set. Otherwise the working directory is tried first, so running from the
+// repository keeps working, then the folder of the executable, so an
+// installed binary finds the prompt files installed next to it
+func resolvePromptPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	if base := os.Getenv("PROMPT_BASE_DIR"); base != "" {
+		return filepath.Join(base, path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	if executable, err := os.Executable(); err == nil {
+		candidate := filepath.Join(filepath.Dir(executable), path)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return path
+}
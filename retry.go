@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	defaultMaxAttempts = 4
+	retryBaseDelay     = time.Second
+	retryMaxDelay      = 30 * time.Second
+)
+
+// maxAttemptsFromEnv returns ANTHROPIC_MAX_ATTEMPTS, the number of times a
+// request is tried before giving up, or the default when it is unset
+func maxAttemptsFromEnv() int {
+	attempts, err := strconv.Atoi(os.Getenv("ANTHROPIC_MAX_ATTEMPTS"))
+	if err != nil || attempts < 1 {
+		return defaultMaxAttempts
+	}
+	return attempts
+}
+
+// newMessageWithRetry sends a request, retrying transient failures with
+// exponential backoff and jitter. Client errors other than rate limits fail
+// right away
+func (a *Agent) newMessageWithRetry(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	maxAttempts := max(a.maxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		message, err := a.newMessage(ctx, params)
+		if err == nil || attempt >= maxAttempts || !isRetryable(err) || ctx.Err() != nil {
+			return message, err
+		}
+
+		delay := backoffDelay(attempt)
+		fmt.Printf("Request failed: %v. Retrying in %s (attempt %d of %d)\n", err, delay.Round(time.Millisecond), attempt+1, maxAttempts)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// newMessage sends a request once per configured key, moving on to the next
+// key while the current one is rate limited
+func (a *Agent) newMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	message, err := a.streamMessage(ctx, params)
+	for tried := 1; isRateLimited(err) && tried < len(a.keys); tried++ {
+		a.rotateKey()
+		message, err = a.streamMessage(ctx, params)
+	}
+	return message, err
+}
+
+// isRetryable reports whether a request failed in a way that may succeed on
+// a later try: rate limits, timeouts, server errors including 529 overloaded,
+// and network errors. Streams that broke off after printing are not retried
+func isRetryable(err error) bool {
+	var partialErr *partialResponseError
+	if errors.Is(err, context.Canceled) || errors.As(err, &partialErr) {
+		return false
+	}
+
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) {
+		switch code := apiErr.StatusCode; {
+		case code == http.StatusTooManyRequests, code == http.StatusRequestTimeout, code == http.StatusConflict:
+			return true
+		default:
+			return code >= 500
+		}
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// backoffDelay doubles the delay with every attempt up to a cap, picking a
+// random delay up to that bound so retries from several clients spread out
+func backoffDelay(attempt int) time.Duration {
+	bound := retryMaxDelay
+	if attempt < 16 {
+		bound = min(retryBaseDelay<<(attempt-1), retryMaxDelay)
+	}
+	return bound/2 + time.Duration(rand.Int63n(int64(bound/2)+1))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// partialResponseError is returned when a stream breaks after Claude's text
+// was already printed. It is not retried, as that would repeat the output
+type partialResponseError struct {
+	err error
+}
+
+func (e *partialResponseError) Error() string {
+	return fmt.Sprintf("response interrupted after partial output: %v", e.err)
+}
+
+func (e *partialResponseError) Unwrap() error {
+	return e.err
+}
+
+// streamMessage sends a request with the streaming API, printing Claude's
+// text as it arrives, and returns the accumulated message
+func (a *Agent) streamMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	stream := a.client.Messages.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	message := anthropic.Message{}
+	printing := false
+	for stream.Next() {
+		event := stream.Current()
+		if err := message.Accumulate(event); err != nil {
+			return nil, a.interrupted(&message, printing, err)
+		}
+
+		switch event := event.AsAny().(type) {
+		case anthropic.ContentBlockStartEvent:
+			if event.ContentBlock.Type == "text" {
+				fmt.Printf("\u001b[93mClaude\u001b[0m: %s", event.ContentBlock.Text)
+				printing = true
+			}
+		case anthropic.ContentBlockDeltaEvent:
+			if delta, ok := event.Delta.AsAny().(anthropic.TextDelta); ok {
+				fmt.Print(delta.Text)
+			}
+		case anthropic.ContentBlockStopEvent:
+			if printing {
+				fmt.Println()
+				printing = false
+			}
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return nil, a.interrupted(&message, printing, err)
+	}
+	return &message, nil
+}
+
+// interrupted ends a half printed line and wraps the error as partial when
+// some of the response already made it out
+func (a *Agent) interrupted(message *anthropic.Message, printing bool, err error) error {
+	if printing {
+		fmt.Println()
+	}
+	if len(message.Content) == 0 {
+		return err
+	}
+	return &partialResponseError{err: err}
+}
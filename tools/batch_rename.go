@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type BatchRenameItem struct {
+	Source string `json:"source" jsonschema_description:"The file or folder to move, in SOURCE_FOLDER or a library."`
+	Target string `json:"target" jsonschema_description:"Where to move it, within JELLYFIN_SHOWS_FOLDER or JELLYFIN_MOVIES_FOLDER."`
+}
+
+type BatchRenameInput struct {
+	Items []BatchRenameItem `json:"items" jsonschema_description:"The moves to make, e.g. every episode of a season. They are applied in order."`
+}
+
+var BatchRenameInputSchema = GenerateSchema[BatchRenameInput]()
+
+var BatchRenameDefinition = ToolDefinition{
+	Name:        "batch_rename",
+	Description: "Move or rename many files in one call, e.g. all episodes of a season. Every pair is validated before anything is moved, and if a move fails the completed ones are moved back, so the batch is applied completely or not at all. Returns the outcome of each item. Prefer this over many rename_jellyfin_media calls.",
+	InputSchema: BatchRenameInputSchema,
+	Contextual:  BatchRename,
+}
+
+// BatchRenameResult is the outcome of one item: moved, invalid, failed,
+// rolled_back or skipped
+type BatchRenameResult struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BatchRenameOutput struct {
+	Applied bool                `json:"applied"`
+	Error   string              `json:"error,omitempty"`
+	Items   []BatchRenameResult `json:"items"`
+}
+
+func BatchRename(ctx context.Context, input json.RawMessage) (string, error) {
+	batchInput := BatchRenameInput{}
+	err := json.Unmarshal(input, &batchInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if len(batchInput.Items) == 0 {
+		return "", fmt.Errorf("items must not be empty")
+	}
+
+	output := batchRename(ctx, batchInput.Items)
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// batchRename validates all items, then moves them in order. Items that fail
+// validation stop the batch before anything is moved
+func batchRename(ctx context.Context, items []BatchRenameItem) BatchRenameOutput {
+	output := BatchRenameOutput{Items: make([]BatchRenameResult, len(items))}
+	sources := map[string]bool{}
+	targets := map[string]bool{}
+	invalid := 0
+	for i, item := range items {
+		result := BatchRenameResult{Source: item.Source, Target: item.Target, Status: "pending"}
+		target, err := sanitizePath(item.Target)
+		if err == nil {
+			result.Target = target
+			err = checkBatchRenameItem(item.Source, target, sources, targets)
+		}
+		if err != nil {
+			result.Status = "invalid"
+			result.Error = err.Error()
+			invalid++
+		}
+		sources[item.Source] = true
+		targets[result.Target] = true
+		output.Items[i] = result
+	}
+
+	if invalid > 0 {
+		output.Error = fmt.Sprintf("%d of %d items are invalid, nothing was moved", invalid, len(items))
+		markPending(output.Items, "skipped")
+		return output
+	}
+
+	if DryRun {
+		output.Error = dryRunPrefix + "nothing was moved"
+		markPending(output.Items, "skipped")
+		return output
+	}
+
+	var tx transaction
+	for i := range output.Items {
+		result := &output.Items[i]
+		source, target := result.Source, result.Target
+		if _, err := moveMediaReplacing(ctx, source, target, false); err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			output.Error = rollbackError(fmt.Errorf("moving item %d failed", i+1), tx.rollback()).Error()
+			for j := range i {
+				output.Items[j].Status = "rolled_back"
+			}
+			markPending(output.Items, "skipped")
+			return output
+		}
+		result.Status = "moved"
+		tx.add("move of "+source, undoMove(source, target))
+	}
+
+	output.Applied = true
+	return output
+}
+
+// checkBatchRenameItem checks one pair up front, including clashes with the
+// other items of the batch
+func checkBatchRenameItem(source, target string, sources, targets map[string]bool) error {
+	if err := ValidatePath(source); err != nil {
+		return fmt.Errorf("invalid source path: %v", err)
+	}
+	if err := ValidateLibraryPath(target); err != nil {
+		return fmt.Errorf("invalid target path: %v", err)
+	}
+	if sources[source] {
+		return fmt.Errorf("source is listed more than once")
+	}
+	if targets[target] {
+		return fmt.Errorf("target is listed more than once")
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return err
+	}
+	if _, err := fsys.Stat(source); err != nil {
+		return fmt.Errorf("source path does not exist: %s", source)
+	}
+	if _, err := fsys.Stat(target); err == nil {
+		return fmt.Errorf("target path already exists: %s", target)
+	}
+	return nil
+}
+
+// markPending sets the status of every item that wasn't handled
+func markPending(items []BatchRenameResult, status string) {
+	for i := range items {
+		if items[i].Status == "pending" {
+			items[i].Status = status
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type BuildMoviePathInput struct {
+	Title      string `json:"title" jsonschema_description:"The movie title, e.g. 'Heat'."`
+	Year       int    `json:"year" jsonschema_description:"The release year, or 0 if unknown."`
+	IMDbID     string `json:"imdb_id" jsonschema_description:"Optional IMDb id, added to the folder name as [imdbid-tt...] to pin the identification."`
+	Edition    string `json:"edition" jsonschema_description:"Optional edition for one of several versions of the movie, e.g. 'Extended' or 'Directors Cut'."`
+	Resolution string `json:"resolution" jsonschema_description:"Optional resolution tag to tell versions apart, e.g. '2160p' or '1080p'."`
+	Extension  string `json:"extension" jsonschema_description:"The video file extension, e.g. '.mkv'. Defaults to .mkv."`
+}
+
+var BuildMoviePathInputSchema = GenerateSchema[BuildMoviePathInput]()
+
+var BuildMoviePathDefinition = ToolDefinition{
+	Name:        "build_movie_path",
+	Description: "Build the Jellyfin path for a movie file in the movies library, like 'Movie (2009)/Movie (2009) - edition-Extended [2160p].mkv'. Use it for every movie target instead of composing names by hand, so editions and versions of a movie are named consistently and end up in the same folder. Nothing is created.",
+	InputSchema: BuildMoviePathInputSchema,
+	Function:    BuildMoviePath,
+}
+
+type BuildMoviePathOutput struct {
+	Folder string `json:"folder"`
+	File   string `json:"file"`
+	Path   string `json:"path"`
+}
+
+var resolutionTagPattern = regexp.MustCompile(`(?i)^(\d{3,4}[pi]|4k|8k)$`)
+
+func BuildMoviePath(input json.RawMessage) (string, error) {
+	buildInput := BuildMoviePathInput{}
+	err := json.Unmarshal(input, &buildInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	output, err := buildMoviePath(buildInput)
+	if err != nil {
+		return "", err
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// buildMoviePath names the folder "Title (Year) [imdbid-id]" and the file
+// after the folder, followed by " - edition-Name" and "[resolution]" when
+// given. Versions of a movie thus share the folder and
+// only differ after the " - " Jellyfin uses to tell versions apart
+func buildMoviePath(input BuildMoviePathInput) (BuildMoviePathOutput, error) {
+	rules, err := filenameRules("")
+	if err != nil {
+		return BuildMoviePathOutput{}, err
+	}
+
+	title := sanitizeFilename(input.Title, rules)
+	if title == "" {
+		return BuildMoviePathOutput{}, fmt.Errorf("title is required")
+	}
+
+	folder := titleYearName(title, input.Year)
+	if id := strings.TrimSpace(input.IMDbID); id != "" {
+		if !imdbIDPattern.MatchString(id) {
+			return BuildMoviePathOutput{}, fmt.Errorf("invalid IMDb id %q, expected something like tt0113277", id)
+		}
+		folder += " [imdbid-" + id + "]"
+	}
+
+	var tags []string
+	if edition := sanitizeFilename(input.Edition, rules); edition != "" {
+		tags = append(tags, "edition-"+edition)
+	}
+	if resolution := strings.TrimSpace(input.Resolution); resolution != "" {
+		if !resolutionTagPattern.MatchString(resolution) {
+			return BuildMoviePathOutput{}, fmt.Errorf("invalid resolution %q, expected something like 2160p or 1080p", resolution)
+		}
+		tags = append(tags, "["+strings.ToLower(resolution)+"]")
+	}
+
+	file := folder
+	if len(tags) > 0 {
+		file += " - " + strings.Join(tags, " ")
+	}
+
+	extension := strings.ToLower(strings.TrimSpace(input.Extension))
+	if extension == "" {
+		extension = ".mkv"
+	}
+	if !strings.HasPrefix(extension, ".") {
+		extension = "." + extension
+	}
+	if !isVideoFile(file + extension) {
+		return BuildMoviePathOutput{}, fmt.Errorf("%s is not a video file extension", extension)
+	}
+	file += extension
+
+	return BuildMoviePathOutput{
+		Folder: folder,
+		File:   file,
+		Path:   filepath.Join(os.Getenv("JELLYFIN_MOVIES_FOLDER"), folder, file),
+	}, nil
+}
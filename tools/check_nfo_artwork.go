@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type CheckNFOArtworkInput struct {
+	NFOPath string `json:"nfo_path" jsonschema_description:"The .nfo file whose artwork references should be checked. Must be within the permitted folders."`
+	Fix     bool   `json:"fix" jsonschema_description:"If true, broken local references are rewritten to point at matching artwork files next to the NFO. If false, only a report is returned."`
+}
+
+var CheckNFOArtworkInputSchema = GenerateSchema[CheckNFOArtworkInput]()
+
+var CheckNFOArtworkDefinition = ToolDefinition{
+	Name:           "check_nfo_artwork",
+	Description:    "Check that the thumb and fanart artwork paths referenced by an NFO file exist. Broken references are reported together with a matching artwork file found next to the NFO (poster.jpg, folder.jpg, fanart.jpg, backdrop.jpg, ...). Set fix to rewrite broken references to those files. Remote URLs are not checked.",
+	InputSchema:    CheckNFOArtworkInputSchema,
+	Function:       CheckNFOArtwork,
+	WritesOnlyWith: "fix",
+}
+
+type ArtworkReference struct {
+	Reference   string `json:"reference"`
+	Kind        string `json:"kind"`
+	Status      string `json:"status"`
+	Replacement string `json:"replacement,omitempty"`
+	Fixed       bool   `json:"fixed"`
+}
+
+var (
+	thumbElementPattern  = regexp.MustCompile(`<thumb[^>]*>([^<]*)</thumb>`)
+	fanartElementPattern = regexp.MustCompile(`(?s)<fanart[^>]*>.*?</fanart>`)
+	imageExtensions      = []string{".jpg", ".jpeg", ".png", ".webp", ".tbn"}
+)
+
+func CheckNFOArtwork(input json.RawMessage) (string, error) {
+	checkInput := CheckNFOArtworkInput{}
+	err := json.Unmarshal(input, &checkInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	nfoPath := checkInput.NFOPath
+	if err := ValidatePath(nfoPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	content, err := readMediaFile(fsys, nfoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read NFO: %v", err)
+	}
+	nfo := string(content)
+	nfoDir := filepath.Dir(nfoPath)
+
+	fanartSpans := fanartElementPattern.FindAllStringIndex(nfo, -1)
+
+	var references []ArtworkReference
+	var edits [][3]int
+	for _, match := range thumbElementPattern.FindAllStringSubmatchIndex(nfo, -1) {
+		raw := nfo[match[2]:match[3]]
+		reference := strings.TrimSpace(html.UnescapeString(raw))
+		if reference == "" {
+			continue
+		}
+
+		kind := "poster"
+		for _, span := range fanartSpans {
+			if match[0] >= span[0] && match[1] <= span[1] {
+				kind = "fanart"
+			}
+		}
+		if strings.Contains(nfo[match[0]:match[2]], `aspect="fanart"`) || strings.Contains(nfo[match[0]:match[2]], `aspect="landscape"`) {
+			kind = "fanart"
+		}
+
+		artwork := ArtworkReference{Reference: reference, Kind: kind}
+
+		if strings.HasPrefix(reference, "http://") || strings.HasPrefix(reference, "https://") {
+			artwork.Status = "remote"
+			references = append(references, artwork)
+			continue
+		}
+
+		artworkPath := reference
+		if !filepath.IsAbs(artworkPath) {
+			artworkPath = filepath.Join(nfoDir, artworkPath)
+		}
+
+		if _, err := fsys.Stat(artworkPath); err == nil {
+			artwork.Status = "ok"
+			references = append(references, artwork)
+			continue
+		}
+
+		artwork.Status = "missing"
+		if candidate := findNeighborArtwork(fsys, nfoPath, kind); candidate != "" {
+			// Keep the style of the original reference
+			if filepath.IsAbs(reference) {
+				artwork.Replacement = filepath.Join(nfoDir, candidate)
+			} else {
+				artwork.Replacement = candidate
+			}
+			edits = append(edits, [3]int{match[2], match[3], len(references)})
+		}
+		references = append(references, artwork)
+	}
+
+	if checkInput.Fix && len(edits) > 0 {
+		// Splice replacements in from the end so earlier offsets stay valid
+		fixed := nfo
+		for i := len(edits) - 1; i >= 0; i-- {
+			start, end, index := edits[i][0], edits[i][1], edits[i][2]
+			fixed = fixed[:start] + escapeXMLText(references[index].Replacement) + fixed[end:]
+			references[index].Fixed = true
+		}
+		if !DryRun {
+			if err := writeMediaFile(fsys, nfoPath, []byte(fixed)); err != nil {
+				return "", fmt.Errorf("failed to write NFO: %v", err)
+			}
+		}
+	}
+
+	jsonData, err := json.Marshal(references)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if checkInput.Fix && DryRun {
+		return dryRunOutput(jsonData), nil
+	}
+
+	return string(jsonData), nil
+}
+
+// findNeighborArtwork returns the name of an artwork file of the given kind
+// next to the NFO, or an empty string if there is none
+func findNeighborArtwork(fsys MediaFS, nfoPath, kind string) string {
+	base := strings.TrimSuffix(filepath.Base(nfoPath), filepath.Ext(nfoPath))
+
+	names := []string{"poster", "folder", "cover", base + "-poster", base}
+	if kind == "fanart" {
+		names = []string{"fanart", "backdrop", "landscape", base + "-fanart", base + "-backdrop"}
+	}
+
+	nfoDir := filepath.Dir(nfoPath)
+	for _, name := range names {
+		for _, ext := range imageExtensions {
+			if _, err := fsys.Stat(filepath.Join(nfoDir, name+ext)); err == nil {
+				return name + ext
+			}
+		}
+	}
+
+	return ""
+}
+
+func escapeXMLText(text string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type CheckTargetCollisionInput struct {
+	Title     string  `json:"title" jsonschema_description:"The title of the proposed target, e.g. 'The Office'."`
+	Year      int     `json:"year" jsonschema_description:"The release year of the proposed target, or 0 if unknown."`
+	MediaType string  `json:"media_type" jsonschema_description:"The library to check: 'movie' or 'show'."`
+	Threshold float64 `json:"threshold" jsonschema_description:"Similarity between 0 and 1 above which a folder is reported as a near collision. If 0, defaults to 0.85."`
+}
+
+var CheckTargetCollisionInputSchema = GenerateSchema[CheckTargetCollisionInput]()
+
+var CheckTargetCollisionDefinition = ToolDefinition{
+	Name:        "check_target_collision",
+	Description: "Before moving or renaming an item, check the target library for existing folders Jellyfin would treat as the same item. Titles are compared normalized (case, punctuation, articles and provider id tags ignored). Returns exact collisions, where the normalized title matches and the years agree, and near collisions, like different spellings or the same title with another year.",
+	InputSchema: CheckTargetCollisionInputSchema,
+	Function:    CheckTargetCollision,
+}
+
+type TargetCollision struct {
+	Path       string  `json:"path"`
+	Kind       string  `json:"kind"`
+	Similarity float64 `json:"similarity"`
+	Year       int     `json:"year,omitempty"`
+}
+
+type CheckTargetCollisionOutput struct {
+	Library    string            `json:"library"`
+	Clean      bool              `json:"clean"`
+	Collisions []TargetCollision `json:"collisions"`
+}
+
+func CheckTargetCollision(input json.RawMessage) (string, error) {
+	collisionInput := CheckTargetCollisionInput{}
+	err := json.Unmarshal(input, &collisionInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	var library string
+	switch collisionInput.MediaType {
+	case "movie":
+		library = os.Getenv("JELLYFIN_MOVIES_FOLDER")
+	case "show":
+		library = os.Getenv("JELLYFIN_SHOWS_FOLDER")
+	default:
+		return "", fmt.Errorf("media_type must be 'movie' or 'show', got %q", collisionInput.MediaType)
+	}
+
+	if err := ValidatePath(library); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	threshold := collisionInput.Threshold
+	if threshold <= 0 {
+		threshold = 0.85
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := fsys.ReadDir(library)
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		// Movies may still sit loose in the library root
+		if entry.IsDir() || (collisionInput.MediaType == "movie" && isVideoFile(entry.Name())) {
+			names = append(names, entry.Name())
+		}
+	}
+
+	output := CheckTargetCollisionOutput{
+		Library:    library,
+		Collisions: findTitleCollisions(collisionInput.Title, collisionInput.Year, names, threshold),
+	}
+	for i := range output.Collisions {
+		output.Collisions[i].Path = filepath.Join(library, output.Collisions[i].Path)
+	}
+	output.Clean = len(output.Collisions) == 0
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// findTitleCollisions compares a proposed title and year against existing
+// item names. An unknown year on either side doesn't rule out a match
+func findTitleCollisions(title string, year int, names []string, threshold float64) []TargetCollision {
+	collisions := []TargetCollision{}
+	proposed := normalizeTitle(title)
+
+	for _, name := range names {
+		existing := parseReleaseName(name)
+		similarity := titleSimilarity(proposed, normalizeTitle(existing.Title))
+		if similarity < threshold {
+			continue
+		}
+
+		sameYear := year == 0 || existing.Year == 0 || year == existing.Year
+		kind := "near"
+		if similarity == 1 && sameYear {
+			kind = "exact"
+		}
+
+		collisions = append(collisions, TargetCollision{
+			Path:       name,
+			Kind:       kind,
+			Similarity: similarity,
+			Year:       existing.Year,
+		})
+	}
+
+	return collisions
+}
@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+type CleanEpisodeTitleInput struct {
+	Path  string `json:"path" jsonschema_description:"The episode file whose name contains SxxEyy followed by an episode title."`
+	Apply bool   `json:"apply" jsonschema_description:"If true, the file and its sidecars are renamed. If false, the corrected name is only proposed."`
+}
+
+var CleanEpisodeTitleInputSchema = GenerateSchema[CleanEpisodeTitleInput]()
+
+var CleanEpisodeTitleDefinition = ToolDefinition{
+	Name:           "clean_episode_title",
+	Description:    "Clean the episode title part of an SxxEyy file name, removing leading and trailing junk such as [1080p], WEB-DL.x264 or a -GROUP suffix while keeping the real title, e.g. 'Show S01E02 - Pilot [1080p]-NTb.mkv' -> 'Show S01E02 - Pilot.mkv'. Set apply to rename the file and its sidecars.",
+	InputSchema:    CleanEpisodeTitleInputSchema,
+	Function:       CleanEpisodeTitle,
+	WritesOnlyWith: "apply",
+}
+
+type CleanEpisodeTitleOutput struct {
+	Current  string             `json:"current"`
+	Proposed string             `json:"proposed"`
+	Changed  bool               `json:"changed"`
+	Moves    []CrossLibraryMove `json:"moves,omitempty"`
+	Applied  bool               `json:"applied"`
+}
+
+var (
+	edgeBracketPattern = regexp.MustCompile(`^\s*\[[^\]]*\]|\[[^\]]*\]\s*$`)
+	groupSuffixPattern = regexp.MustCompile(`-([A-Za-z0-9]+)$`)
+)
+
+func CleanEpisodeTitle(input json.RawMessage) (string, error) {
+	cleanInput := CleanEpisodeTitleInput{}
+	err := json.Unmarshal(input, &cleanInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	path := cleanInput.Path
+	if err := ValidatePath(path); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	name := filepath.Base(path)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	loc := releaseEpisodePattern.FindStringIndex(base)
+	if loc == nil {
+		return "", fmt.Errorf("no SxxEyy episode marker in %s", name)
+	}
+
+	proposedBase := strings.TrimRight(base[:loc[1]], " ")
+	if title := cleanEpisodeTitle(base[loc[1]:]); title != "" {
+		proposedBase += " - " + title
+	}
+
+	output := CleanEpisodeTitleOutput{
+		Current:  name,
+		Proposed: proposedBase + ext,
+		Changed:  proposedBase != base,
+	}
+
+	if output.Changed {
+		fsys, err := GetMediaFS()
+		if err != nil {
+			return "", err
+		}
+		files, err := sidecarFiles(fsys, path)
+		if err != nil {
+			return "", err
+		}
+		dir := filepath.Dir(path)
+		for _, file := range files {
+			target := filepath.Join(dir, proposedBase+strings.TrimPrefix(filepath.Base(file), base))
+			if _, err := fsys.Stat(target); err == nil {
+				return "", fmt.Errorf("target path already exists: %s", target)
+			}
+			output.Moves = append(output.Moves, CrossLibraryMove{Source: file, Target: target})
+		}
+	}
+
+	if cleanInput.Apply && output.Changed && !DryRun {
+		var tx transaction
+		for _, move := range output.Moves {
+			source, target := move.Source, move.Target
+			if err := moveMedia(source, target); err != nil {
+				return "", rollbackError(fmt.Errorf("failed to rename %s: %v", source, err), tx.rollback())
+			}
+			tx.add("rename of "+source, undoMove(source, target))
+		}
+		output.Applied = true
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if cleanInput.Apply && DryRun {
+		return dryRunOutput(jsonData), nil
+	}
+
+	return string(jsonData), nil
+}
+
+// cleanEpisodeTitle strips release junk from the text following an episode
+// marker and returns the bare episode title, or "" if nothing is left
+func cleanEpisodeTitle(title string) string {
+	title = strings.Trim(title, " -._")
+
+	// Dotted release names use dots between the words of the title too
+	if !strings.Contains(title, " ") {
+		title = strings.NewReplacer(".", " ", "_", " ").Replace(title)
+	}
+
+	for {
+		trimmed := strings.TrimSpace(edgeBracketPattern.ReplaceAllString(title, ""))
+		if trimmed == title {
+			break
+		}
+		title = trimmed
+	}
+
+	// Everything from the first quality or release tag on is junk, including
+	// the group name that usually closes it
+	cut := len(title)
+	if loc := releaseResolutionPattern.FindStringIndex(title); loc != nil {
+		cut = min(cut, loc[0])
+	}
+	if loc := releaseTagPattern.FindStringIndex(title); loc != nil {
+		cut = min(cut, loc[0])
+	}
+	title = strings.Trim(title[:cut], " -._[(")
+
+	// A bare -GROUP suffix is only dropped when it looks like a group tag, so
+	// hyphenated titles like Spider-Man are kept
+	if match := groupSuffixPattern.FindStringSubmatch(title); match != nil && looksLikeGroupTag(match[1]) {
+		title = strings.Trim(strings.TrimSuffix(title, match[0]), " -._")
+	}
+
+	return title
+}
+
+// looksLikeGroupTag reports whether a word is written like a release group,
+// all capitals or digits (FLUX, NTB) or with capitals after the first letter
+// (NTb, iNTERNAL)
+func looksLikeGroupTag(word string) bool {
+	if len(word) < 2 {
+		return false
+	}
+	if strings.ToUpper(word) == word {
+		return true
+	}
+	for i, r := range word {
+		if i > 0 && unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type CompareQualitiesInput struct {
+	PathA         string `json:"path_a" jsonschema_description:"The first video file."`
+	PathB         string `json:"path_b" jsonschema_description:"The second video file."`
+	CheckDuration bool   `json:"check_duration" jsonschema_description:"If true, the durations are compared with ffprobe to confirm both files hold the same content."`
+}
+
+var CompareQualitiesInputSchema = GenerateSchema[CompareQualitiesInput]()
+
+var CompareQualitiesDefinition = ToolDefinition{
+	Name:        "compare_qualities",
+	Description: "Decide whether two video files are the same movie at different qualities, like Movie.1080p.mkv and Movie.2160p.mkv, to plan upgrades. Compares the parsed titles and years and optionally the durations via ffprobe. Returns a verdict (same_movie, different_movie or uncertain) and which file has the higher quality.",
+	InputSchema: CompareQualitiesInputSchema,
+	Function:    CompareQualities,
+}
+
+type ComparedFile struct {
+	Path       string  `json:"path"`
+	Title      string  `json:"title"`
+	Year       int     `json:"year,omitempty"`
+	Resolution string  `json:"resolution,omitempty"`
+	Size       int64   `json:"size"`
+	Duration   float64 `json:"duration_seconds,omitempty"`
+}
+
+type CompareQualitiesOutput struct {
+	Verdict       string       `json:"verdict"`
+	Reasons       []string     `json:"reasons"`
+	HigherQuality string       `json:"higher_quality,omitempty"`
+	A             ComparedFile `json:"a"`
+	B             ComparedFile `json:"b"`
+}
+
+// durationTolerance is how far apart two cuts of the same film may be, as a
+// fraction of the longer one. Different releases often differ by intros or
+// studio logos
+const durationTolerance = 0.02
+
+var resolutionRank = map[string]int{"480p": 1, "576p": 2, "720p": 3, "1080i": 4, "1080p": 5, "2160p": 6}
+
+// probeDuration returns the duration of a media file in seconds
+func probeDuration(path string) (float64, error) {
+	out, err := runFFprobe(context.Background(), path,
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+	)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+}
+
+func CompareQualities(input json.RawMessage) (string, error) {
+	compareInput := CompareQualitiesInput{}
+	err := json.Unmarshal(input, &compareInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	var files [2]ComparedFile
+	for i, path := range []string{compareInput.PathA, compareInput.PathB} {
+		if err := ValidatePath(path); err != nil {
+			return "", fmt.Errorf("access denied: %v", err)
+		}
+
+		info, err := fsys.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("file does not exist: %s", path)
+		}
+
+		files[i] = describeComparedFile(path, info.Size())
+		if compareInput.CheckDuration {
+			if IsRemoteMedia() {
+				return "", fmt.Errorf("probing is only supported for local media")
+			}
+			files[i].Duration, err = probeDuration(path)
+			if err != nil {
+				return "", fmt.Errorf("ffprobe failed on %s: %v", path, err)
+			}
+		}
+	}
+
+	output := compareFiles(files[0], files[1])
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// describeComparedFile parses a video's name, falling back to its folder
+// name for titles and years the file name doesn't carry
+func describeComparedFile(path string, size int64) ComparedFile {
+	info := parseReleaseName(filepath.Base(path))
+	folder := parseReleaseName(filepath.Base(filepath.Dir(path)))
+	if info.Title == "" {
+		info.Title = folder.Title
+	}
+	if info.Year == 0 {
+		info.Year = folder.Year
+	}
+
+	return ComparedFile{
+		Path:       path,
+		Title:      info.Title,
+		Year:       info.Year,
+		Resolution: info.Resolution,
+		Size:       size,
+	}
+}
+
+func compareFiles(a, b ComparedFile) CompareQualitiesOutput {
+	output := CompareQualitiesOutput{A: a, B: b, Reasons: []string{}}
+
+	similarity := titleSimilarity(normalizeTitle(a.Title), normalizeTitle(b.Title))
+	sameTitle := similarity >= 0.9
+	if sameTitle {
+		output.Reasons = append(output.Reasons, fmt.Sprintf("titles match (similarity %.2f)", similarity))
+	} else {
+		output.Reasons = append(output.Reasons, fmt.Sprintf("titles differ: %q vs %q", a.Title, b.Title))
+	}
+
+	yearsConflict := a.Year > 0 && b.Year > 0 && a.Year != b.Year
+	switch {
+	case yearsConflict:
+		output.Reasons = append(output.Reasons, fmt.Sprintf("years differ: %d vs %d", a.Year, b.Year))
+	case a.Year == 0 || b.Year == 0:
+		output.Reasons = append(output.Reasons, "year missing from at least one name")
+	default:
+		output.Reasons = append(output.Reasons, fmt.Sprintf("years match (%d)", a.Year))
+	}
+
+	durationsConflict := false
+	if a.Duration > 0 && b.Duration > 0 {
+		diff := math.Abs(a.Duration-b.Duration) / math.Max(a.Duration, b.Duration)
+		durationsConflict = diff > durationTolerance
+		if durationsConflict {
+			output.Reasons = append(output.Reasons, fmt.Sprintf("durations differ by %.1f%%", diff*100))
+		} else {
+			output.Reasons = append(output.Reasons, fmt.Sprintf("durations match within %.1f%%", diff*100))
+		}
+	}
+
+	switch {
+	case !sameTitle || yearsConflict || durationsConflict:
+		output.Verdict = "different_movie"
+	case a.Year == 0 || b.Year == 0:
+		output.Verdict = "uncertain"
+	default:
+		output.Verdict = "same_movie"
+	}
+
+	if output.Verdict != "different_movie" {
+		output.HigherQuality = higherQuality(a, b)
+	}
+
+	return output
+}
+
+// higherQuality picks the file with the higher resolution, or the larger
+// file when the resolutions are the same or unknown
+func higherQuality(a, b ComparedFile) string {
+	rankA, rankB := resolutionRank[a.Resolution], resolutionRank[b.Resolution]
+	switch {
+	case rankA > rankB:
+		return a.Path
+	case rankB > rankA:
+		return b.Path
+	case a.Size >= b.Size:
+		return a.Path
+	default:
+		return b.Path
+	}
+}
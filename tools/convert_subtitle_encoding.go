@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+type ConvertSubtitleEncodingInput struct {
+	Path           string `json:"path" jsonschema_description:"The subtitle file to check and convert."`
+	SourceEncoding string `json:"source_encoding" jsonschema_description:"The encoding to convert from, like windows-1250 or iso-8859-2. If empty, it is detected: UTF-8, UTF-16 (with BOM), Windows-1252 or ISO-8859-1."`
+	OutputPath     string `json:"output_path" jsonschema_description:"Where to write the UTF-8 copy. Required unless in_place is set."`
+	InPlace        bool   `json:"in_place" jsonschema_description:"If true, the file is converted in place and the original is kept next to it with a .bak suffix."`
+	DryRun         bool   `json:"dry_run" jsonschema_description:"If true, only report the detected encoding without writing anything."`
+}
+
+var ConvertSubtitleEncodingInputSchema = GenerateSchema[ConvertSubtitleEncodingInput]()
+
+var ConvertSubtitleEncodingDefinition = ToolDefinition{
+	Name:        "convert_subtitle_encoding",
+	Description: "Detect the text encoding of a subtitle file and convert it to UTF-8, fixing garbled accented characters in Jellyfin. Writes to output_path, or in place keeping a .bak backup. Files that are already UTF-8 are left untouched. Use dry_run to only detect the encoding.",
+	InputSchema: ConvertSubtitleEncodingInputSchema,
+	Function:    ConvertSubtitleEncoding,
+	PreviewWith: "dry_run",
+}
+
+type ConvertSubtitleEncodingOutput struct {
+	Path       string `json:"path"`
+	Encoding   string `json:"encoding"`
+	Converted  bool   `json:"converted"`
+	OutputPath string `json:"output_path,omitempty"`
+	BackupPath string `json:"backup_path,omitempty"`
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+func ConvertSubtitleEncoding(input json.RawMessage) (string, error) {
+	convertInput := ConvertSubtitleEncodingInput{}
+	err := json.Unmarshal(input, &convertInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(convertInput.Path); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+	if !isSubtitleFile(convertInput.Path) {
+		return "", fmt.Errorf("not a subtitle file: %s", convertInput.Path)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	content, err := readMediaFile(fsys, convertInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	name, enc := detectTextEncoding(content)
+	if convertInput.SourceEncoding != "" {
+		enc, err = htmlindex.Get(convertInput.SourceEncoding)
+		if err != nil {
+			return "", fmt.Errorf("unknown encoding %q", convertInput.SourceEncoding)
+		}
+		name, _ = htmlindex.Name(enc)
+	}
+
+	output := ConvertSubtitleEncodingOutput{Path: convertInput.Path, Encoding: name}
+	if enc == nil || convertInput.DryRun {
+		jsonData, err := json.Marshal(output)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal results: %w", err)
+		}
+		return string(jsonData), nil
+	}
+
+	converted, err := enc.NewDecoder().Bytes(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode as %s: %v", name, err)
+	}
+	converted = bytes.TrimPrefix(converted, utf8BOM)
+
+	target := convertInput.OutputPath
+	if convertInput.InPlace {
+		target = convertInput.Path
+		output.BackupPath = convertInput.Path + ".bak"
+		if _, err := fsys.Stat(output.BackupPath); err == nil {
+			return "", fmt.Errorf("backup path already exists: %s", output.BackupPath)
+		}
+	} else {
+		if target == "" {
+			return "", fmt.Errorf("output_path is required unless in_place is set")
+		}
+		if err := ValidatePath(target); err != nil {
+			return "", fmt.Errorf("access denied: %v", err)
+		}
+		if _, err := fsys.Stat(target); err == nil {
+			return "", fmt.Errorf("target path already exists: %s", target)
+		}
+	}
+
+	if DryRun {
+		output.OutputPath = target
+		jsonData, err := json.Marshal(output)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal results: %w", err)
+		}
+		return dryRunOutput(jsonData), nil
+	}
+
+	if convertInput.InPlace {
+		if err := writeMediaFile(fsys, output.BackupPath, content); err != nil {
+			return "", fmt.Errorf("failed to write backup: %v", err)
+		}
+	}
+	if err := writeMediaFile(fsys, target, converted); err != nil {
+		return "", fmt.Errorf("failed to write converted subtitle: %v", err)
+	}
+
+	output.Converted = true
+	output.OutputPath = target
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// detectTextEncoding guesses the encoding of subtitle text. It returns a nil
+// encoding when the content is already UTF-8 and needs no conversion. Bytes
+// 0x80-0x9F are control codes in ISO-8859-1 but printable characters like
+// curly quotes in Windows-1252, which tells the two apart
+func detectTextEncoding(content []byte) (string, encoding.Encoding) {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		return "utf-16le", unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		return "utf-16be", unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case utf8.Valid(content):
+		return "utf-8", nil
+	}
+
+	for _, b := range content {
+		if b >= 0x80 && b <= 0x9F {
+			return "windows-1252", charmap.Windows1252
+		}
+	}
+	return "iso-8859-1", charmap.ISO8859_1
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,20 +10,21 @@ import (
 )
 
 type CopyFileInput struct {
-	InitialPath string `json:"initial_path" jsonschema_description:"The source file path to copy from. Use an absolute path"`
-	EndingPath  string `json:"ending_path" jsonschema_description:"The destination file path to copy to. Use an absolute path"`
+	InitialPath string `json:"initial_path" jsonschema_description:"The source file path to copy from, in SOURCE_FOLDER or a library. Use an absolute path"`
+	EndingPath  string `json:"ending_path" jsonschema_description:"The destination file path to copy to, in JELLYFIN_SHOWS_FOLDER or JELLYFIN_MOVIES_FOLDER. Use an absolute path"`
+	Overwrite   bool   `json:"overwrite" jsonschema_description:"If true, an existing destination file is replaced. Defaults to false, which fails when the destination exists. Copies that replace a file can't be undone."`
 }
 
 var CopyFileInputSchema = GenerateSchema[CopyFileInput]()
 
 var CopyFileDefinition = ToolDefinition{
 	Name:        "copy_file",
-	Description: "Copy a file from any source path to a destination within Jellyfin media directories. Source and destination should be absolute paths",
+	Description: "Copy a file from SOURCE_FOLDER or a library to a destination within the Jellyfin shows or movies folders, e.g. to import a new download. Source and destination should be absolute paths",
 	InputSchema: CopyFileInputSchema,
-	Function:    CopyFile,
+	Contextual:  CopyFile,
 }
 
-func CopyFile(input json.RawMessage) (string, error) {
+func CopyFile(ctx context.Context, input json.RawMessage) (string, error) {
 	copyFileInput := CopyFileInput{}
 	err := json.Unmarshal(input, &copyFileInput)
 	if err != nil {
@@ -30,44 +32,134 @@ func CopyFile(input json.RawMessage) (string, error) {
 	}
 
 	srcPath := copyFileInput.InitialPath
+	dstPath, err := sanitizePath(copyFileInput.EndingPath)
+	if err != nil {
+		return "", err
+	}
 
-	// Validate destination path within Jellyfin directories
-	if err = ValidatePath(copyFileInput.EndingPath); err != nil {
+	// Copies import into the libraries, never into the downloads folder
+	if err := ValidateLibraryPath(dstPath); err != nil {
+		return "", fmt.Errorf("invalid destination path: %v", err)
+	}
+
+	if err := copyMedia(ctx, srcPath, dstPath, copyFileInput.Overwrite); err != nil {
 		return "", err
 	}
 
-	dstPath := copyFileInput.EndingPath
+	note := ""
+	if dstPath != copyFileInput.EndingPath {
+		note = fmt.Sprintf(". The destination name was sanitized, it is %s", filepath.Base(dstPath))
+	}
+
+	if DryRun {
+		return fmt.Sprintf("%swould copy %s to %s%s", dryRunPrefix, srcPath, dstPath, note), nil
+	}
+	return fmt.Sprintf("Successfully copied file from %s to %s%s", srcPath, dstPath, note), nil
+}
+
+// copyMedia validates both paths and copies srcPath to dstPath,
+// creating the destination's parent directory when needed. An existing
+// destination file is only replaced when overwrite is set. In dry-run mode
+// it stops after the checks. Cancelling ctx aborts the copy
+func copyMedia(ctx context.Context, srcPath, dstPath string, overwrite bool) error {
+	if err := ValidatePath(srcPath); err != nil {
+		return fmt.Errorf("invalid source path: %v", err)
+	}
+
+	// Validate destination path within Jellyfin directories
+	if err := ValidatePath(dstPath); err != nil {
+		return err
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return err
+	}
 
 	// Check if source file exists
-	if _, err := os.Stat(srcPath); os.IsNotExist(err) {
-		return "", fmt.Errorf("source file does not exist: %s", srcPath)
+	if _, err := fsys.Stat(srcPath); os.IsNotExist(err) {
+		return fmt.Errorf("source file does not exist: %s", srcPath)
+	}
+
+	// The collision check runs in dry-run mode too, so a preview reports it
+	dstInfo, err := fsys.Stat(dstPath)
+	existed := err == nil
+	if existed && dstInfo.IsDir() {
+		return fmt.Errorf("destination is a folder: %s", dstPath)
+	}
+	if existed && !overwrite {
+		return fmt.Errorf("destination already exists: %s, set overwrite to replace it", dstPath)
+	}
+
+	if DryRun {
+		return nil
 	}
 
 	// Create destination directory if it doesn't exist
 	dstDir := filepath.Dir(dstPath)
-	if err := os.MkdirAll(dstDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create destination directory: %v", err)
+	if err := fsys.MkdirAll(dstDir, 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %v", err)
 	}
 
-	// Open source file
-	srcFile, err := os.Open(srcPath)
+	// Deleting a copy that replaced a file would lose that file, so such
+	// copies are logged apart and undo refuses them
+	operation := "copy"
+	if existed {
+		operation = "overwrite"
+	}
+
+	if _, err := copyFileContents(ctx, fsys, srcPath, dstPath); err != nil {
+		return err
+	}
+	recordOperation(operation, srcPath, dstPath)
+
+	return nil
+}
+
+// copyFileContents copies srcPath to dstPath through a .partial file, so an
+// interrupted copy never looks like a complete file in the library. A failed
+// copy removes its partial file, as does cancelling ctx. It returns the number
+// of bytes copied
+func copyFileContents(ctx context.Context, fsys MediaFS, srcPath, dstPath string) (int64, error) {
+	srcFile, err := fsys.Open(srcPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to open source file: %v", err)
+		return 0, fmt.Errorf("failed to open source file: %v", err)
 	}
 	defer srcFile.Close()
 
-	// Create destination file
-	dstFile, err := os.Create(dstPath)
+	partialPath := dstPath + partialSuffix
+	dstFile, err := fsys.Create(partialPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to create destination file: %v", err)
+		return 0, fmt.Errorf("failed to create destination file: %v", err)
 	}
-	defer dstFile.Close()
 
-	// Copy file contents
-	_, err = io.Copy(dstFile, srcFile)
+	written, err := io.Copy(dstFile, contextReader{ctx: ctx, r: srcFile})
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to copy file contents: %v", err)
+		fsys.Remove(partialPath)
+		return 0, fmt.Errorf("failed to copy file contents: %v", err)
 	}
 
-	return fmt.Sprintf("Successfully copied file from %s to %s", srcPath, dstPath), nil
+	if err := fsys.Rename(partialPath, dstPath); err != nil {
+		fsys.Remove(partialPath)
+		return 0, fmt.Errorf("failed to move the finished copy into place: %v", err)
+	}
+
+	return written, nil
+}
+
+// contextReader stops a copy between reads once its context is cancelled,
+// so even a single large file can be aborted
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
 }
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyMediaCollisions(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  bool
+		overwrite bool
+		dryRun    bool
+		wantErr   bool
+		wantData  string
+		wantLog   string
+	}{
+		{name: "new destination", wantData: "source", wantLog: "copy"},
+		{name: "existing destination", existing: true, wantErr: true, wantData: "existing"},
+		{name: "existing destination in dry-run", existing: true, dryRun: true, wantErr: true, wantData: "existing"},
+		{name: "overwrite", existing: true, overwrite: true, wantData: "source", wantLog: "overwrite"},
+		{name: "dry-run", dryRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			library := setupTestLibrary(t)
+			source := filepath.Join(library, "source.mkv")
+			target := filepath.Join(library, "Movie", "target.mkv")
+			if err := os.WriteFile(source, []byte("source"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.existing {
+				if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(target, []byte("existing"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			dryRun := DryRun
+			DryRun = tt.dryRun
+			defer func() { DryRun = dryRun }()
+
+			err := copyMedia(context.Background(), source, target, tt.overwrite)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("copyMedia error = %v, want error %v", err, tt.wantErr)
+			}
+
+			data, err := os.ReadFile(target)
+			if tt.wantData == "" {
+				if !os.IsNotExist(err) {
+					t.Errorf("destination was written")
+				}
+			} else if string(data) != tt.wantData {
+				t.Errorf("destination holds %q, want %q", data, tt.wantData)
+			}
+
+			entries, err := readLedger(ledgerPath())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantLog == "" {
+				if len(entries) != 0 {
+					t.Errorf("logged %+v, want nothing", entries)
+				}
+			} else if len(entries) != 1 || entries[0].Operation != tt.wantLog {
+				t.Errorf("logged %+v, want one %s", entries, tt.wantLog)
+			}
+		})
+	}
+}
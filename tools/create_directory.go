@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type CreateDirectoryInput struct {
+	Type    string `json:"type" jsonschema:"enum=shows,enum=movies" jsonschema_description:"The library to create the folder in: 'shows' or 'movies'."`
+	Subpath string `json:"subpath" jsonschema_description:"The folder to create, relative to the library, e.g. 'Severance (2022)/Season 01'. Missing parent folders are created as well."`
+}
+
+var CreateDirectoryInputSchema = GenerateSchema[CreateDirectoryInput]()
+
+var CreateDirectoryDefinition = ToolDefinition{
+	Name:        "create_directory",
+	Description: "Create a folder in the shows or movies library, like a show's 'Season 01' folder, before moving files into it. Creating a folder that already exists is fine and reported as such.",
+	InputSchema: CreateDirectoryInputSchema,
+	Function:    CreateDirectory,
+}
+
+func CreateDirectory(input json.RawMessage) (string, error) {
+	createInput := CreateDirectoryInput{}
+	err := json.Unmarshal(input, &createInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if createInput.Type != "shows" && createInput.Type != "movies" {
+		return "", fmt.Errorf("type must be 'shows' or 'movies', got %q", createInput.Type)
+	}
+	if createInput.Subpath == "" {
+		return "", fmt.Errorf("subpath must not be empty")
+	}
+
+	path, err := pathInFolder(createInput.Type, createInput.Subpath)
+	if err != nil {
+		return "", err
+	}
+	if err := ValidateLibraryPath(path); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	info, err := fsys.Stat(path)
+	if err == nil {
+		if !info.IsDir() {
+			return "", fmt.Errorf("%s already exists as a file", path)
+		}
+		return fmt.Sprintf("%s already exists, nothing to do", path), nil
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to check %s: %v", path, err)
+	}
+
+	if DryRun {
+		return fmt.Sprintf("%swould create %s", dryRunPrefix, path), nil
+	}
+
+	if err := fsys.MkdirAll(path, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	recordOperation("create", "", path)
+
+	return fmt.Sprintf("Successfully created %s", path), nil
+}
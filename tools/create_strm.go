@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type CreateStrmInput struct {
+	URL       string `json:"url" jsonschema_description:"The stream URL the .strm file points at (http, https, rtsp, rtmp or mms)."`
+	MediaType string `json:"media_type" jsonschema_description:"'movie' or 'episode'."`
+	Title     string `json:"title" jsonschema_description:"The movie title, or the series name for an episode."`
+	Year      int    `json:"year" jsonschema_description:"The movie or series year, or 0 if unknown."`
+	Season    int    `json:"season" jsonschema_description:"The season number, for episodes."`
+	Episode   int    `json:"episode" jsonschema_description:"The episode number, for episodes."`
+}
+
+var CreateStrmInputSchema = GenerateSchema[CreateStrmInput]()
+
+var CreateStrmDefinition = ToolDefinition{
+	Name:        "create_strm",
+	Description: "Register streamed content without local media by writing a Jellyfin .strm file containing the stream URL. The file is named and placed following Jellyfin conventions: 'Title (Year)/Title (Year).strm' in the movies library or 'Series (Year)/Season XX/Series SxxEyy.strm' in the shows library. The URL is validated and existing files are never overwritten.",
+	InputSchema: CreateStrmInputSchema,
+	Function:    CreateStrm,
+}
+
+var strmSchemes = map[string]bool{"http": true, "https": true, "rtsp": true, "rtmp": true, "mms": true}
+
+func CreateStrm(input json.RawMessage) (string, error) {
+	strmInput := CreateStrmInput{}
+	err := json.Unmarshal(input, &strmInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := validateStreamURL(strmInput.URL); err != nil {
+		return "", err
+	}
+
+	title := strings.TrimSpace(strmInput.Title)
+	if title == "" {
+		return "", fmt.Errorf("title is required")
+	}
+	if strings.ContainsAny(title, `/\`) {
+		return "", fmt.Errorf("title cannot contain path separators: %s", title)
+	}
+
+	var target string
+	switch strmInput.MediaType {
+	case "movie":
+		name := titleYearName(title, strmInput.Year)
+		target = filepath.Join(os.Getenv("JELLYFIN_MOVIES_FOLDER"), name, name+".strm")
+	case "episode":
+		if strmInput.Episode <= 0 || strmInput.Season < 0 {
+			return "", fmt.Errorf("episodes need a season and an episode number")
+		}
+		target = filepath.Join(
+			os.Getenv("JELLYFIN_SHOWS_FOLDER"),
+			titleYearName(title, strmInput.Year),
+			fmt.Sprintf("Season %02d", strmInput.Season),
+			fmt.Sprintf("%s S%02dE%02d.strm", title, strmInput.Season, strmInput.Episode),
+		)
+	default:
+		return "", fmt.Errorf("media_type must be 'movie' or 'episode', got %q", strmInput.MediaType)
+	}
+
+	if err := ValidatePath(target); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+	if _, err := fsys.Stat(target); err == nil {
+		return "", fmt.Errorf("target path already exists: %s", target)
+	}
+	if DryRun {
+		return fmt.Sprintf("%swould create %s pointing at %s", dryRunPrefix, target, strmInput.URL), nil
+	}
+	if err := fsys.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", fmt.Errorf("failed to create target directory: %v", err)
+	}
+
+	file, err := fsys.Create(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to create .strm file: %v", err)
+	}
+	_, err = file.Write([]byte(strmInput.URL + "\n"))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write .strm file: %v", err)
+	}
+	recordOperation("create", strmInput.URL, target)
+
+	return fmt.Sprintf("Created %s pointing at %s", target, strmInput.URL), nil
+}
+
+// validateStreamURL checks that a URL is a single line absolute URL with a
+// scheme Jellyfin can stream from
+func validateStreamURL(raw string) error {
+	if strings.ContainsAny(raw, "\r\n") {
+		return fmt.Errorf("URL must be a single line")
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %v", err)
+	}
+	if !strmSchemes[strings.ToLower(parsed.Scheme)] {
+		return fmt.Errorf("unsupported URL scheme %q, expected http, https, rtsp, rtmp or mms", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("URL has no host: %s", raw)
+	}
+	return nil
+}
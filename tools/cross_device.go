@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// mediaMove describes how a move was carried out. Copied is set when the
+// source was on another device and had to be copied and deleted
+type mediaMove struct {
+	Copied bool
+	Files  int
+	Bytes  int64
+}
+
+// isCrossDevice reports whether a rename failed because source and target
+// are on different file systems
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
+
+// moveAcrossDevices moves a file or folder that can't be renamed into place
+// by copying it and deleting the source afterwards. Cancelling ctx stops the
+// copy and leaves the source in place
+func moveAcrossDevices(ctx context.Context, fsys MediaFS, sourcePath string, sourceInfo os.FileInfo, targetPath string) (mediaMove, error) {
+	if sourceInfo.IsDir() {
+		return moveTreeAcrossDevices(ctx, fsys, sourcePath, targetPath)
+	}
+
+	var tx transaction
+	written, err := copyFileContents(ctx, fsys, sourcePath, targetPath)
+	if err != nil {
+		return mediaMove{}, err
+	}
+	tx.add("copy to "+targetPath, func() error { return fsys.Remove(targetPath) })
+	preserveFileInfo(targetPath, sourceInfo)
+
+	if err := fsys.Remove(sourcePath); err != nil {
+		return mediaMove{}, rollbackError(fmt.Errorf("copied to %s but failed to remove the source: %v", targetPath, err), tx.rollback())
+	}
+
+	return mediaMove{Copied: true, Files: 1, Bytes: written}, nil
+}
+
+// moveTreeAcrossDevices recreates a folder tree under targetPath, copies
+// every file into it and removes the source once all of them made it. A
+// failed copy undoes every copied file and created folder, so the source
+// stays the only copy
+func moveTreeAcrossDevices(ctx context.Context, fsys MediaFS, sourcePath, targetPath string) (mediaMove, error) {
+	move := mediaMove{Copied: true}
+	var dirs []string
+	var tx transaction
+	err := filepath.WalkDir(sourcePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(sourcePath, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(targetPath, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, target)
+			// Only folders created here are removed again, never ones that
+			// were already there
+			if _, err := os.Stat(target); err == nil {
+				return nil
+			}
+			if err := os.Mkdir(target, info.Mode().Perm()|0700); err != nil {
+				return err
+			}
+			tx.add("creation of "+target, func() error { return os.Remove(target) })
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("cannot copy %s, it is not a regular file", path)
+		}
+
+		written, err := copyFileContents(ctx, fsys, path, target)
+		if err != nil {
+			return err
+		}
+		tx.add("copy to "+target, func() error { return os.Remove(target) })
+		preserveFileInfo(target, info)
+		move.Files++
+		move.Bytes += written
+		return nil
+	})
+	if err != nil {
+		return mediaMove{}, rollbackError(fmt.Errorf("copying the folder failed, nothing was moved: %v", err), tx.rollback())
+	}
+
+	// Folder times change while files are copied into them, so they are
+	// restored deepest first once everything is in place
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rel, _ := filepath.Rel(targetPath, dirs[i])
+		if info, err := os.Stat(filepath.Join(sourcePath, rel)); err == nil {
+			preserveFileInfo(dirs[i], info)
+		}
+	}
+
+	if err := os.RemoveAll(sourcePath); err != nil {
+		return mediaMove{}, fmt.Errorf("copied to %s but failed to remove the source: %v", targetPath, err)
+	}
+
+	return move, nil
+}
+
+// preserveFileInfo carries the mode and modification time of the original
+// over to a copy. Cross-device moves only happen locally, and failing to
+// keep either is not worth failing the move for
+func preserveFileInfo(path string, info os.FileInfo) {
+	os.Chmod(path, info.Mode().Perm())
+	os.Chtimes(path, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMoveTreeAcrossDevicesRollsBack(t *testing.T) {
+	library := setupTestLibrary(t)
+	source := filepath.Join(library, "Show")
+	writeTestFile(t, filepath.Join(source, "Season 01", "a.mkv"))
+	writeTestFile(t, filepath.Join(source, "Season 01", "b.mkv"))
+	// Walked last, after the files above were copied
+	if err := os.Symlink("/etc/hostname", filepath.Join(source, "z-link")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A folder that already existed must survive the rollback
+	target := filepath.Join(library, "Target")
+	writeTestFile(t, filepath.Join(target, "Season 01", "keep.mkv"))
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := moveTreeAcrossDevices(context.Background(), fsys, source, target); err == nil {
+		t.Fatal("copying a symlink should fail")
+	}
+
+	for _, path := range []string{"a.mkv", "b.mkv"} {
+		if _, err := os.Stat(filepath.Join(source, "Season 01", path)); err != nil {
+			t.Errorf("source %s is gone: %v", path, err)
+		}
+		if _, err := os.Stat(filepath.Join(target, "Season 01", path)); !os.IsNotExist(err) {
+			t.Errorf("copy of %s was not removed", path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(target, "Season 01", "keep.mkv")); err != nil {
+		t.Errorf("existing file was removed: %v", err)
+	}
+}
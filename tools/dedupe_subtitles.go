@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type DedupeSubtitlesInput struct {
+	FolderPath string `json:"folder_path" jsonschema_description:"The movie or season folder containing the subtitle sidecars."`
+	Remove     bool   `json:"remove" jsonschema_description:"If true, the duplicates are deleted, keeping the recommended file of each group. If false, they are only reported."`
+}
+
+var DedupeSubtitlesInputSchema = GenerateSchema[DedupeSubtitlesInput]()
+
+var DedupeSubtitlesDefinition = ToolDefinition{
+	Name:           "dedupe_subtitles",
+	Description:    "Find duplicate subtitle sidecars in a folder, like Movie.en.srt and Movie.English.srt with identical content. Subtitles are grouped by video, detected language and flags (forced, sdh, cc...), and files with the same content hash within a group are duplicates. Variants such as forced or SDH tracks are never treated as duplicates of the full track. Recommends which file to keep (the one named with the ISO 639-1 code) and optionally removes the others.",
+	InputSchema:    DedupeSubtitlesInputSchema,
+	Function:       DedupeSubtitles,
+	WritesOnlyWith: "remove",
+}
+
+type SubtitleDuplicateGroup struct {
+	Language   string   `json:"language"`
+	Flags      []string `json:"flags,omitempty"`
+	Keep       string   `json:"keep"`
+	Duplicates []string `json:"duplicates"`
+}
+
+type DedupeSubtitlesOutput struct {
+	Groups  []SubtitleDuplicateGroup `json:"groups"`
+	Unknown []string                 `json:"unknown_language"`
+	Removed []string                 `json:"removed"`
+}
+
+type subtitleSidecar struct {
+	path     string
+	base     string
+	language string
+	flags    []string
+	// canonical is set when the language is written as its ISO 639-1 code
+	canonical bool
+}
+
+func DedupeSubtitles(input json.RawMessage) (string, error) {
+	dedupeInput := DedupeSubtitlesInput{}
+	err := json.Unmarshal(input, &dedupeInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(dedupeInput.FolderPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := fsys.ReadDir(dedupeInput.FolderPath)
+	if err != nil {
+		return "", err
+	}
+
+	videoBases := videoBaseNames(entries)
+
+	output := DedupeSubtitlesOutput{Groups: []SubtitleDuplicateGroup{}, Unknown: []string{}, Removed: []string{}}
+
+	groups := map[string][]subtitleSidecar{}
+	var keys []string
+	for _, entry := range entries {
+		if entry.IsDir() || !isSubtitleFile(entry.Name()) {
+			continue
+		}
+
+		sidecar := parseSubtitleSidecar(filepath.Join(dedupeInput.FolderPath, entry.Name()), videoBases)
+		if sidecar.language == "" {
+			output.Unknown = append(output.Unknown, sidecar.path)
+			continue
+		}
+
+		key := strings.Join([]string{sidecar.base, sidecar.language, strings.Join(sidecar.flags, "."), strings.ToLower(filepath.Ext(sidecar.path))}, "|")
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], sidecar)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		sidecars := groups[key]
+		if len(sidecars) < 2 {
+			continue
+		}
+
+		byHash := map[string][]subtitleSidecar{}
+		var hashes []string
+		for _, sidecar := range sidecars {
+			hash, err := hashFile(fsys, sidecar.path)
+			if err != nil {
+				return "", fmt.Errorf("failed to hash %s: %v", sidecar.path, err)
+			}
+			if _, ok := byHash[hash]; !ok {
+				hashes = append(hashes, hash)
+			}
+			byHash[hash] = append(byHash[hash], sidecar)
+		}
+
+		for _, hash := range hashes {
+			identical := byHash[hash]
+			if len(identical) < 2 {
+				continue
+			}
+
+			keep := preferredSubtitle(identical)
+			group := SubtitleDuplicateGroup{
+				Language:   keep.language,
+				Flags:      keep.flags,
+				Keep:       keep.path,
+				Duplicates: []string{},
+			}
+			for _, sidecar := range identical {
+				if sidecar.path != keep.path {
+					group.Duplicates = append(group.Duplicates, sidecar.path)
+				}
+			}
+			output.Groups = append(output.Groups, group)
+		}
+	}
+
+	if dedupeInput.Remove && !DryRun {
+		for _, group := range output.Groups {
+			for _, duplicate := range group.Duplicates {
+				if err := fsys.Remove(duplicate); err != nil {
+					return "", fmt.Errorf("failed to remove %s: %v", duplicate, err)
+				}
+				recordOperation("delete", duplicate, "")
+				output.Removed = append(output.Removed, duplicate)
+			}
+		}
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if dedupeInput.Remove && DryRun {
+		return dryRunOutput(jsonData), nil
+	}
+
+	return string(jsonData), nil
+}
+
+// videoBaseNames returns the names of the video files among entries, without
+// their extensions
+func videoBaseNames(entries []os.FileInfo) []string {
+	var bases []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isVideoFile(entry.Name()) {
+			bases = append(bases, strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+		}
+	}
+	return bases
+}
+
+// parseSubtitleSidecar reads the language and flags from the tokens after
+// the video name, as in Movie (2009).English.forced.srt
+func parseSubtitleSidecar(path string, videoBases []string) subtitleSidecar {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	sidecar := subtitleSidecar{path: path}
+
+	// Match the longest video name so "Movie" doesn't claim "Movie 2" files
+	rest := name
+	for _, base := range videoBases {
+		if strings.HasPrefix(name, base+".") && len(base) > len(sidecar.base) {
+			sidecar.base = base
+			rest = strings.TrimPrefix(name, base+".")
+		}
+	}
+	if sidecar.base == "" {
+		sidecar.base, rest, _ = strings.Cut(name, ".")
+	}
+
+	for _, token := range strings.Split(rest, ".") {
+		lower := strings.ToLower(token)
+		if subtitleFlags[lower] {
+			sidecar.flags = append(sidecar.flags, lower)
+			continue
+		}
+		if code, ok := lookupLanguage(lower); ok && sidecar.language == "" {
+			sidecar.language = code
+			sidecar.canonical = lower == code
+		}
+	}
+	sort.Strings(sidecar.flags)
+
+	return sidecar
+}
+
+// preferredSubtitle picks the file to keep among identical subtitles: one
+// named with the ISO 639-1 code, then the shortest name
+func preferredSubtitle(sidecars []subtitleSidecar) subtitleSidecar {
+	best := sidecars[0]
+	for _, sidecar := range sidecars[1:] {
+		switch {
+		case sidecar.canonical != best.canonical:
+			if sidecar.canonical {
+				best = sidecar
+			}
+		case len(sidecar.path) != len(best.path):
+			if len(sidecar.path) < len(best.path) {
+				best = sidecar
+			}
+		case sidecar.path < best.path:
+			best = sidecar
+		}
+	}
+	return best
+}
@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type DeleteFileInput struct {
+	Path      string `json:"path" jsonschema_description:"The file or folder to delete. Must be within Jellyfin media directories and can't be one of the library root folders."`
+	Recursive bool   `json:"recursive" jsonschema_description:"If true, a folder is deleted together with everything in it. Without it only files and empty folders can be deleted."`
+}
+
+var DeleteFileInputSchema = GenerateSchema[DeleteFileInput]()
+
+var DeleteFileDefinition = ToolDefinition{
+	Name:        "delete_file",
+	Description: "Delete a leftover file or empty folder within the Jellyfin media directories, like sample videos, .txt readmes or folders emptied by moves. Non-empty folders are only deleted when recursive is set. The library root folders can never be deleted.",
+	InputSchema: DeleteFileInputSchema,
+	Function:    DeleteFile,
+}
+
+func DeleteFile(input json.RawMessage) (string, error) {
+	deleteInput := DeleteFileInput{}
+	err := json.Unmarshal(input, &deleteInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	// The resolved path is the one deleted, so a symlink can't lead the
+	// delete to a folder other than the one that was checked
+	path, err := ResolvePath(deleteInput.Path)
+	if err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+	if isMediaRoot(path) {
+		return "", fmt.Errorf("refusing to delete the media root folder %s", path)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	info, err := fsys.Stat(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("path does not exist: %s", path)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read path: %v", err)
+	}
+
+	if info.IsDir() && !deleteInput.Recursive {
+		entries, err := fsys.ReadDir(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read folder: %v", err)
+		}
+		if len(entries) > 0 {
+			return "", fmt.Errorf("folder %s is not empty (%d entries), set recursive to delete it with its contents", path, len(entries))
+		}
+	}
+
+	if DryRun {
+		return fmt.Sprintf("%swould delete %s", dryRunPrefix, path), nil
+	}
+
+	if err := removeMediaTree(fsys, path, info); err != nil {
+		return "", fmt.Errorf("failed to delete %s: %v", path, err)
+	}
+	recordOperation("delete", path, "")
+
+	return fmt.Sprintf("Successfully deleted %s", path), nil
+}
+
+// isMediaRoot reports whether path is one of the configured library or
+// source root folders. Both sides are resolved like ResolvePath does, so a
+// root given through a symlink or by its real path is still recognized
+func isMediaRoot(path string) bool {
+	resolved, err := ResolvePath(path)
+	if err != nil {
+		return true
+	}
+	for _, name := range []string{"JELLYFIN_SHOWS_FOLDER", "JELLYFIN_MOVIES_FOLDER", "SOURCE_FOLDER"} {
+		folder := os.Getenv(name)
+		if folder == "" {
+			continue
+		}
+		if root, err := ResolvePath(folder); err == nil && root == resolved {
+			return true
+		}
+	}
+	return false
+}
+
+// removeMediaTree removes a file, or a folder after everything in it, through
+// the media file system so it works for remote media as well
+func removeMediaTree(fsys MediaFS, path string, info os.FileInfo) error {
+	if info.IsDir() {
+		entries, err := fsys.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := removeMediaTree(fsys, filepath.Join(path, entry.Name()), entry); err != nil {
+				return err
+			}
+		}
+	}
+	return fsys.Remove(path)
+}
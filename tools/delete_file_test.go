@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsMediaRoot(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	realMovies := filepath.Join(dir, "real-movies")
+	movies := filepath.Join(dir, "movies")
+	source := filepath.Join(dir, "downloads")
+	for _, folder := range []string{filepath.Join(realMovies, "Movie (2009)"), source} {
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The library is configured through a symlink to its real folder
+	if err := os.Symlink(realMovies, movies); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(movies, filepath.Join(source, "library")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("JELLYFIN_MOVIES_FOLDER", movies)
+	t.Setenv("JELLYFIN_SHOWS_FOLDER", "")
+	t.Setenv("SOURCE_FOLDER", source)
+	t.Setenv("REMOTE_MEDIA_URL", "")
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{name: "configured root", path: movies, want: true},
+		{name: "trailing slash", path: movies + "/", want: true},
+		{name: "real path of the root", path: realMovies, want: true},
+		{name: "symlink to the root", path: filepath.Join(source, "library"), want: true},
+		{name: "source root", path: source, want: true},
+		{name: "folder in the library", path: filepath.Join(movies, "Movie (2009)"), want: false},
+		{name: "folder through the symlink", path: filepath.Join(source, "library", "Movie (2009)"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isMediaRoot(tt.path); got != tt.want {
+				t.Errorf("isMediaRoot(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := DeleteFile([]byte(`{"path":"` + filepath.Join(source, "library") + `","recursive":true}`)); err == nil {
+		t.Error("deleting a symlink to the library root should fail")
+	}
+	if _, err := os.Stat(filepath.Join(realMovies, "Movie (2009)")); err != nil {
+		t.Errorf("library contents were deleted: %v", err)
+	}
+}
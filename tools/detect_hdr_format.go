@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type DetectHDRFormatInput struct {
+	Path string `json:"path" jsonschema_description:"The video file to probe. Must be within the permitted folders."`
+}
+
+var DetectHDRFormatInputSchema = GenerateSchema[DetectHDRFormatInput]()
+
+var DetectHDRFormatDefinition = ToolDefinition{
+	Name:        "detect_hdr_format",
+	Description: "Probe a video file with ffprobe and detect its resolution, aspect ratio and dynamic range (SDR, HLG, HDR10, HDR10+ or Dolby Vision). Returns the quality tag to use in a Jellyfin file name, like ' - [2160p DV]'. Files without HDR metadata are reported as SDR and only get a resolution tag.",
+	InputSchema: DetectHDRFormatInputSchema,
+	Function:    DetectHDRFormat,
+}
+
+type HDRFormatOutput struct {
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	AspectRatio string `json:"aspect_ratio,omitempty"`
+	Resolution  string `json:"resolution"`
+	HDRFormat   string `json:"hdr_format"`
+	DVProfile   int    `json:"dolby_vision_profile,omitempty"`
+	Tag         string `json:"tag"`
+}
+
+// ffprobeOutput is the subset of `ffprobe -print_format json` output used to
+// detect the video format
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType          string            `json:"codec_type"`
+		Width              int               `json:"width"`
+		Height             int               `json:"height"`
+		DisplayAspectRatio string            `json:"display_aspect_ratio"`
+		ColorTransfer      string            `json:"color_transfer"`
+		ColorPrimaries     string            `json:"color_primaries"`
+		SideDataList       []ffprobeSideData `json:"side_data_list"`
+	} `json:"streams"`
+	Frames []struct {
+		SideDataList []ffprobeSideData `json:"side_data_list"`
+	} `json:"frames"`
+}
+
+type ffprobeSideData struct {
+	SideDataType string `json:"side_data_type"`
+	DVProfile    int    `json:"dv_profile"`
+}
+
+func DetectHDRFormat(input json.RawMessage) (string, error) {
+	detectInput := DetectHDRFormatInput{}
+	err := json.Unmarshal(input, &detectInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(detectInput.Path); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	if IsRemoteMedia() {
+		return "", fmt.Errorf("probing is only supported for local media")
+	}
+
+	probe, err := runFFprobe(context.Background(), detectInput.Path, hdrProbeArgs...)
+	if err != nil {
+		return "", fmt.Errorf("ffprobe failed: %v", err)
+	}
+
+	output, err := parseHDRFormat(probe)
+	if err != nil {
+		return "", err
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// parseHDRFormat interprets ffprobe JSON output. Dolby Vision takes
+// precedence over the HDR10 base layer it is usually paired with
+func parseHDRFormat(probe []byte) (HDRFormatOutput, error) {
+	var parsed ffprobeOutput
+	if err := json.Unmarshal(probe, &parsed); err != nil {
+		return HDRFormatOutput{}, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+
+	if len(parsed.Streams) == 0 {
+		return HDRFormatOutput{}, fmt.Errorf("no video stream found")
+	}
+	stream := parsed.Streams[0]
+
+	output := HDRFormatOutput{
+		Width:      stream.Width,
+		Height:     stream.Height,
+		Resolution: resolutionLabel(stream.Width, stream.Height),
+		HDRFormat:  "SDR",
+	}
+	if stream.DisplayAspectRatio != "" && stream.DisplayAspectRatio != "0:1" {
+		output.AspectRatio = stream.DisplayAspectRatio
+	}
+
+	sideData := stream.SideDataList
+	for _, frame := range parsed.Frames {
+		sideData = append(sideData, frame.SideDataList...)
+	}
+
+	switch stream.ColorTransfer {
+	case "smpte2084":
+		output.HDRFormat = "HDR10"
+	case "arib-std-b67":
+		output.HDRFormat = "HLG"
+	}
+
+	for _, data := range sideData {
+		dataType := strings.ToLower(data.SideDataType)
+		switch {
+		case strings.Contains(dataType, "dovi"):
+			output.HDRFormat = "DV"
+			output.DVProfile = data.DVProfile
+		case strings.Contains(dataType, "hdr10+") || strings.Contains(dataType, "smpte2094-40"):
+			if output.HDRFormat != "DV" {
+				output.HDRFormat = "HDR10+"
+			}
+		}
+	}
+
+	tag := output.Resolution
+	if output.HDRFormat != "SDR" {
+		tag = strings.TrimSpace(tag + " " + output.HDRFormat)
+	}
+	if tag != "" {
+		output.Tag = " - [" + tag + "]"
+	}
+
+	return output, nil
+}
+
+// resolutionLabel maps frame dimensions to the usual resolution label. The
+// width is checked as well so cropped widescreen encodes, like 3840x1600,
+// still count as 2160p
+func resolutionLabel(width, height int) string {
+	switch {
+	case width >= 3200 || height >= 2000:
+		return "2160p"
+	case width >= 1800 || height >= 1000:
+		return "1080p"
+	case width >= 1200 || height >= 700:
+		return "720p"
+	case width >= 1000 || height >= 560:
+		return "576p"
+	case height > 0:
+		return "480p"
+	}
+	return ""
+}
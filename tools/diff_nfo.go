@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+type DiffNFOInput struct {
+	NFOPath string            `json:"nfo_path" jsonschema_description:"The NFO file the proposed fields would be applied to. It may not exist yet, in which case every field is reported as added."`
+	Root    string            `json:"root" jsonschema_description:"The root element for a new NFO: movie, tvshow or episodedetails. Ignored when the NFO exists. Defaults to movie."`
+	Fields  map[string]string `json:"fields" jsonschema_description:"Proposed top-level fields, e.g. {\"title\": \"Heat\", \"year\": \"1995\"}. An empty value removes the field. Fields not listed are kept as they are."`
+}
+
+var DiffNFOInputSchema = GenerateSchema[DiffNFOInput]()
+
+var DiffNFODefinition = ToolDefinition{
+	Name:        "diff_nfo",
+	Description: "Preview an NFO update without writing anything. Renders the NFO with the proposed fields applied and returns the list of added, changed and removed fields together with the rendered XML, so the change can be reviewed first.",
+	InputSchema: DiffNFOInputSchema,
+	Function:    DiffNFO,
+}
+
+type NFOFieldChange struct {
+	Field  string `json:"field"`
+	Change string `json:"change"`
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+}
+
+type DiffNFOOutput struct {
+	Changes  []NFOFieldChange `json:"changes"`
+	Rendered string           `json:"rendered"`
+}
+
+// nfoElement is a top-level element of an NFO. Elements with nested
+// children, like actor or fanart, are not simple and are kept verbatim
+type nfoElement struct {
+	name   string
+	value  string
+	simple bool
+	raw    string
+}
+
+func DiffNFO(input json.RawMessage) (string, error) {
+	diffInput := DiffNFOInput{}
+	err := json.Unmarshal(input, &diffInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(diffInput.NFOPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	root := diffInput.Root
+	if root == "" {
+		root = "movie"
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	var elements []nfoElement
+	content, err := readMediaFile(fsys, diffInput.NFOPath)
+	if err == nil {
+		root, elements, err = parseNFOElements(content)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse NFO: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read NFO: %v", err)
+	}
+
+	rendered, changes := applyNFOFields(root, elements, diffInput.Fields)
+
+	jsonData, err := json.Marshal(DiffNFOOutput{Changes: changes, Rendered: rendered})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// parseNFOElements returns the root element name and the top-level elements
+// of an NFO in document order
+func parseNFOElements(content []byte) (string, []nfoElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+
+	var root string
+	var elements []nfoElement
+	var current *nfoElement
+	var text strings.Builder
+	var elementStart int64
+	depth := 0
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch depth {
+			case 1:
+				root = t.Name.Local
+			case 2:
+				current = &nfoElement{name: t.Name.Local, simple: true}
+				elementStart = offset
+				text.Reset()
+			default:
+				current.simple = false
+			}
+		case xml.CharData:
+			if depth == 2 {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if depth == 2 && current != nil {
+				current.raw = string(content[elementStart:decoder.InputOffset()])
+				current.value = strings.TrimSpace(text.String())
+				if !current.simple {
+					current.value = current.raw
+				}
+				elements = append(elements, *current)
+				current = nil
+			}
+			depth--
+		}
+	}
+
+	if root == "" {
+		return "", nil, fmt.Errorf("no root element found")
+	}
+
+	return root, elements, nil
+}
+
+// applyNFOFields renders the NFO with the proposed fields applied and lists
+// the field level changes. A proposed field replaces every existing element
+// of that name, and an empty value removes them
+func applyNFOFields(root string, elements []nfoElement, fields map[string]string) (string, []NFOFieldChange) {
+	var out strings.Builder
+	out.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n")
+	fmt.Fprintf(&out, "<%s>\n", root)
+
+	changes := []NFOFieldChange{}
+	oldValues := map[string][]string{}
+	for _, element := range elements {
+		if _, ok := fields[element.name]; ok {
+			oldValues[element.name] = append(oldValues[element.name], element.value)
+		}
+	}
+
+	written := map[string]bool{}
+	for _, element := range elements {
+		value, proposed := fields[element.name]
+		if !proposed {
+			fmt.Fprintf(&out, "  %s\n", element.raw)
+			continue
+		}
+		if written[element.name] {
+			continue
+		}
+		written[element.name] = true
+
+		old := strings.Join(oldValues[element.name], ", ")
+		switch {
+		case value == "":
+			changes = append(changes, NFOFieldChange{Field: element.name, Change: "removed", Old: old})
+			continue
+		case value != old:
+			changes = append(changes, NFOFieldChange{Field: element.name, Change: "changed", Old: old, New: value})
+		}
+		fmt.Fprintf(&out, "  <%s>%s</%s>\n", element.name, escapeXMLText(value), element.name)
+	}
+
+	// Fields that didn't exist yet are appended in a stable order
+	var added []string
+	for name, value := range fields {
+		if !written[name] && value != "" {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		changes = append(changes, NFOFieldChange{Field: name, Change: "added", New: fields[name]})
+		fmt.Fprintf(&out, "  <%s>%s</%s>\n", name, escapeXMLText(fields[name]), name)
+	}
+
+	fmt.Fprintf(&out, "</%s>\n", root)
+	return out.String(), changes
+}
@@ -0,0 +1,138 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type DownloadArtworkInput struct {
+	URL       string `json:"url" jsonschema_description:"The http or https URL of the image, e.g. a poster URL from lookup_imdb_id or TMDb."`
+	Directory string `json:"directory" jsonschema_description:"The movie or show folder to save the image in. Must be within JELLYFIN_SHOWS_FOLDER or JELLYFIN_MOVIES_FOLDER."`
+	Kind      string `json:"kind" jsonschema_description:"'poster' saves poster.jpg, 'backdrop' saves backdrop.jpg. The extension follows the image type, e.g. poster.png. Defaults to poster."`
+	Overwrite bool   `json:"overwrite" jsonschema_description:"If true, existing artwork of the same kind and type is replaced. Defaults to false."`
+}
+
+var DownloadArtworkInputSchema = GenerateSchema[DownloadArtworkInput]()
+
+var DownloadArtworkDefinition = ToolDefinition{
+	Name:        "download_artwork",
+	Description: "Download a poster or backdrop image into a movie or show folder as poster.jpg or backdrop.jpg, so Jellyfin shows it without fetching artwork itself. Only images up to 20 MB are accepted.",
+	InputSchema: DownloadArtworkInputSchema,
+	Function:    DownloadArtwork,
+}
+
+const maxArtworkBytes = 20 << 20
+
+// artworkExtensions maps the accepted image types to the extension Jellyfin
+// looks for
+var artworkExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+var artworkHTTPClient = &http.Client{Timeout: 60 * time.Second, Transport: networkTransport}
+
+func DownloadArtwork(input json.RawMessage) (string, error) {
+	artworkInput := DownloadArtworkInput{}
+	err := json.Unmarshal(input, &artworkInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	kind := artworkInput.Kind
+	if kind == "" {
+		kind = "poster"
+	}
+	if kind != "poster" && kind != "backdrop" {
+		return "", fmt.Errorf("kind must be 'poster' or 'backdrop', got %q", kind)
+	}
+
+	parsed, err := url.Parse(artworkInput.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("url must be an absolute http or https URL: %s", artworkInput.URL)
+	}
+
+	// The final name depends on the image type, so the folder is checked
+	// with a name that is known to be in it
+	if err := ValidateLibraryPath(filepath.Join(artworkInput.Directory, kind+".jpg")); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+	if info, err := fsys.Stat(artworkInput.Directory); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("directory does not exist: %s", artworkInput.Directory)
+	}
+
+	resp, err := artworkHTTPClient.Get(parsed.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to download artwork: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("artwork download returned %s", resp.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	extension, ok := artworkExtensions[mediaType]
+	if !ok {
+		return "", fmt.Errorf("the URL is not a JPEG, PNG or WebP image, it has content type %q", resp.Header.Get("Content-Type"))
+	}
+	if resp.ContentLength > maxArtworkBytes {
+		return "", fmt.Errorf("the image is %d bytes, more than the %d bytes allowed", resp.ContentLength, maxArtworkBytes)
+	}
+
+	// The length header is optional, so the body is capped as well
+	image, err := io.ReadAll(io.LimitReader(resp.Body, maxArtworkBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to download artwork: %w", err)
+	}
+	if len(image) > maxArtworkBytes {
+		return "", fmt.Errorf("the image is more than the %d bytes allowed", maxArtworkBytes)
+	}
+
+	target := filepath.Join(artworkInput.Directory, kind+extension)
+	_, err = fsys.Stat(target)
+	exists := err == nil
+	if exists && !artworkInput.Overwrite {
+		return "", fmt.Errorf("target path already exists: %s", target)
+	}
+
+	if DryRun {
+		return fmt.Sprintf("%swould save the %d byte image as %s", dryRunPrefix, len(image), target), nil
+	}
+
+	// Written through a partial file, so a failed write doesn't leave a
+	// broken image Jellyfin would pick up
+	partialPath := target + partialSuffix
+	file, err := fsys.Create(partialPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create artwork file: %v", err)
+	}
+	_, err = file.Write(image)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fsys.Rename(partialPath, target)
+	}
+	if err != nil {
+		fsys.Remove(partialPath)
+		return "", fmt.Errorf("failed to write artwork file: %v", err)
+	}
+	if !exists {
+		recordOperation("create", parsed.String(), target)
+	}
+
+	return fmt.Sprintf("Saved the %s as %s (%d bytes, %s)", kind, target, len(image), strings.TrimPrefix(mediaType, "image/")), nil
+}
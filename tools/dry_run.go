@@ -0,0 +1,15 @@
+package tools
+
+// DryRun makes the tools that change files run their validation and checks
+// without touching the file system. main sets it from --dry-run or DRY_RUN
+var DryRun bool
+
+// dryRunPrefix marks tool results of simulated operations, so Claude doesn't
+// assume the files actually changed
+const dryRunPrefix = "[DRY RUN] "
+
+// dryRunOutput marks the JSON result of a tool that was asked to apply its
+// changes while DryRun skipped them
+func dryRunOutput(jsonData []byte) string {
+	return dryRunPrefix + "nothing was changed, this is what would have been done:\n" + string(jsonData)
+}
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWritingToolsHonorDryRun(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup creates the files the tool works on and returns its input
+		// and a path that must be left as it was
+		setup func(t *testing.T, library string) (input string, untouched string, mustExist bool)
+		run   func(input json.RawMessage) (string, error)
+	}{
+		{
+			name: "folder_loose_movies",
+			setup: func(t *testing.T, library string) (string, string, bool) {
+				movie := filepath.Join(library, "Movie.2009.1080p.mkv")
+				writeTestFile(t, movie)
+				return `{"apply":true}`, movie, true
+			},
+			run: FolderLooseMovies,
+		},
+		{
+			name: "quick_rename",
+			setup: func(t *testing.T, library string) (string, string, bool) {
+				movie := filepath.Join(library, "Movie.2009.1080p.BluRay.x264-GRP.mkv")
+				writeTestFile(t, movie)
+				return `{"source_path":"` + movie + `","apply":true}`, movie, true
+			},
+			run: QuickRename,
+		},
+		{
+			name: "dedupe_subtitles",
+			setup: func(t *testing.T, library string) (string, string, bool) {
+				folder := filepath.Join(library, "Movie (2009)")
+				writeTestFile(t, filepath.Join(folder, "Movie (2009).mkv"))
+				for _, name := range []string{"Movie (2009).en.srt", "Movie (2009).English.srt"} {
+					if err := os.WriteFile(filepath.Join(folder, name), []byte("same"), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				return `{"folder_path":"` + folder + `","remove":true}`, filepath.Join(folder, "Movie (2009).English.srt"), true
+			},
+			run: DedupeSubtitles,
+		},
+		{
+			name: "create_strm",
+			setup: func(t *testing.T, library string) (string, string, bool) {
+				return `{"url":"https://example.com/movie","media_type":"movie","title":"Movie","year":2009}`,
+					filepath.Join(library, "Movie (2009)"), false
+			},
+			run: CreateStrm,
+		},
+		{
+			name: "generate_hash_manifest",
+			setup: func(t *testing.T, library string) (string, string, bool) {
+				writeTestFile(t, filepath.Join(library, "Movie (2009)", "Movie (2009).mkv"))
+				manifest := filepath.Join(library, "manifest.sha256")
+				return `{"library_path":"` + library + `","manifest_path":"` + manifest + `"}`, manifest, false
+			},
+			run: GenerateHashManifest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			library := setupTestLibrary(t)
+			input, untouched, mustExist := tt.setup(t, library)
+
+			dryRun := DryRun
+			DryRun = true
+			defer func() { DryRun = dryRun }()
+
+			result, err := tt.run([]byte(input))
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !strings.HasPrefix(result, dryRunPrefix) {
+				t.Errorf("result %q is not marked as a dry run", result)
+			}
+			if strings.Contains(result, `"applied":true`) {
+				t.Errorf("result %q claims the changes were applied", result)
+			}
+
+			_, statErr := os.Stat(untouched)
+			if exists := statErr == nil; exists != mustExist {
+				t.Errorf("%s exists = %v, want %v", untouched, exists, mustExist)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type EstimateCopyTimeInput struct {
+	SourcePath     string `json:"source_path" jsonschema_description:"The file or folder that would be copied."`
+	DestinationDir string `json:"destination_dir" jsonschema_description:"An existing folder on the filesystem the copy would be written to."`
+}
+
+var EstimateCopyTimeInputSchema = GenerateSchema[EstimateCopyTimeInput]()
+
+var EstimateCopyTimeDefinition = ToolDefinition{
+	Name:        "estimate_copy_time",
+	Description: "Estimate how long copying a file or folder would take. Measures write throughput to the destination with a short timed test write (the test file is removed afterwards) and combines it with the source size. Use it before a large copy_file to set expectations.",
+	InputSchema: EstimateCopyTimeInputSchema,
+	Function:    EstimateCopyTime,
+}
+
+type EstimateCopyTimeOutput struct {
+	SourceBytes       int64   `json:"source_bytes"`
+	ThroughputMBps    float64 `json:"throughput_mb_per_second"`
+	EstimatedSeconds  float64 `json:"estimated_seconds"`
+	EstimatedDuration string  `json:"estimated_duration"`
+}
+
+// throughputTestSize is how much is written to measure throughput. It is
+// large enough to get past most write caches' burst speed
+const throughputTestSize = 32 << 20
+
+// measureWriteThroughput writes a temporary file to dir and returns the
+// observed bytes per second. It is a variable so the measurement can be faked
+var measureWriteThroughput = func(dir string) (float64, error) {
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return 0, err
+	}
+
+	testPath := filepath.Join(dir, fmt.Sprintf("%s%d", throughputPrefix, os.Getpid()))
+	file, err := fsys.Create(testPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create test file: %v", err)
+	}
+	defer fsys.Remove(testPath)
+
+	chunk := make([]byte, 1<<20)
+	start := time.Now()
+	for written := 0; written < throughputTestSize; written += len(chunk) {
+		if _, err := file.Write(chunk); err != nil {
+			file.Close()
+			return 0, fmt.Errorf("failed to write test file: %v", err)
+		}
+	}
+	if syncer, ok := file.(interface{ Sync() error }); ok {
+		syncer.Sync()
+	}
+	if err := file.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write test file: %v", err)
+	}
+
+	elapsed := time.Since(start).Seconds()
+	if elapsed <= 0 {
+		elapsed = 1e-3
+	}
+	return throughputTestSize / elapsed, nil
+}
+
+func EstimateCopyTime(input json.RawMessage) (string, error) {
+	estimateInput := EstimateCopyTimeInput{}
+	err := json.Unmarshal(input, &estimateInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(estimateInput.SourcePath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+	if err := ValidatePath(estimateInput.DestinationDir); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	size, err := mediaSize(fsys, estimateInput.SourcePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to measure source size: %v", err)
+	}
+
+	throughput, err := measureWriteThroughput(estimateInput.DestinationDir)
+	if err != nil {
+		return "", err
+	}
+
+	seconds := float64(size) / throughput
+	output := EstimateCopyTimeOutput{
+		SourceBytes:       size,
+		ThroughputMBps:    throughput / (1 << 20),
+		EstimatedSeconds:  seconds,
+		EstimatedDuration: time.Duration(seconds * float64(time.Second)).Round(time.Second).String(),
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// mediaSize returns the size of a file, or the total size of the files in a
+// folder tree
+func mediaSize(fsys MediaFS, path string) (int64, error) {
+	info, err := fsys.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if !info.IsDir() {
+		return info.Size(), nil
+	}
+
+	entries, err := fsys.ReadDir(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, entry := range entries {
+		size, err := mediaSize(fsys, filepath.Join(path, entry.Name()))
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
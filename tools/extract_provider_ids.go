@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type ExtractProviderIDsInput struct {
+	Name string `json:"name" jsonschema_description:"The file or folder name (or full path) to look for embedded metadata provider ids in."`
+}
+
+var ExtractProviderIDsInputSchema = GenerateSchema[ExtractProviderIDsInput]()
+
+var ExtractProviderIDsDefinition = ToolDefinition{
+	Name:        "extract_provider_ids",
+	Description: "Extract metadata provider ids embedded in a file or folder name, like {imdb-tt1234567}, [imdbid-tt1234567], {tmdb-12345} or [tvdbid-67890]. When an id is found, use it directly for metadata lookups instead of searching by title.",
+	InputSchema: ExtractProviderIDsInputSchema,
+	Function:    ExtractProviderIDs,
+}
+
+// ProviderID is a metadata provider id embedded in a name
+type ProviderID struct {
+	Provider string `json:"provider"`
+	ID       string `json:"id"`
+}
+
+var embeddedIDPattern = regexp.MustCompile(`(?i)[\[{](imdb|tmdb|tvdb)(?:id)?[-=]([a-z0-9]+)[\]}]`)
+
+var imdbIDPattern = regexp.MustCompile(`^tt\d+$`)
+
+func ExtractProviderIDs(input json.RawMessage) (string, error) {
+	extractInput := ExtractProviderIDsInput{}
+	err := json.Unmarshal(input, &extractInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	ids := ParseProviderIDs(filepath.Base(extractInput.Name))
+
+	jsonData, err := json.Marshal(ids)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// ParseProviderIDs returns the provider ids embedded in a name, in the order
+// they appear. Malformed ids (an imdb id without the tt prefix, a non numeric
+// tmdb id) are ignored
+func ParseProviderIDs(name string) []ProviderID {
+	ids := []ProviderID{}
+	seen := map[ProviderID]bool{}
+
+	for _, match := range embeddedIDPattern.FindAllStringSubmatch(name, -1) {
+		id := ProviderID{Provider: strings.ToLower(match[1]), ID: strings.ToLower(match[2])}
+
+		switch id.Provider {
+		case "imdb":
+			if !imdbIDPattern.MatchString(id.ID) {
+				continue
+			}
+		default:
+			if strings.Trim(id.ID, "0123456789") != "" {
+				continue
+			}
+		}
+
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
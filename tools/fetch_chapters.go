@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FetchChaptersInput struct {
+	VideoPath    string `json:"video_path" jsonschema_description:"The video the chapters belong to. The chapter file is written next to it."`
+	ChapterSetID string `json:"chapter_set_id" jsonschema_description:"The ChapterDb id of the chapter set for this release."`
+	Language     string `json:"language" jsonschema_description:"ISO 639-2 code of the chapter names. If empty, defaults to eng."`
+	Overwrite    bool   `json:"overwrite" jsonschema_description:"If true, an existing chapter file is replaced."`
+}
+
+var FetchChaptersInputSchema = GenerateSchema[FetchChaptersInput]()
+
+var FetchChaptersDefinition = ToolDefinition{
+	Name:        "fetch_chapters",
+	Description: "Fetch chapter timestamps for a video from ChapterDb by chapter set id and write them as a Matroska chapter XML sidecar ('<video name>.chapters.xml') next to the video, ready for players or to be muxed in with mkvmerge. Requires CHAPTERDB_API_KEY.",
+	InputSchema: FetchChaptersInputSchema,
+	Function:    FetchChapters,
+}
+
+// Chapter is a named chapter starting at an offset into the video
+type Chapter struct {
+	Start time.Duration `json:"start"`
+	Name  string        `json:"name"`
+}
+
+// chapterSource fetches the chapters of a chapter set
+type chapterSource interface {
+	Chapters(id string) ([]Chapter, error)
+}
+
+var chapters chapterSource = chapterDBSource{}
+
+const chapterDBBaseURL = "https://chapterdb.plex.tv"
+
+var chapterDBHTTPClient = &http.Client{Timeout: 15 * time.Second, Transport: networkTransport}
+
+type chapterDBSource struct{}
+
+func (chapterDBSource) Chapters(id string) ([]Chapter, error) {
+	apiKey := os.Getenv("CHAPTERDB_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("CHAPTERDB_API_KEY environment variable must be set to use ChapterDb")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, chapterDBBaseURL+"/chapters/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build ChapterDb request: %w", err)
+	}
+	req.Header.Set("ApiKey", apiKey)
+	req.Header.Set("Accept", "application/xml")
+
+	resp, err := chapterDBHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ChapterDb: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("ChapterDb returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var info struct {
+		Chapters []struct {
+			Time string `xml:"time,attr"`
+			Name string `xml:"name,attr"`
+		} `xml:"chapters>chapter"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode ChapterDb response: %w", err)
+	}
+
+	result := make([]Chapter, 0, len(info.Chapters))
+	for _, chapter := range info.Chapters {
+		start, err := parseChapterTime(chapter.Time)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, Chapter{Start: start, Name: chapter.Name})
+	}
+
+	return result, nil
+}
+
+func FetchChapters(input json.RawMessage) (string, error) {
+	fetchInput := FetchChaptersInput{}
+	err := json.Unmarshal(input, &fetchInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	videoPath := fetchInput.VideoPath
+	target := strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ".chapters.xml"
+
+	if err := ValidatePath(videoPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+	if err := ValidatePath(target); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+	if !isVideoFile(videoPath) {
+		return "", fmt.Errorf("not a video file: %s", videoPath)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := fsys.Stat(videoPath); err != nil {
+		return "", fmt.Errorf("video does not exist: %s", videoPath)
+	}
+	if _, err := fsys.Stat(target); err == nil && !fetchInput.Overwrite {
+		return "", fmt.Errorf("chapter file already exists: %s", target)
+	}
+
+	fetched, err := chapters.Chapters(fetchInput.ChapterSetID)
+	if err != nil {
+		return "", err
+	}
+	if len(fetched) == 0 {
+		return "", fmt.Errorf("chapter set %s has no chapters", fetchInput.ChapterSetID)
+	}
+
+	language := fetchInput.Language
+	if language == "" {
+		language = "eng"
+	}
+
+	if DryRun {
+		return fmt.Sprintf("%swould write %d chapters to %s", dryRunPrefix, len(fetched), target), nil
+	}
+	if err := writeMediaFile(fsys, target, []byte(renderMatroskaChapters(fetched, language))); err != nil {
+		return "", fmt.Errorf("failed to write chapter file: %v", err)
+	}
+
+	return fmt.Sprintf("Wrote %d chapters to %s", len(fetched), target), nil
+}
+
+// renderMatroskaChapters renders chapters in the XML format read by
+// mkvmerge and most players
+func renderMatroskaChapters(chapters []Chapter, language string) string {
+	var out strings.Builder
+	out.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	out.WriteString("<!DOCTYPE Chapters SYSTEM \"matroskachapters.dtd\">\n")
+	out.WriteString("<Chapters>\n  <EditionEntry>\n")
+	for _, chapter := range chapters {
+		out.WriteString("    <ChapterAtom>\n")
+		fmt.Fprintf(&out, "      <ChapterTimeStart>%s</ChapterTimeStart>\n", formatChapterTime(chapter.Start))
+		out.WriteString("      <ChapterDisplay>\n")
+		fmt.Fprintf(&out, "        <ChapterString>%s</ChapterString>\n", escapeXMLText(chapter.Name))
+		fmt.Fprintf(&out, "        <ChapterLanguage>%s</ChapterLanguage>\n", escapeXMLText(language))
+		out.WriteString("      </ChapterDisplay>\n")
+		out.WriteString("    </ChapterAtom>\n")
+	}
+	out.WriteString("  </EditionEntry>\n</Chapters>\n")
+	return out.String()
+}
+
+// parseChapterTime parses HH:MM:SS with optional fractional seconds
+func parseChapterTime(value string) (time.Duration, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid chapter time %q", value)
+	}
+
+	hours, err1 := strconv.Atoi(parts[0])
+	minutes, err2 := strconv.Atoi(parts[1])
+	seconds, err3 := strconv.ParseFloat(parts[2], 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, fmt.Errorf("invalid chapter time %q", value)
+	}
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)).Round(time.Millisecond), nil
+}
+
+func formatChapterTime(d time.Duration) string {
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	seconds := d / time.Second
+	d -= seconds * time.Second
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, seconds, d/time.Millisecond)
+}
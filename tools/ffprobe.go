@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// hdrProbeArgs read the first video stream and its first frame, which is
+// where HDR10+ dynamic metadata shows up
+var hdrProbeArgs = []string{
+	"-select_streams", "v:0",
+	"-read_intervals", "%+#1",
+	"-show_streams",
+	"-show_frames",
+	"-print_format", "json",
+}
+
+// runFFprobe runs ffprobe with args on a file and returns its output.
+// Cancelling ctx kills ffprobe. It is a variable so probing can be replaced
+// without ffprobe installed
+var runFFprobe = func(ctx context.Context, path string, args ...string) ([]byte, error) {
+	args = append(append([]string{"-v", "error"}, args...), path)
+	return exec.CommandContext(ctx, "ffprobe", args...).Output()
+}
+
+// checkFFprobe tells apart a missing ffprobe from a file it can't read
+func checkFFprobe() error {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		return fmt.Errorf("ffprobe is not installed or not on PATH, so the file can't be probed. Fall back to the details in the file name, e.g. with parse_release_name")
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type FindDuplicateShowsInput struct {
+	ShowsPath string  `json:"shows_path" jsonschema_description:"The shows library root to scan. If empty, JELLYFIN_SHOWS_FOLDER is used."`
+	Threshold float64 `json:"threshold" jsonschema_description:"Similarity between 0 and 1 above which two normalized titles are considered the same series. If 0, defaults to 0.85."`
+}
+
+var FindDuplicateShowsInputSchema = GenerateSchema[FindDuplicateShowsInput]()
+
+var FindDuplicateShowsDefinition = ToolDefinition{
+	Name:        "find_duplicate_shows",
+	Description: "Scan the shows library for series split across several folders under different spellings, like 'The Office', 'Office, The' and 'The Office (US)'. Returns clusters of folders that likely refer to the same series so merges can be proposed. Review each cluster before merging: qualifiers like (US)/(UK) or different years can mean genuinely different shows.",
+	InputSchema: FindDuplicateShowsInputSchema,
+	Function:    FindDuplicateShows,
+}
+
+type ShowCluster struct {
+	NormalizedTitle string   `json:"normalized_title"`
+	Folders         []string `json:"folders"`
+}
+
+var (
+	providerIDTagPattern = regexp.MustCompile(`(?i)[\[{](imdb|tmdb|tvdb)(id)?-[^\]}]+[\]}]`)
+	parentheticalPattern = regexp.MustCompile(`\([^)]*\)`)
+	nonAlphanumPattern   = regexp.MustCompile(`[^a-z0-9 ]+`)
+	trailingArticle      = regexp.MustCompile(`^(.*), (the|a|an)$`)
+	leadingArticle       = regexp.MustCompile(`^(the|a|an) `)
+)
+
+func FindDuplicateShows(input json.RawMessage) (string, error) {
+	findInput := FindDuplicateShowsInput{}
+	err := json.Unmarshal(input, &findInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	showsPath := findInput.ShowsPath
+	if showsPath == "" {
+		showsPath = os.Getenv("JELLYFIN_SHOWS_FOLDER")
+	}
+
+	if err := ValidatePath(showsPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	threshold := findInput.Threshold
+	if threshold <= 0 {
+		threshold = 0.85
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := fsys.ReadDir(showsPath)
+	if err != nil {
+		return "", err
+	}
+
+	var folders []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			folders = append(folders, entry.Name())
+		}
+	}
+
+	clusters := clusterTitles(folders, threshold)
+
+	// Only clusters with more than one folder are potential duplicates
+	results := []ShowCluster{}
+	for _, cluster := range clusters {
+		if len(cluster) < 2 {
+			continue
+		}
+
+		showCluster := ShowCluster{NormalizedTitle: normalizeTitle(cluster[0])}
+		for _, name := range cluster {
+			showCluster.Folders = append(showCluster.Folders, filepath.Join(showsPath, name))
+		}
+		results = append(results, showCluster)
+	}
+
+	jsonData, err := json.Marshal(results)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// normalizeTitle reduces a library folder name to a comparable form:
+// provider ids, years and qualifiers are removed, punctuation and case are
+// dropped and articles are ignored, so "Office, The (US)" becomes "office"
+func normalizeTitle(name string) string {
+	title := providerIDTagPattern.ReplaceAllString(name, " ")
+	title = parentheticalPattern.ReplaceAllString(title, " ")
+	title = strings.ToLower(title)
+	title = strings.ReplaceAll(title, "&", " and ")
+	title = strings.NewReplacer(".", " ", "_", " ").Replace(title)
+	title = strings.Join(strings.Fields(title), " ")
+
+	if match := trailingArticle.FindStringSubmatch(title); match != nil {
+		title = match[1]
+	}
+
+	title = nonAlphanumPattern.ReplaceAllString(title, "")
+	title = strings.Join(strings.Fields(title), " ")
+	return leadingArticle.ReplaceAllString(title, "")
+}
+
+// clusterTitles groups names whose normalized titles are equal or at least
+// threshold similar. Clusters and their members are sorted by name
+func clusterTitles(names []string, threshold float64) [][]string {
+	parent := make([]int, len(names))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	normalized := make([]string, len(names))
+	for i, name := range names {
+		normalized[i] = normalizeTitle(name)
+	}
+
+	for i := range names {
+		for j := i + 1; j < len(names); j++ {
+			if normalized[i] == "" || normalized[j] == "" {
+				continue
+			}
+			if titleSimilarity(normalized[i], normalized[j]) >= threshold {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := map[int][]string{}
+	for i, name := range names {
+		root := find(i)
+		groups[root] = append(groups[root], name)
+	}
+
+	clusters := make([][]string, 0, len(groups))
+	for _, group := range groups {
+		sort.Strings(group)
+		clusters = append(clusters, group)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i][0] < clusters[j][0] })
+
+	return clusters
+}
+
+// titleSimilarity returns 1 minus the normalized Levenshtein distance
+func titleSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type FindMainVideoInput struct {
+	Path              string `json:"path" jsonschema_description:"The release folder to scan, including its subfolders. Must be within the permitted folders."`
+	SampleThresholdMB int    `json:"sample_threshold_mb" jsonschema_description:"Videos named 'sample' that are smaller than this many megabytes are treated as samples. Defaults to 500."`
+}
+
+var FindMainVideoInputSchema = GenerateSchema[FindMainVideoInput]()
+
+var FindMainVideoDefinition = ToolDefinition{
+	Name:        "find_main_video",
+	Description: "Find the main feature of a release folder: the largest video that isn't a sample or an extra like a featurette or trailer, plus the runner-up to judge how clear the choice is. Samples and extras are listed separately.",
+	InputSchema: FindMainVideoInputSchema,
+	Function:    FindMainVideo,
+}
+
+type VideoCandidate struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+type FindMainVideoOutput struct {
+	Main     *VideoCandidate  `json:"main"`
+	RunnerUp *VideoCandidate  `json:"runner_up,omitempty"`
+	Samples  []VideoCandidate `json:"samples,omitempty"`
+	Extras   []VideoCandidate `json:"extras,omitempty"`
+}
+
+const defaultSampleThresholdMB = 500
+
+// extraKeywords mark bonus material that is never the main feature
+var extraKeywords = []string{"featurette", "trailer", "behind the scenes", "behindthescenes", "deleted scene", "interview", "extras", "bonus"}
+
+func FindMainVideo(input json.RawMessage) (string, error) {
+	findInput := FindMainVideoInput{}
+	err := json.Unmarshal(input, &findInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(findInput.Path); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	threshold := int64(findInput.SampleThresholdMB)
+	if threshold <= 0 {
+		threshold = defaultSampleThresholdMB
+	}
+	threshold <<= 20
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	videos, err := collectVideos(fsys, findInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	output := FindMainVideoOutput{}
+	var features []VideoCandidate
+	for _, video := range videos {
+		rel, _ := filepath.Rel(findInput.Path, video.Path)
+		name := strings.ToLower(rel)
+		switch {
+		case strings.Contains(name, "sample") && video.Size < threshold:
+			output.Samples = append(output.Samples, video)
+		case isExtraName(name):
+			output.Extras = append(output.Extras, video)
+		default:
+			features = append(features, video)
+		}
+	}
+
+	sort.Slice(features, func(i, j int) bool { return features[i].Size > features[j].Size })
+	if len(features) > 0 {
+		output.Main = &features[0]
+	}
+	if len(features) > 1 {
+		output.RunnerUp = &features[1]
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// collectVideos returns every video below dir with its size
+func collectVideos(fsys MediaFS, dir string) ([]VideoCandidate, error) {
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var videos []VideoCandidate
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			nested, err := collectVideos(fsys, path)
+			if err != nil {
+				return nil, err
+			}
+			videos = append(videos, nested...)
+		} else if isVideoFile(entry.Name()) {
+			videos = append(videos, VideoCandidate{Path: path, Size: entry.Size()})
+		}
+	}
+	return videos, nil
+}
+
+func isExtraName(name string) bool {
+	for _, keyword := range extraKeywords {
+		if strings.Contains(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
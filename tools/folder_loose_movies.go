@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type FolderLooseMoviesInput struct {
+	MoviesPath string `json:"movies_path" jsonschema_description:"The movies library root to scan. If empty, JELLYFIN_MOVIES_FOLDER is used."`
+	Apply      bool   `json:"apply" jsonschema_description:"If true, the proposed moves are executed and rolled back if any of them fails. If false, they are only reported."`
+}
+
+var FolderLooseMoviesInputSchema = GenerateSchema[FolderLooseMoviesInput]()
+
+var FolderLooseMoviesDefinition = ToolDefinition{
+	Name:           "folder_loose_movies",
+	Description:    "Find video files sitting loose at the top level of the movies library and propose wrapping each one in its own 'Title (Year)' folder, renaming the video and its same-named subtitle/nfo sidecars to match. Title and year are parsed from the file name; files that can't be parsed or look like TV episodes are skipped. Set apply to execute the whole batch, which is rolled back if any move fails.",
+	InputSchema:    FolderLooseMoviesInputSchema,
+	Function:       FolderLooseMovies,
+	WritesOnlyWith: "apply",
+}
+
+type LooseMovieMove struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+type LooseMovie struct {
+	File    string           `json:"file"`
+	Title   string           `json:"title,omitempty"`
+	Year    int              `json:"year,omitempty"`
+	Moves   []LooseMovieMove `json:"moves,omitempty"`
+	Skipped string           `json:"skipped,omitempty"`
+}
+
+type FolderLooseMoviesOutput struct {
+	Movies          []LooseMovie `json:"movies"`
+	AlreadyFoldered int          `json:"already_foldered"`
+	Applied         bool         `json:"applied"`
+}
+
+func FolderLooseMovies(input json.RawMessage) (string, error) {
+	folderInput := FolderLooseMoviesInput{}
+	err := json.Unmarshal(input, &folderInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	moviesPath := folderInput.MoviesPath
+	if moviesPath == "" {
+		moviesPath = os.Getenv("JELLYFIN_MOVIES_FOLDER")
+	}
+
+	if err := ValidatePath(moviesPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := fsys.ReadDir(moviesPath)
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	output := FolderLooseMoviesOutput{Movies: []LooseMovie{}}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			output.AlreadyFoldered++
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+
+	targets := map[string]bool{}
+	for _, name := range names {
+		if !isVideoFile(name) {
+			continue
+		}
+
+		movie := LooseMovie{File: filepath.Join(moviesPath, name)}
+		info := parseReleaseName(name)
+
+		switch {
+		case info.Title == "":
+			movie.Skipped = "could not parse a title from the file name"
+		case info.IsEpisode:
+			movie.Skipped = "looks like a TV episode, not a movie"
+		}
+		if movie.Skipped != "" {
+			output.Movies = append(output.Movies, movie)
+			continue
+		}
+
+		movie.Title = info.Title
+		movie.Year = info.Year
+
+		folderName := titleYearName(info.Title, info.Year)
+		folderPath := filepath.Join(moviesPath, folderName)
+
+		// Bring along sidecars like Movie.2009.en.srt, renamed to match
+		base := strings.TrimSuffix(name, filepath.Ext(name))
+		for _, sibling := range names {
+			if sibling != name && !strings.HasPrefix(sibling, base+".") {
+				continue
+			}
+			if sibling != name && !isSubtitleFile(sibling) && strings.ToLower(filepath.Ext(sibling)) != ".nfo" {
+				continue
+			}
+
+			target := filepath.Join(folderPath, folderName+strings.TrimPrefix(sibling, base))
+			movie.Moves = append(movie.Moves, LooseMovieMove{
+				Source: filepath.Join(moviesPath, sibling),
+				Target: target,
+			})
+		}
+
+		for _, move := range movie.Moves {
+			if _, err := fsys.Stat(move.Target); err == nil || targets[move.Target] {
+				movie.Skipped = "target already exists: " + move.Target
+			}
+		}
+		if movie.Skipped != "" {
+			movie.Moves = nil
+		} else {
+			for _, move := range movie.Moves {
+				targets[move.Target] = true
+			}
+		}
+
+		output.Movies = append(output.Movies, movie)
+	}
+
+	if folderInput.Apply && !DryRun {
+		var tx transaction
+		for _, movie := range output.Movies {
+			for _, move := range movie.Moves {
+				source, target := move.Source, move.Target
+				if err := moveMedia(source, target); err != nil {
+					return "", rollbackError(fmt.Errorf("failed to move %s: %v", source, err), tx.rollback())
+				}
+				tx.add("move of "+source, func() error {
+					if err := undoMove(source, target)(); err != nil {
+						return err
+					}
+					// Drop the movie folder again once it is empty
+					fsys.Remove(filepath.Dir(target))
+					return nil
+				})
+			}
+		}
+		output.Applied = true
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if folderInput.Apply && DryRun {
+		return dryRunOutput(jsonData), nil
+	}
+
+	return string(jsonData), nil
+}
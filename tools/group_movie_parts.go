@@ -0,0 +1,237 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type GroupMoviePartsInput struct {
+	Path  string `json:"path" jsonschema_description:"The folder holding the parts of a split movie, like Movie.cd1.avi and Movie.cd2.avi, or one of the part files."`
+	Title string `json:"title" jsonschema_description:"The movie title. If empty, it is parsed from the file names. Only allowed when a single movie is found."`
+	Year  int    `json:"year" jsonschema_description:"The release year. If 0, it is parsed from the file names."`
+	Apply bool   `json:"apply" jsonschema_description:"If true, the parts and their sidecars are moved into the movie folder. If false, the detected grouping is only reported, so it can be confirmed first."`
+}
+
+var GroupMoviePartsInputSchema = GenerateSchema[GroupMoviePartsInput]()
+
+var GroupMoviePartsDefinition = ToolDefinition{
+	Name:           "group_movie_parts",
+	Description:    "Detect movies split into several files named like cd1/cd2, part1/part2, pt1, disc1 or disk1, and rename them to Jellyfin's multi-part convention 'Movie (Year)/Movie (Year)-part1.ext' in the movies library, so Jellyfin plays them as one movie. Run it without apply first to confirm the grouping.",
+	InputSchema:    GroupMoviePartsInputSchema,
+	Function:       GroupMovieParts,
+	WritesOnlyWith: "apply",
+}
+
+type MoviePartGroup struct {
+	Title string             `json:"title"`
+	Year  int                `json:"year,omitempty"`
+	Parts []string           `json:"parts"`
+	Moves []CrossLibraryMove `json:"moves"`
+}
+
+type GroupMoviePartsOutput struct {
+	Groups  []MoviePartGroup `json:"groups"`
+	Notes   []string         `json:"notes,omitempty"`
+	Applied bool             `json:"applied"`
+}
+
+// moviePartPattern matches the part marker at the end of a file's base name,
+// e.g. "Movie.2009.cd1" or "Movie (2009) - Part 2"
+var moviePartPattern = regexp.MustCompile(`(?i)^(.*?)[ ._\-]*(?:cd|part|pt|disc|disk)[ ._\-]?(\d{1,2})$`)
+
+type moviePart struct {
+	path   string
+	number int
+}
+
+func GroupMovieParts(input json.RawMessage) (string, error) {
+	groupInput := GroupMoviePartsInput{}
+	err := json.Unmarshal(input, &groupInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(groupInput.Path); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+	info, err := fsys.Stat(groupInput.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read path: %v", err)
+	}
+	folder := groupInput.Path
+	if !info.IsDir() {
+		folder = filepath.Dir(groupInput.Path)
+	}
+
+	groups, notes, err := findMovieParts(fsys, folder)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		groups = groupsContaining(groups, groupInput.Path)
+	}
+	if len(groups) == 0 {
+		return "", fmt.Errorf("no multi-part movie found in %s, parts must be named like cd1/cd2 or part1/part2", folder)
+	}
+	if len(groups) > 1 && (groupInput.Title != "" || groupInput.Year != 0) {
+		return "", fmt.Errorf("found %d multi-part movies, title and year can only be given for a single one, pass one of the part files as path instead", len(groups))
+	}
+
+	prefixes := make([]string, 0, len(groups))
+	for prefix := range groups {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	output := GroupMoviePartsOutput{Notes: notes}
+	targets := map[string]bool{}
+	for _, prefix := range prefixes {
+		group, err := moviePartMoves(fsys, prefix, groups[prefix], groupInput.Title, groupInput.Year)
+		if err != nil {
+			return "", err
+		}
+		for _, move := range group.Moves {
+			if err := ValidatePath(move.Target); err != nil {
+				return "", fmt.Errorf("invalid target path: %v", err)
+			}
+			if _, err := fsys.Stat(move.Target); err == nil || targets[move.Target] {
+				return "", fmt.Errorf("target path already exists: %s", move.Target)
+			}
+			targets[move.Target] = true
+		}
+		output.Groups = append(output.Groups, group)
+	}
+
+	if groupInput.Apply && !DryRun {
+		var tx transaction
+		for _, group := range output.Groups {
+			for _, move := range group.Moves {
+				source, target := move.Source, move.Target
+				if err := moveMedia(source, target); err != nil {
+					return "", rollbackError(fmt.Errorf("failed to move %s: %v", source, err), tx.rollback())
+				}
+				tx.add("move of "+source, undoMove(source, target))
+			}
+		}
+		output.Applied = true
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if groupInput.Apply && DryRun {
+		return dryRunOutput(jsonData), nil
+	}
+
+	return string(jsonData), nil
+}
+
+// findMovieParts groups the video files of a folder that carry a part marker
+// by the name before it. A lone part or a group with gaps in its numbering
+// is reported in the notes instead, as it is probably not a split movie
+func findMovieParts(fsys MediaFS, folder string) (map[string][]moviePart, []string, error) {
+	entries, err := fsys.ReadDir(folder)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read directory: %v", err)
+	}
+
+	candidates := map[string][]moviePart{}
+	for _, entry := range entries {
+		if entry.IsDir() || !isVideoFile(entry.Name()) {
+			continue
+		}
+		base := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		match := moviePartPattern.FindStringSubmatch(base)
+		if match == nil {
+			continue
+		}
+		number, _ := strconv.Atoi(match[2])
+		prefix := strings.ToLower(strings.TrimSpace(match[1]))
+		candidates[prefix] = append(candidates[prefix], moviePart{path: filepath.Join(folder, entry.Name()), number: number})
+	}
+
+	groups := map[string][]moviePart{}
+	var notes []string
+	for prefix, parts := range candidates {
+		sort.Slice(parts, func(i, j int) bool { return parts[i].number < parts[j].number })
+		if len(parts) < 2 {
+			notes = append(notes, fmt.Sprintf("%s looks like a part but has no siblings, skipped", filepath.Base(parts[0].path)))
+			continue
+		}
+		consecutive := true
+		for i, part := range parts {
+			if part.number != i+1 {
+				consecutive = false
+			}
+		}
+		if !consecutive {
+			notes = append(notes, fmt.Sprintf("the parts of %q are not numbered 1 to %d, skipped", prefix, len(parts)))
+			continue
+		}
+		groups[prefix] = parts
+	}
+	sort.Strings(notes)
+	return groups, notes, nil
+}
+
+// groupsContaining keeps only the group the file belongs to
+func groupsContaining(groups map[string][]moviePart, file string) map[string][]moviePart {
+	for prefix, parts := range groups {
+		for _, part := range parts {
+			if part.path == filepath.Clean(file) {
+				return map[string][]moviePart{prefix: parts}
+			}
+		}
+	}
+	return nil
+}
+
+// moviePartMoves computes where each part and its sidecars go. The title and
+// year are parsed from the name of the first part before its marker unless
+// given
+func moviePartMoves(fsys MediaFS, prefix string, parts []moviePart, title string, year int) (MoviePartGroup, error) {
+	name := strings.TrimSuffix(filepath.Base(parts[0].path), filepath.Ext(parts[0].path))
+	if match := moviePartPattern.FindStringSubmatch(name); match != nil {
+		name = match[1]
+	}
+	parsed := parseReleaseName(name)
+	if title == "" {
+		title = parsed.Title
+	}
+	if year == 0 {
+		year = parsed.Year
+	}
+	if title == "" {
+		return MoviePartGroup{}, fmt.Errorf("could not parse a title for the parts of %q, please provide one", prefix)
+	}
+
+	movieName := titleYearName(title, year)
+	movieFolder := filepath.Join(os.Getenv("JELLYFIN_MOVIES_FOLDER"), movieName)
+	group := MoviePartGroup{Title: title, Year: year}
+	for _, part := range parts {
+		group.Parts = append(group.Parts, part.path)
+
+		files, err := sidecarFiles(fsys, part.path)
+		if err != nil {
+			return MoviePartGroup{}, err
+		}
+		sourceBase := strings.TrimSuffix(filepath.Base(part.path), filepath.Ext(part.path))
+		targetBase := fmt.Sprintf("%s-part%d", movieName, part.number)
+		for _, file := range files {
+			target := filepath.Join(movieFolder, targetBase+strings.TrimPrefix(filepath.Base(file), sourceBase))
+			group.Moves = append(group.Moves, CrossLibraryMove{Source: file, Target: target})
+		}
+	}
+	return group, nil
+}
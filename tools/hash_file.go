@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type HashFileInput struct {
+	Path      string `json:"path" jsonschema_description:"The file to hash. Must be within the permitted folders."`
+	PartialMB int    `json:"partial_mb" jsonschema_description:"If set, only the first N megabytes are hashed, a quick way to compare large videos. Both files must be hashed with the same value to be comparable. If 0, the whole file is hashed."`
+}
+
+var HashFileInputSchema = GenerateSchema[HashFileInput]()
+
+var HashFileDefinition = ToolDefinition{
+	Name:        "hash_file",
+	Description: "Compute the SHA-256 of a file, or of its first partial_mb megabytes for a quick check of large videos. Compare the digests of a download and a library file to avoid importing the same movie twice under different names.",
+	InputSchema: HashFileInputSchema,
+	Function:    HashFile,
+}
+
+type HashFileOutput struct {
+	Path        string `json:"path"`
+	SHA256      string `json:"sha256"`
+	Size        int64  `json:"size"`
+	HashedBytes int64  `json:"hashed_bytes"`
+	Partial     bool   `json:"partial"`
+}
+
+func HashFile(input json.RawMessage) (string, error) {
+	hashInput := HashFileInput{}
+	err := json.Unmarshal(input, &hashInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(hashInput.Path); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+	if hashInput.PartialMB < 0 {
+		return "", fmt.Errorf("partial_mb must not be negative")
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	file, err := fsys.Open(hashInput.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a folder", hashInput.Path)
+	}
+
+	limit := int64(hashInput.PartialMB) << 20
+	digest, err := hashReader(file, limit)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %v", hashInput.Path, err)
+	}
+
+	output := HashFileOutput{Path: hashInput.Path, SHA256: digest, Size: info.Size(), HashedBytes: info.Size()}
+	if limit > 0 && limit < info.Size() {
+		output.HashedBytes = limit
+		output.Partial = true
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,288 @@
+package tools
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type GenerateHashManifestInput struct {
+	LibraryPath  string `json:"library_path" jsonschema_description:"The folder whose files are hashed, recursively."`
+	ManifestPath string `json:"manifest_path" jsonschema_description:"Where to write the manifest. Paths in it are relative to the manifest's folder, so keep it inside or above library_path."`
+	Workers      int    `json:"workers" jsonschema_description:"How many files to hash at once. If 0, defaults to 4."`
+}
+
+var GenerateHashManifestInputSchema = GenerateSchema[GenerateHashManifestInput]()
+
+var GenerateHashManifestDefinition = ToolDefinition{
+	Name:        "generate_hash_manifest",
+	Description: "Hash every file in a library folder with SHA-256 and write a manifest in sha256sum format ('<hash>  <relative path>' per line), for later integrity checks with verify_hash_manifest. Refuses to overwrite an existing manifest.",
+	InputSchema: GenerateHashManifestInputSchema,
+	Function:    GenerateHashManifest,
+}
+
+type VerifyHashManifestInput struct {
+	ManifestPath string `json:"manifest_path" jsonschema_description:"The sha256sum-format manifest to verify against."`
+	Workers      int    `json:"workers" jsonschema_description:"How many files to hash at once. If 0, defaults to 4."`
+}
+
+var VerifyHashManifestInputSchema = GenerateSchema[VerifyHashManifestInput]()
+
+var VerifyHashManifestDefinition = ToolDefinition{
+	Name:        "verify_hash_manifest",
+	Description: "Verify files against a SHA-256 manifest in sha256sum format. Reports files whose content no longer matches and files that are missing.",
+	InputSchema: VerifyHashManifestInputSchema,
+	Function:    VerifyHashManifest,
+}
+
+type HashMismatch struct {
+	Path     string `json:"path"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+type VerifyHashManifestOutput struct {
+	Verified   int            `json:"verified"`
+	Mismatched []HashMismatch `json:"mismatched"`
+	Missing    []string       `json:"missing"`
+	Errors     []string       `json:"errors,omitempty"`
+}
+
+type hashResult struct {
+	path string
+	hash string
+	err  error
+}
+
+func GenerateHashManifest(input json.RawMessage) (string, error) {
+	generateInput := GenerateHashManifestInput{}
+	err := json.Unmarshal(input, &generateInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(generateInput.LibraryPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+	if err := ValidatePath(generateInput.ManifestPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := fsys.Stat(generateInput.ManifestPath); err == nil {
+		return "", fmt.Errorf("manifest already exists: %s", generateInput.ManifestPath)
+	}
+
+	manifestPath, err := filepath.Abs(generateInput.ManifestPath)
+	if err != nil {
+		return "", err
+	}
+
+	var paths []string
+	err = walkMedia(fsys, generateInput.LibraryPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if abs, _ := filepath.Abs(path); info.Mode().IsRegular() && abs != manifestPath {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	manifestDir := filepath.Dir(manifestPath)
+	var lines []string
+	for _, result := range hashFiles(fsys, paths, generateInput.Workers) {
+		if result.err != nil {
+			return "", fmt.Errorf("failed to hash %s: %v", result.path, result.err)
+		}
+		abs, _ := filepath.Abs(result.path)
+		rel, err := filepath.Rel(manifestDir, abs)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, result.hash+"  "+filepath.ToSlash(rel))
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][66:] < lines[j][66:] })
+
+	content := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		content += "\n"
+	}
+	if DryRun {
+		return fmt.Sprintf("%swould write %d hashes to %s", dryRunPrefix, len(lines), manifestPath), nil
+	}
+	if err := writeMediaFile(fsys, manifestPath, []byte(content)); err != nil {
+		return "", fmt.Errorf("failed to write manifest: %v", err)
+	}
+
+	return fmt.Sprintf("Wrote %d hashes to %s", len(lines), manifestPath), nil
+}
+
+func VerifyHashManifest(input json.RawMessage) (string, error) {
+	verifyInput := VerifyHashManifestInput{}
+	err := json.Unmarshal(input, &verifyInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(verifyInput.ManifestPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	expected, err := readHashManifest(fsys, verifyInput.ManifestPath)
+	if err != nil {
+		return "", err
+	}
+
+	output := VerifyHashManifestOutput{Mismatched: []HashMismatch{}, Missing: []string{}}
+	var paths []string
+	for path := range expected {
+		if err := ValidatePath(path); err != nil {
+			output.Errors = append(output.Errors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		paths = append(paths, path)
+	}
+
+	for _, result := range hashFiles(fsys, paths, verifyInput.Workers) {
+		switch {
+		case os.IsNotExist(result.err):
+			output.Missing = append(output.Missing, result.path)
+		case result.err != nil:
+			output.Errors = append(output.Errors, fmt.Sprintf("%s: %v", result.path, result.err))
+		case result.hash != expected[result.path]:
+			output.Mismatched = append(output.Mismatched, HashMismatch{
+				Path:     result.path,
+				Expected: expected[result.path],
+				Actual:   result.hash,
+			})
+		default:
+			output.Verified++
+		}
+	}
+
+	sort.Strings(output.Missing)
+	sort.Slice(output.Mismatched, func(i, j int) bool { return output.Mismatched[i].Path < output.Mismatched[j].Path })
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// readHashManifest parses a sha256sum-format manifest into a map from path,
+// resolved against the manifest's folder, to hash
+func readHashManifest(fsys MediaFS, manifestPath string) (map[string]string, error) {
+	file, err := fsys.Open(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	manifestDir := filepath.Dir(manifestPath)
+	hashes := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		hash, path, ok := strings.Cut(line, " ")
+		path = strings.TrimPrefix(strings.TrimLeft(path, " "), "*")
+		if !ok || len(hash) != 64 || path == "" {
+			return nil, fmt.Errorf("invalid manifest line %d: %s", lineNumber, line)
+		}
+
+		path = filepath.FromSlash(path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(manifestDir, path)
+		}
+		hashes[path] = strings.ToLower(hash)
+	}
+
+	return hashes, scanner.Err()
+}
+
+// hashFiles hashes files with at most workers running at once
+func hashFiles(fsys MediaFS, paths []string, workers int) []hashResult {
+	if workers <= 0 {
+		workers = 4
+	}
+
+	jobs := make(chan string)
+	results := make(chan hashResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				hash, err := hashFile(fsys, path)
+				results <- hashResult{path: path, hash: hash, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for _, path := range paths {
+			jobs <- path
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	collected := make([]hashResult, 0, len(paths))
+	for result := range results {
+		collected = append(collected, result)
+	}
+	return collected
+}
+
+// hashFile returns the hex encoded SHA-256 of a file's content
+func hashFile(fsys MediaFS, path string) (string, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return hashReader(file, 0)
+}
+
+// hashReader returns the hex SHA-256 of the first limit bytes of r, or of
+// all of it when limit is 0
+func hashReader(r io.Reader, limit int64) (string, error) {
+	if limit > 0 {
+		r = io.LimitReader(r, limit)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
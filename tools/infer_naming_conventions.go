@@ -0,0 +1,347 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type InferNamingConventionsInput struct {
+	LibraryPath string `json:"library_path" jsonschema_description:"The root folder of a correctly organized library to learn naming conventions from. Must be within the permitted folders."`
+	SampleSize  int    `json:"sample_size" jsonschema_description:"Maximum number of top-level items to sample. If 0, samples up to 50 items."`
+}
+
+var InferNamingConventionsInputSchema = GenerateSchema[InferNamingConventionsInput]()
+
+var InferNamingConventionsDefinition = ToolDefinition{
+	Name:        "infer_naming_conventions",
+	Description: "Sample an existing, correctly organized library root and infer its naming conventions: word separator, year format, season folder format, episode format and quality tagging. Returns a convention profile that can be used to name new imports so they match the existing library exactly.",
+	InputSchema: InferNamingConventionsInputSchema,
+	Function:    InferNamingConventions,
+}
+
+// ConventionProfile describes how the items of a library are named
+type ConventionProfile struct {
+	// Separator between words of a title: " ", "." or "_"
+	Separator string `json:"separator"`
+	// YearFormat is one of "parentheses" (2009), "brackets" [2009], "bare" 2009 or "none"
+	YearFormat string `json:"year_format"`
+	// SeasonFormat is one of "padded" (Season 01), "unpadded" (Season 1), "short" (S01) or "none"
+	SeasonFormat string `json:"season_format"`
+	// EpisodeFormat is one of "SxxEyy", "sxxeyy" or "NxNN"
+	EpisodeFormat string `json:"episode_format"`
+	// QualityFormat is one of "brackets" [1080p], "dash" - 1080p, "bare" 1080p or "none"
+	QualityFormat string `json:"quality_format"`
+}
+
+// DefaultConventionProfile follows the naming recommended by the Jellyfin docs
+var DefaultConventionProfile = ConventionProfile{
+	Separator:     " ",
+	YearFormat:    "parentheses",
+	SeasonFormat:  "padded",
+	EpisodeFormat: "SxxEyy",
+	QualityFormat: "none",
+}
+
+type InferNamingConventionsOutput struct {
+	Profile         ConventionProfile         `json:"profile"`
+	ItemsSampled    int                       `json:"items_sampled"`
+	Votes           map[string]map[string]int `json:"votes"`
+	ExampleNames    []string                  `json:"example_names"`
+	ProfileExamples map[string]string         `json:"profile_examples"`
+}
+
+var (
+	parenYearPattern     = regexp.MustCompile(`\((19|20)\d{2}\)`)
+	bracketYearPattern   = regexp.MustCompile(`\[(19|20)\d{2}\]`)
+	bareYearPattern      = regexp.MustCompile(`(^|[ ._\-])(19|20)\d{2}($|[ ._\-])`)
+	seasonFolderPattern  = regexp.MustCompile(`^(?i:season)[ ._]?(\d+)$`)
+	shortSeasonPattern   = regexp.MustCompile(`^[Ss](\d+)$`)
+	upperEpisodePattern  = regexp.MustCompile(`S\d+E\d+`)
+	lowerEpisodePattern  = regexp.MustCompile(`s\d+e\d+`)
+	crossEpisodePattern  = regexp.MustCompile(`(^|[ ._\-])\d{1,2}x\d{2,3}($|[ ._\-])`)
+	bracketQualityRegexp = regexp.MustCompile(`(?i)\[(2160p|1080p|720p|576p|480p|4k)[^\]]*\]`)
+	dashQualityRegexp    = regexp.MustCompile(`(?i) - (2160p|1080p|720p|576p|480p|4k)\b`)
+	bareQualityRegexp    = regexp.MustCompile(`(?i)[ ._](2160p|1080p|720p|576p|480p|4k)\b`)
+)
+
+func InferNamingConventions(input json.RawMessage) (string, error) {
+	inferInput := InferNamingConventionsInput{}
+	err := json.Unmarshal(input, &inferInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(inferInput.LibraryPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	sampleSize := inferInput.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = 50
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := inferConventionProfile(fsys, inferInput.LibraryPath, sampleSize)
+	if err != nil {
+		return "", err
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+func inferConventionProfile(fsys MediaFS, libraryPath string, sampleSize int) (InferNamingConventionsOutput, error) {
+	entries, err := fsys.ReadDir(libraryPath)
+	if err != nil {
+		return InferNamingConventionsOutput{}, err
+	}
+
+	votes := map[string]map[string]int{
+		"separator": {},
+		"year":      {},
+		"season":    {},
+		"episode":   {},
+		"quality":   {},
+	}
+
+	output := InferNamingConventionsOutput{Votes: votes}
+
+	for _, entry := range entries {
+		if output.ItemsSampled >= sampleSize {
+			break
+		}
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		output.ItemsSampled++
+		if len(output.ExampleNames) < 5 {
+			output.ExampleNames = append(output.ExampleNames, entry.Name())
+		}
+
+		voteTitleConventions(votes, entry.Name(), entry.IsDir())
+
+		if !entry.IsDir() {
+			continue
+		}
+
+		// Look inside the item for season folders and video files
+		itemPath := filepath.Join(libraryPath, entry.Name())
+		children, err := fsys.ReadDir(itemPath)
+		if err != nil {
+			continue
+		}
+
+		for _, child := range children {
+			if child.IsDir() {
+				if format := seasonFolderFormat(child.Name()); format != "" {
+					votes["season"][format]++
+					voteEpisodeConventions(fsys, votes, filepath.Join(itemPath, child.Name()))
+				}
+				continue
+			}
+			if isVideoFile(child.Name()) {
+				voteQualityConvention(votes, child.Name())
+			}
+		}
+	}
+
+	output.Profile = ConventionProfile{
+		Separator:     topVote(votes["separator"], DefaultConventionProfile.Separator),
+		YearFormat:    topVote(votes["year"], "none"),
+		SeasonFormat:  topVote(votes["season"], "none"),
+		EpisodeFormat: topVote(votes["episode"], DefaultConventionProfile.EpisodeFormat),
+		QualityFormat: topVote(votes["quality"], "none"),
+	}
+
+	output.ProfileExamples = map[string]string{
+		"movie":   output.Profile.MovieName("Example Movie", 2009, "1080p"),
+		"season":  output.Profile.SeasonFolder(1),
+		"episode": output.Profile.EpisodeName("Example Show", 1, 2, "1080p"),
+	}
+
+	return output, nil
+}
+
+// voteTitleConventions votes on the year format and separator of an item's
+// name. Folder names keep everything after their last dot, as in
+// The.Matrix.1999 or Mr. Robot
+func voteTitleConventions(votes map[string]map[string]int, name string, isDir bool) {
+	title := name
+	if !isDir {
+		title = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	switch {
+	case parenYearPattern.MatchString(title):
+		votes["year"]["parentheses"]++
+	case bracketYearPattern.MatchString(title):
+		votes["year"]["brackets"]++
+	case bareYearPattern.MatchString(title):
+		votes["year"]["bare"]++
+	default:
+		votes["year"]["none"]++
+	}
+
+	// The separator is whichever word delimiter dominates the name
+	spaces := strings.Count(title, " ")
+	dots := strings.Count(title, ".")
+	underscores := strings.Count(title, "_")
+	switch {
+	case dots > spaces && dots >= underscores:
+		votes["separator"]["."]++
+	case underscores > spaces && underscores > dots:
+		votes["separator"]["_"]++
+	case spaces > 0:
+		votes["separator"][" "]++
+	}
+}
+
+func voteEpisodeConventions(fsys MediaFS, votes map[string]map[string]int, seasonPath string) {
+	episodes, err := fsys.ReadDir(seasonPath)
+	if err != nil {
+		return
+	}
+
+	for _, episode := range episodes {
+		if episode.IsDir() || !isVideoFile(episode.Name()) {
+			continue
+		}
+
+		switch {
+		case upperEpisodePattern.MatchString(episode.Name()):
+			votes["episode"]["SxxEyy"]++
+		case lowerEpisodePattern.MatchString(episode.Name()):
+			votes["episode"]["sxxeyy"]++
+		case crossEpisodePattern.MatchString(episode.Name()):
+			votes["episode"]["NxNN"]++
+		}
+
+		voteQualityConvention(votes, episode.Name())
+	}
+}
+
+func voteQualityConvention(votes map[string]map[string]int, name string) {
+	switch {
+	case bracketQualityRegexp.MatchString(name):
+		votes["quality"]["brackets"]++
+	case dashQualityRegexp.MatchString(name):
+		votes["quality"]["dash"]++
+	case bareQualityRegexp.MatchString(name):
+		votes["quality"]["bare"]++
+	default:
+		votes["quality"]["none"]++
+	}
+}
+
+func seasonFolderFormat(name string) string {
+	if match := seasonFolderPattern.FindStringSubmatch(name); match != nil {
+		if len(match[1]) >= 2 {
+			return "padded"
+		}
+		return "unpadded"
+	}
+	if shortSeasonPattern.MatchString(name) {
+		return "short"
+	}
+	return ""
+}
+
+func topVote(counts map[string]int, fallback string) string {
+	best := fallback
+	bestCount := 0
+	for value, count := range counts {
+		// Break ties alphabetically so the result is deterministic
+		if count > bestCount || (count == bestCount && count > 0 && value < best) {
+			best = value
+			bestCount = count
+		}
+	}
+	return best
+}
+
+// MovieName builds a movie folder or file base name following the profile.
+// A year of 0 or an empty quality are left out
+func (p ConventionProfile) MovieName(title string, year int, quality string) string {
+	name := p.joinWords(title)
+
+	if year > 0 {
+		switch p.YearFormat {
+		case "parentheses":
+			name += " (" + strconv.Itoa(year) + ")"
+		case "brackets":
+			name += " [" + strconv.Itoa(year) + "]"
+		case "bare":
+			name += p.separator() + strconv.Itoa(year)
+		}
+	}
+
+	return name + p.qualitySuffix(quality)
+}
+
+// SeasonFolder builds the name of a season folder following the profile
+func (p ConventionProfile) SeasonFolder(season int) string {
+	switch p.SeasonFormat {
+	case "unpadded":
+		return fmt.Sprintf("Season %d", season)
+	case "short":
+		return fmt.Sprintf("S%02d", season)
+	default:
+		return fmt.Sprintf("Season %02d", season)
+	}
+}
+
+// EpisodeName builds an episode file base name following the profile
+func (p ConventionProfile) EpisodeName(show string, season, episode int, quality string) string {
+	var marker string
+	switch p.EpisodeFormat {
+	case "sxxeyy":
+		marker = fmt.Sprintf("s%02de%02d", season, episode)
+	case "NxNN":
+		marker = fmt.Sprintf("%dx%02d", season, episode)
+	default:
+		marker = fmt.Sprintf("S%02dE%02d", season, episode)
+	}
+
+	return p.joinWords(show) + p.separator() + marker + p.qualitySuffix(quality)
+}
+
+func (p ConventionProfile) separator() string {
+	if p.Separator == "" {
+		return " "
+	}
+	return p.Separator
+}
+
+func (p ConventionProfile) joinWords(title string) string {
+	return strings.Join(strings.Fields(title), p.separator())
+}
+
+func (p ConventionProfile) qualitySuffix(quality string) string {
+	if quality == "" {
+		return ""
+	}
+
+	switch p.QualityFormat {
+	case "brackets":
+		return " [" + quality + "]"
+	case "dash":
+		return " - " + quality
+	case "bare":
+		return p.separator() + quality
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestInferConventionProfile(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  ConventionProfile
+	}{
+		{
+			name: "jellyfin movies",
+			files: []string{
+				"The Matrix (1999)/The Matrix (1999) - 1080p.mkv",
+				"Heat (1995)/Heat (1995) - 2160p.mkv",
+				"Mr. Nobody (2009)/Mr. Nobody (2009) - 1080p.mkv",
+			},
+			want: ConventionProfile{Separator: " ", YearFormat: "parentheses", SeasonFormat: "none", EpisodeFormat: "SxxEyy", QualityFormat: "dash"},
+		},
+		{
+			name: "dotted movie folders",
+			files: []string{
+				"The.Matrix.1999/The.Matrix.1999.1080p.mkv",
+				"Heat.1995/Heat.1995.720p.mkv",
+				"Blade.Runner.1982/Blade.Runner.1982.1080p.mkv",
+			},
+			want: ConventionProfile{Separator: ".", YearFormat: "bare", SeasonFormat: "none", EpisodeFormat: "SxxEyy", QualityFormat: "bare"},
+		},
+		{
+			name: "shows",
+			files: []string{
+				"Mr. Robot (2015)/Season 1/Mr. Robot s01e01 [1080p].mkv",
+				"Mr. Robot (2015)/Season 1/Mr. Robot s01e02 [1080p].mkv",
+				"Dark [2017]/Season 2/Dark s02e01 [720p].mkv",
+				"Fargo [2014]/Season 1/Fargo s01e01 [1080p].mkv",
+			},
+			want: ConventionProfile{Separator: " ", YearFormat: "brackets", SeasonFormat: "unpadded", EpisodeFormat: "sxxeyy", QualityFormat: "brackets"},
+		},
+		{
+			name: "short seasons and cross episodes",
+			files: []string{
+				"Show_One/S01/Show_One 1x01.mkv",
+				"Show_Two/S03/Show_Two 3x04.mkv",
+			},
+			want: ConventionProfile{Separator: "_", YearFormat: "none", SeasonFormat: "short", EpisodeFormat: "NxNN", QualityFormat: "none"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			library := t.TempDir()
+			for _, file := range tt.files {
+				writeTestFile(t, filepath.Join(library, filepath.FromSlash(file)))
+			}
+
+			output, err := inferConventionProfile(localFS{}, library, 50)
+			if err != nil {
+				t.Fatalf("inferConventionProfile: %v", err)
+			}
+			if output.Profile != tt.want {
+				t.Errorf("profile = %+v, want %+v (votes %v)", output.Profile, tt.want, output.Votes)
+			}
+		})
+	}
+}
+
+func TestVoteTitleConventionsKeepsFolderDots(t *testing.T) {
+	tests := []struct {
+		name          string
+		isDir         bool
+		wantYear      string
+		wantSeparator string
+	}{
+		{name: "The.Matrix.1999", isDir: true, wantYear: "bare", wantSeparator: "."},
+		{name: "Mr. Robot", isDir: true, wantYear: "none", wantSeparator: " "},
+		{name: "The.Matrix.1999.mkv", wantYear: "bare", wantSeparator: "."},
+		{name: "Heat (1995).mkv", wantYear: "parentheses", wantSeparator: " "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			votes := map[string]map[string]int{"year": {}, "separator": {}}
+			voteTitleConventions(votes, tt.name, tt.isDir)
+			if votes["year"][tt.wantYear] != 1 {
+				t.Errorf("year votes = %v, want %s", votes["year"], tt.wantYear)
+			}
+			if votes["separator"][tt.wantSeparator] != 1 {
+				t.Errorf("separator votes = %v, want %q", votes["separator"], tt.wantSeparator)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var jellyfinHTTPClient = &http.Client{Timeout: 30 * time.Second, Transport: networkTransport}
+
+// jellyfinGet performs a GET against the Jellyfin server API and decodes the
+// JSON response into out. Requires JELLYFIN_SERVER_URL and JELLYFIN_API_KEY
+func jellyfinGet(path string, query url.Values, out any) error {
+	req, err := newJellyfinRequest(http.MethodGet, path, query)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := jellyfinHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query Jellyfin: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return jellyfinStatusError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Jellyfin response: %w", err)
+	}
+
+	return nil
+}
+
+// jellyfinPost performs a POST without a body against the Jellyfin server
+// API and returns the response status. Any 2xx status is a success
+func jellyfinPost(path string, query url.Values) (int, error) {
+	req, err := newJellyfinRequest(http.MethodPost, path, query)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := jellyfinHTTPClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach Jellyfin: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, jellyfinStatusError(resp)
+	}
+	return resp.StatusCode, nil
+}
+
+// newJellyfinRequest builds an authenticated request against the Jellyfin
+// server from JELLYFIN_SERVER_URL and JELLYFIN_API_KEY
+func newJellyfinRequest(method, path string, query url.Values) (*http.Request, error) {
+	serverURL := strings.TrimRight(os.Getenv("JELLYFIN_SERVER_URL"), "/")
+	apiKey := os.Getenv("JELLYFIN_API_KEY")
+	if serverURL == "" || apiKey == "" {
+		return nil, fmt.Errorf("JELLYFIN_SERVER_URL and JELLYFIN_API_KEY environment variables must be set to use the Jellyfin API")
+	}
+
+	requestURL := serverURL + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Jellyfin request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("MediaBrowser Token=%q", apiKey))
+	return req, nil
+}
+
+// jellyfinStatusError reports an unexpected status with the start of the
+// response body, which usually explains it
+func jellyfinStatusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("Jellyfin returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// JellyfinItem is a library item as returned by the Jellyfin API
+type JellyfinItem struct {
+	ID             string `json:"Id"`
+	Name           string `json:"Name"`
+	Type           string `json:"Type"`
+	Path           string `json:"Path"`
+	ProductionYear int    `json:"ProductionYear"`
+}
+
+// jellyfinLibrary lists the items Jellyfin has indexed
+type jellyfinLibrary interface {
+	Items(types []string) ([]JellyfinItem, error)
+	Search(term string, types []string, limit int) ([]JellyfinItem, error)
+}
+
+var jellyfin jellyfinLibrary = jellyfinAPILibrary{}
+
+type jellyfinAPILibrary struct{}
+
+// jellyfinPageSize is how many items are requested per page
+const jellyfinPageSize = 500
+
+func (jellyfinAPILibrary) Items(types []string) ([]JellyfinItem, error) {
+	var items []JellyfinItem
+	for start := 0; ; start += jellyfinPageSize {
+		var page struct {
+			Items            []JellyfinItem `json:"Items"`
+			TotalRecordCount int            `json:"TotalRecordCount"`
+		}
+
+		query := url.Values{
+			"Recursive":        {"true"},
+			"Fields":           {"Path"},
+			"IncludeItemTypes": {strings.Join(types, ",")},
+			"StartIndex":       {strconv.Itoa(start)},
+			"Limit":            {strconv.Itoa(jellyfinPageSize)},
+		}
+		if err := jellyfinGet("/Items", query, &page); err != nil {
+			return nil, err
+		}
+
+		items = append(items, page.Items...)
+		if len(page.Items) == 0 || len(items) >= page.TotalRecordCount {
+			return items, nil
+		}
+	}
+}
+
+func (jellyfinAPILibrary) Search(term string, types []string, limit int) ([]JellyfinItem, error) {
+	var result struct {
+		Items []JellyfinItem `json:"Items"`
+	}
+
+	query := url.Values{
+		"Recursive":        {"true"},
+		"SearchTerm":       {term},
+		"Fields":           {"Path,ProductionYear"},
+		"IncludeItemTypes": {strings.Join(types, ",")},
+		"Limit":            {strconv.Itoa(limit)},
+	}
+	if err := jellyfinGet("/Items", query, &result); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type JellyfinSearchLibraryInput struct {
+	SearchTerm string `json:"search_term" jsonschema_description:"The title to look for, e.g. 'Breaking Bad'. Jellyfin matches it against item names."`
+	MediaType  string `json:"media_type" jsonschema_description:"'movie' or 'series' to only search that kind of item. If empty, both are searched."`
+	MaxResults int    `json:"max_results" jsonschema_description:"The maximum number of items to return. Defaults to 20."`
+}
+
+var JellyfinSearchLibraryInputSchema = GenerateSchema[JellyfinSearchLibraryInput]()
+
+var JellyfinSearchLibraryDefinition = ToolDefinition{
+	Name:        "jellyfin_search_library",
+	Description: "Search the movies and shows the Jellyfin server already has, returning their name, year, id and path. Use it before moving a title into the library, to find out whether it is already present, possibly under a different folder name, instead of creating a duplicate entry. Requires JELLYFIN_SERVER_URL and JELLYFIN_API_KEY.",
+	InputSchema: JellyfinSearchLibraryInputSchema,
+	Function:    JellyfinSearchLibrary,
+}
+
+type JellyfinLibraryMatch struct {
+	Name string `json:"name"`
+	Year int    `json:"year,omitempty"`
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Path string `json:"path,omitempty"`
+}
+
+const defaultJellyfinSearchResults = 20
+
+// jellyfinSearchTypes maps media_type to the Jellyfin item types searched
+var jellyfinSearchTypes = map[string][]string{
+	"":       {"Movie", "Series"},
+	"movie":  {"Movie"},
+	"series": {"Series"},
+}
+
+func JellyfinSearchLibrary(input json.RawMessage) (string, error) {
+	searchInput := JellyfinSearchLibraryInput{}
+	err := json.Unmarshal(input, &searchInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	term := strings.TrimSpace(searchInput.SearchTerm)
+	if term == "" {
+		return "", fmt.Errorf("search_term must not be empty")
+	}
+	types, ok := jellyfinSearchTypes[searchInput.MediaType]
+	if !ok {
+		return "", fmt.Errorf("media_type must be 'movie' or 'series', got %q", searchInput.MediaType)
+	}
+	maxResults := searchInput.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultJellyfinSearchResults
+	}
+
+	items, err := jellyfin.Search(term, types, maxResults)
+	if err != nil {
+		return "", err
+	}
+
+	matches := []JellyfinLibraryMatch{}
+	for _, item := range items {
+		matches = append(matches, JellyfinLibraryMatch{
+			Name: item.Name,
+			Year: item.ProductionYear,
+			Type: item.Type,
+			ID:   item.ID,
+			Path: item.Path,
+		})
+	}
+
+	jsonData, err := json.Marshal(matches)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
@@ -0,0 +1,68 @@
+package tools
+
+import "strings"
+
+// languageCodes maps the language tokens found in subtitle and audio file
+// names, as ISO 639-1/639-2 codes or English and native names, to ISO 639-1
+var languageCodes = map[string]string{}
+
+func init() {
+	languages := map[string][]string{
+		"en": {"eng", "english"},
+		"es": {"spa", "spanish", "español", "espanol", "castellano", "latino"},
+		"fr": {"fre", "fra", "french", "français", "francais"},
+		"de": {"ger", "deu", "german", "deutsch"},
+		"it": {"ita", "italian", "italiano"},
+		"pt": {"por", "portuguese", "português", "portugues", "pob", "ptbr", "pt-br"},
+		"nl": {"dut", "nld", "dutch", "nederlands"},
+		"ja": {"jpn", "japanese"},
+		"zh": {"chi", "zho", "chinese"},
+		"ko": {"kor", "korean"},
+		"ru": {"rus", "russian"},
+		"ar": {"ara", "arabic"},
+		"sv": {"swe", "swedish", "svenska"},
+		"no": {"nor", "nob", "norwegian", "norsk"},
+		"da": {"dan", "danish", "dansk"},
+		"fi": {"fin", "finnish", "suomi"},
+		"pl": {"pol", "polish", "polski"},
+		"tr": {"tur", "turkish"},
+		"he": {"heb", "hebrew"},
+		"el": {"gre", "ell", "greek"},
+		"cs": {"cze", "ces", "czech"},
+		"hu": {"hun", "hungarian", "magyar"},
+		"ro": {"rum", "ron", "romanian"},
+		"uk": {"ukr", "ukrainian"},
+		"vi": {"vie", "vietnamese"},
+		"th": {"tha", "thai"},
+		"id": {"ind", "indonesian"},
+		"hr": {"hrv", "croatian"},
+		"bg": {"bul", "bulgarian"},
+	}
+
+	for code, aliases := range languages {
+		languageCodes[code] = code
+		for _, alias := range aliases {
+			languageCodes[alias] = code
+		}
+	}
+
+	// The "hi" code itself is left out on purpose, in subtitle names it
+	// means hearing impaired rather than Hindi
+	languageCodes["hin"] = "hi"
+	languageCodes["hindi"] = "hi"
+}
+
+// subtitleFlags are the tokens Jellyfin reads as subtitle flags
+var subtitleFlags = map[string]bool{
+	"default": true,
+	"forced":  true,
+	"sdh":     true,
+	"cc":      true,
+	"hi":      true,
+}
+
+// lookupLanguage returns the ISO 639-1 code for a file name token
+func lookupLanguage(token string) (string, bool) {
+	code, ok := languageCodes[strings.ToLower(token)]
+	return code, ok
+}
@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type LastRunSummaryInput struct {
+	IncludeCurrent bool `json:"include_current" jsonschema_description:"If true, operations made during the current session count as the latest run. By default the current session is skipped so the previous run is reported."`
+}
+
+var LastRunSummaryInputSchema = GenerateSchema[LastRunSummaryInput]()
+
+var LastRunSummaryDefinition = ToolDefinition{
+	Name:        "last_run_summary",
+	Description: "Report what was done during the most recent previous run, read from the persisted operation log. Returns every move and copy of that run with its source, target and time, so recent history can be reviewed.",
+	InputSchema: LastRunSummaryInputSchema,
+	Function:    LastRunSummary,
+}
+
+type LastRunSummaryOutput struct {
+	RunID      string         `json:"run_id,omitempty"`
+	Started    time.Time      `json:"started,omitempty"`
+	Finished   time.Time      `json:"finished,omitempty"`
+	Counts     map[string]int `json:"counts"`
+	Operations []LedgerEntry  `json:"operations"`
+	TotalRuns  int            `json:"total_runs"`
+}
+
+func LastRunSummary(input json.RawMessage) (string, error) {
+	summaryInput := LastRunSummaryInput{}
+	err := json.Unmarshal(input, &summaryInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	entries, err := readLedger(ledgerPath())
+	if err != nil {
+		return "", fmt.Errorf("failed to read the operation log: %v", err)
+	}
+
+	skip := ""
+	if !summaryInput.IncludeCurrent {
+		skip = ledgerRunID
+	}
+
+	jsonData, err := json.Marshal(summarizeLastRun(entries, skip))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// summarizeLastRun collects the operations of the last run in the log,
+// ignoring the run with id skip
+func summarizeLastRun(entries []LedgerEntry, skip string) LastRunSummaryOutput {
+	output := LastRunSummaryOutput{Counts: map[string]int{}, Operations: []LedgerEntry{}}
+
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		if entry.RunID == skip {
+			continue
+		}
+		if !seen[entry.RunID] {
+			seen[entry.RunID] = true
+			output.TotalRuns++
+		}
+		output.RunID = entry.RunID
+	}
+
+	for _, entry := range entries {
+		if entry.RunID != output.RunID || output.RunID == "" {
+			continue
+		}
+		if len(output.Operations) == 0 {
+			output.Started = entry.Time
+		}
+		output.Finished = entry.Time
+		output.Counts[entry.Operation]++
+		output.Operations = append(output.Operations, entry)
+	}
+
+	return output
+}
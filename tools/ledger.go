@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LedgerEntry is one completed filesystem operation in the operation log
+type LedgerEntry struct {
+	RunID     string    `json:"run_id"`
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	Source    string    `json:"source"`
+	Target    string    `json:"target"`
+}
+
+// ledgerRunID identifies the operations made by this process, so the log
+// can be split into runs. The nanoseconds and process ID keep two runs
+// started in the same second apart
+var ledgerRunID = fmt.Sprintf("%s-%d", time.Now().Format("20060102-150405.000000000"), os.Getpid())
+
+var ledgerMu sync.Mutex
+
+// ledgerPath returns the operation log location, OPERATIONS_LOG or
+// operations.log in the working directory
+func ledgerPath() string {
+	if path := os.Getenv("OPERATIONS_LOG"); path != "" {
+		return path
+	}
+	return "operations.log"
+}
+
+// recordOperation appends a completed operation to the operation log. The
+// operation already happened, so a failure to record it is only logged
+func recordOperation(operation, source, target string) {
+	entry := LedgerEntry{
+		RunID:     ledgerRunID,
+		Time:      time.Now(),
+		Operation: operation,
+		Source:    source,
+		Target:    target,
+	}
+
+	if err := appendLedgerEntry(ledgerPath(), entry); err != nil {
+		log.Printf("failed to record %s of %s in the operation log: %v", operation, source, err)
+	}
+}
+
+func appendLedgerEntry(path string, entry LedgerEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	ledgerMu.Lock()
+	defer ledgerMu.Unlock()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// readLedger returns every entry of the operation log in the order they were
+// recorded. A missing log has no entries
+func readLedger(path string) ([]LedgerEntry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []LedgerEntry
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry LedgerEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid entry on line %d of %s: %v", lineNumber, path, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
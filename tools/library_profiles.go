@@ -0,0 +1,247 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LibraryProfile is the quality a library expects its items to have
+type LibraryProfile struct {
+	MinResolution string   `json:"min_resolution,omitempty" jsonschema_description:"Lowest acceptable resolution, like 1080p or 2160p. Empty means any."`
+	RequireHDR    bool     `json:"require_hdr,omitempty" jsonschema_description:"If true, SDR files don't meet the profile."`
+	HDRFormats    []string `json:"hdr_formats,omitempty" jsonschema_description:"Acceptable HDR formats (HDR10, HDR10+, DV, HLG). Empty means any HDR format."`
+}
+
+type SetLibraryProfileInput struct {
+	LibraryPath string         `json:"library_path" jsonschema_description:"The library root the profile applies to, including everything below it."`
+	Profile     LibraryProfile `json:"profile" jsonschema_description:"The expectations for the library. An empty profile removes the library's entry."`
+}
+
+var SetLibraryProfileInputSchema = GenerateSchema[SetLibraryProfileInput]()
+
+var SetLibraryProfileDefinition = ToolDefinition{
+	Name:        "set_library_profile",
+	Description: "Set the default quality expectations of a library, like a minimum resolution of 2160p and HDR for a 4K library. Profiles are saved to the LIBRARY_PROFILES file and used by check_library_profile.",
+	InputSchema: SetLibraryProfileInputSchema,
+	Function:    SetLibraryProfile,
+}
+
+type CheckLibraryProfileInput struct {
+	Path       string `json:"path" jsonschema_description:"The video file to check."`
+	TargetPath string `json:"target_path" jsonschema_description:"Where the file is going. If empty, the file is checked against the library it is in."`
+	Probe      bool   `json:"probe" jsonschema_description:"If true, resolution and HDR are read with ffprobe instead of trusting the file name."`
+}
+
+var CheckLibraryProfileInputSchema = GenerateSchema[CheckLibraryProfileInput]()
+
+var CheckLibraryProfileDefinition = ToolDefinition{
+	Name:        "check_library_profile",
+	Description: "Check a video against the quality profile of its target library and warn when it doesn't meet it, e.g. a 1080p file going into the 4K library. Returns the profile that applies, the detected resolution and HDR format, and any warnings. Resolution and HDR are taken from the file name unless probe is set.",
+	InputSchema: CheckLibraryProfileInputSchema,
+	Function:    CheckLibraryProfile,
+}
+
+type CheckLibraryProfileOutput struct {
+	Library    string          `json:"library,omitempty"`
+	Profile    *LibraryProfile `json:"profile,omitempty"`
+	Resolution string          `json:"resolution,omitempty"`
+	HDRFormat  string          `json:"hdr_format,omitempty"`
+	Conforms   bool            `json:"conforms"`
+	Warnings   []string        `json:"warnings"`
+}
+
+var nameHDRPatterns = []struct {
+	format  string
+	pattern *regexp.Regexp
+}{
+	{"DV", regexp.MustCompile(`(?i)(?:^|[ ._\-\[(])(dv|dovi|dolby[ ._]?vision)(?:$|[ ._\-\])])`)},
+	{"HDR10+", regexp.MustCompile(`(?i)(?:^|[ ._\-\[(])hdr10(\+|plus)(?:$|[ ._\-\])])`)},
+	{"HLG", regexp.MustCompile(`(?i)(?:^|[ ._\-\[(])hlg(?:$|[ ._\-\])])`)},
+	{"HDR10", regexp.MustCompile(`(?i)(?:^|[ ._\-\[(])hdr(10)?(?:$|[ ._\-\])])`)},
+}
+
+// libraryProfilesPath returns the profile file, LIBRARY_PROFILES or
+// library_profiles.json in the working directory
+func libraryProfilesPath() string {
+	if path := os.Getenv("LIBRARY_PROFILES"); path != "" {
+		return path
+	}
+	return "library_profiles.json"
+}
+
+func loadLibraryProfiles() (map[string]LibraryProfile, error) {
+	profiles := map[string]LibraryProfile{}
+	content, err := os.ReadFile(libraryProfilesPath())
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &profiles); err != nil {
+		return nil, fmt.Errorf("invalid library profiles in %s: %v", libraryProfilesPath(), err)
+	}
+	return profiles, nil
+}
+
+// profileForPath returns the profile of the most specific library containing
+// path, so a 4K library nested inside the movies root wins over the root
+func profileForPath(profiles map[string]LibraryProfile, path string) (string, *LibraryProfile) {
+	var libraries []string
+	for library := range profiles {
+		if isWithinFolder(path, library) {
+			libraries = append(libraries, library)
+		}
+	}
+	if len(libraries) == 0 {
+		return "", nil
+	}
+
+	sort.Slice(libraries, func(i, j int) bool { return len(libraries[i]) > len(libraries[j]) })
+	profile := profiles[libraries[0]]
+	return libraries[0], &profile
+}
+
+func SetLibraryProfile(input json.RawMessage) (string, error) {
+	setInput := SetLibraryProfileInput{}
+	err := json.Unmarshal(input, &setInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(setInput.LibraryPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+	if setInput.Profile.MinResolution != "" && resolutionRank[setInput.Profile.MinResolution] == 0 {
+		return "", fmt.Errorf("unknown resolution %q", setInput.Profile.MinResolution)
+	}
+
+	profiles, err := loadLibraryProfiles()
+	if err != nil {
+		return "", err
+	}
+
+	library := filepath.Clean(setInput.LibraryPath)
+	profile := setInput.Profile
+	if profile.MinResolution == "" && !profile.RequireHDR && len(profile.HDRFormats) == 0 {
+		delete(profiles, library)
+	} else {
+		profiles[library] = profile
+	}
+
+	content, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if DryRun {
+		return fmt.Sprintf("%swould save the profile for %s", dryRunPrefix, library), nil
+	}
+	if err := os.WriteFile(libraryProfilesPath(), append(content, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to save library profiles: %v", err)
+	}
+
+	return fmt.Sprintf("Saved the profile for %s", library), nil
+}
+
+func CheckLibraryProfile(input json.RawMessage) (string, error) {
+	checkInput := CheckLibraryProfileInput{}
+	err := json.Unmarshal(input, &checkInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(checkInput.Path); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	targetPath := checkInput.TargetPath
+	if targetPath == "" {
+		targetPath = checkInput.Path
+	}
+
+	profiles, err := loadLibraryProfiles()
+	if err != nil {
+		return "", err
+	}
+
+	output := CheckLibraryProfileOutput{Warnings: []string{}}
+	output.Library, output.Profile = profileForPath(profiles, filepath.Clean(targetPath))
+
+	if checkInput.Probe {
+		if IsRemoteMedia() {
+			return "", fmt.Errorf("probing is only supported for local media")
+		}
+		probe, err := runFFprobe(context.Background(), checkInput.Path, hdrProbeArgs...)
+		if err != nil {
+			return "", fmt.Errorf("ffprobe failed: %v", err)
+		}
+		format, err := parseHDRFormat(probe)
+		if err != nil {
+			return "", err
+		}
+		output.Resolution, output.HDRFormat = format.Resolution, format.HDRFormat
+	} else {
+		name := filepath.Base(checkInput.Path)
+		output.Resolution = parseReleaseName(name).Resolution
+		output.HDRFormat = hdrFormatFromName(name)
+	}
+
+	if output.Profile != nil {
+		output.Warnings = profileWarnings(*output.Profile, output.Resolution, output.HDRFormat)
+	}
+	output.Conforms = len(output.Warnings) == 0
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// profileWarnings lists how a file falls short of a library profile
+func profileWarnings(profile LibraryProfile, resolution, hdrFormat string) []string {
+	warnings := []string{}
+
+	if profile.MinResolution != "" {
+		switch {
+		case resolution == "":
+			warnings = append(warnings, fmt.Sprintf("resolution is unknown, the library expects at least %s", profile.MinResolution))
+		case resolutionRank[resolution] < resolutionRank[profile.MinResolution]:
+			warnings = append(warnings, fmt.Sprintf("resolution %s is below the library minimum of %s", resolution, profile.MinResolution))
+		}
+	}
+
+	if profile.RequireHDR && (hdrFormat == "" || hdrFormat == "SDR") {
+		warnings = append(warnings, "the library expects HDR but the file is SDR")
+	}
+
+	if len(profile.HDRFormats) > 0 && hdrFormat != "" && hdrFormat != "SDR" {
+		accepted := false
+		for _, format := range profile.HDRFormats {
+			accepted = accepted || strings.EqualFold(format, hdrFormat)
+		}
+		if !accepted {
+			warnings = append(warnings, fmt.Sprintf("HDR format %s is not one of %v", hdrFormat, profile.HDRFormats))
+		}
+	}
+
+	return warnings
+}
+
+// hdrFormatFromName reads an HDR tag from a file name, or SDR when there is
+// none
+func hdrFormatFromName(name string) string {
+	for _, candidate := range nameHDRPatterns {
+		if candidate.pattern.MatchString(name) {
+			return candidate.format
+		}
+	}
+	return "SDR"
+}
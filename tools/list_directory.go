@@ -3,56 +3,138 @@ package tools
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+	"path/filepath"
+	"strings"
 )
 
 type ListDirectoryInput struct {
-	Path string `json:"path" jsonschema_description:"The directory path to list. Can be absolute or relative path."`
+	Path       string   `json:"path" jsonschema_description:"The directory path to list. Can be absolute or relative path."`
+	Type       string   `json:"type" jsonschema_description:"Optional folder a relative path is resolved against: 'shows', 'movies' or 'source' (the incoming downloads in SOURCE_FOLDER). An empty path with a type lists that folder itself."`
+	Recursive  bool     `json:"recursive" jsonschema_description:"If true, the whole folder tree is returned at once as an indented tree with file sizes, instead of only the direct entries."`
+	MaxDepth   int      `json:"max_depth" jsonschema_description:"With recursive, how many folder levels to descend. 0 means no limit."`
+	Pattern    string   `json:"pattern" jsonschema_description:"Optional glob files must match to be listed, e.g. '*.mkv' or '*S01E*'. Folders are always listed."`
+	Extensions []string `json:"extensions" jsonschema_description:"Optional file extensions to list, e.g. [\"mkv\", \"mp4\"]. Matching is case-insensitive and folders are always listed."`
 }
 
 var ListDirectoryInputSchema = GenerateSchema[ListDirectoryInput]()
 
 var ListDirectoryDefinition = ToolDefinition{
 	Name:        "list_directory",
-	Description: "List the contents of a directory.",
+	Description: "List the contents of a directory, or with recursive its whole tree in a single call. Set type to 'source' to look at the incoming files in SOURCE_FOLDER, or 'shows'/'movies' for the libraries.",
 	InputSchema: ListDirectoryInputSchema,
-	Function:    ListDirectory,
+	Structured:  func(input json.RawMessage) (any, error) { return ListDirectory(input) },
 }
 
-func ListDirectory(input json.RawMessage) (string, error) {
+type DirectoryEntry struct {
+	Name  string `json:"name"`
+	IsDir bool   `json:"is_dir"`
+	Size  int64  `json:"size,omitempty"`
+}
+
+type ListDirectoryResult struct {
+	Path    string           `json:"path"`
+	Entries []DirectoryEntry `json:"entries,omitempty"`
+	Tree    string           `json:"tree,omitempty"`
+}
+
+func ListDirectory(input json.RawMessage) (*ListDirectoryResult, error) {
 	listDirInput := ListDirectoryInput{}
 	err := json.Unmarshal(input, &listDirInput)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	dirPath := listDirInput.Path
+	dirPath, err := pathInFolder(listDirInput.Type, listDirInput.Path)
+	if err != nil {
+		return nil, err
+	}
 
 	// Validate that the path is within allowed directories
 	err = ValidatePath(dirPath)
 	if err != nil {
-		return "", fmt.Errorf("access denied: %v", err)
+		return nil, fmt.Errorf("access denied: %v", err)
+	}
+
+	include, err := fileFilter(listDirInput.Pattern, listDirInput.Extensions)
+	if err != nil {
+		return nil, err
 	}
 
-	entries, err := os.ReadDir(dirPath)
+	fsys, err := GetMediaFS()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	result := ""
+	if listDirInput.Recursive {
+		var tree strings.Builder
+		writeDirectoryTree(fsys, &tree, dirPath, 0, listDirInput.MaxDepth, include)
+		return &ListDirectoryResult{Path: dirPath, Tree: tree.String()}, nil
+	}
+
+	entries, err := fsys.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ListDirectoryResult{Path: dirPath, Entries: []DirectoryEntry{}}
 	for _, entry := range entries {
-		name := entry.Name()
-		if entry.IsDir() {
-			result += name + "/\n"
-		} else {
-			info, err := entry.Info()
-			if err != nil {
-				result += name + "\n"
-			} else {
-				result += fmt.Sprintf("%s (%d bytes)\n", name, info.Size())
-			}
+		if !entry.IsDir() && !include(entry.Name()) {
+			continue
+		}
+		dirEntry := DirectoryEntry{Name: entry.Name(), IsDir: entry.IsDir()}
+		if !entry.IsDir() {
+			dirEntry.Size = entry.Size()
 		}
+		result.Entries = append(result.Entries, dirEntry)
 	}
 
 	return result, nil
 }
+
+// writeDirectoryTree writes the entries below dir indented by depth, folders
+// with a trailing slash and included files with their size. Folders that
+// can't be read are skipped
+func writeDirectoryTree(fsys MediaFS, tree *strings.Builder, dir string, depth, maxDepth int, include func(name string) bool) {
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	indent := strings.Repeat("  ", depth)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			if !include(entry.Name()) {
+				continue
+			}
+			fmt.Fprintf(tree, "%s%s (%d bytes)\n", indent, entry.Name(), entry.Size())
+			continue
+		}
+
+		fmt.Fprintf(tree, "%s%s/\n", indent, entry.Name())
+		if maxDepth <= 0 || depth+1 < maxDepth {
+			writeDirectoryTree(fsys, tree, filepath.Join(dir, entry.Name()), depth+1, maxDepth, include)
+		}
+	}
+}
+
+// fileFilter returns whether a file name matches the glob pattern and has one
+// of the extensions. Empty filters match everything
+func fileFilter(pattern string, extensions []string) (func(name string) bool, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+
+	wanted := map[string]bool{}
+	for _, ext := range extensions {
+		wanted["."+strings.ToLower(strings.TrimPrefix(ext, "."))] = true
+	}
+
+	return func(name string) bool {
+		if pattern != "" {
+			if matched, _ := filepath.Match(pattern, name); !matched {
+				return false
+			}
+		}
+		return len(wanted) == 0 || wanted[strings.ToLower(filepath.Ext(name))]
+	}, nil
+}
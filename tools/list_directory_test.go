@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestListDirectoryResults(t *testing.T) {
+	library := setupTestLibrary(t)
+	writeTestFile(t, filepath.Join(library, "Heat (1995)", "Heat (1995).mkv"))
+	writeTestFile(t, filepath.Join(library, "notes.txt"))
+	writeTestFile(t, filepath.Join(library, "Alien (1979).mkv"))
+
+	tests := []struct {
+		name  string
+		input ListDirectoryInput
+		want  *ListDirectoryResult
+	}{
+		{
+			name:  "entries",
+			input: ListDirectoryInput{Path: library},
+			want: &ListDirectoryResult{Path: library, Entries: []DirectoryEntry{
+				{Name: "Alien (1979).mkv", Size: int64(len("Alien (1979).mkv"))},
+				{Name: "Heat (1995)", IsDir: true},
+				{Name: "notes.txt", Size: int64(len("notes.txt"))},
+			}},
+		},
+		{
+			name:  "filtered by extension",
+			input: ListDirectoryInput{Type: "movies", Extensions: []string{"MKV"}},
+			want: &ListDirectoryResult{Path: library, Entries: []DirectoryEntry{
+				{Name: "Alien (1979).mkv", Size: int64(len("Alien (1979).mkv"))},
+				{Name: "Heat (1995)", IsDir: true},
+			}},
+		},
+		{
+			name:  "recursive tree",
+			input: ListDirectoryInput{Path: library, Recursive: true, Pattern: "*.mkv"},
+			want: &ListDirectoryResult{Path: library, Tree: "Alien (1979).mkv (16 bytes)\n" +
+				"Heat (1995)/\n" +
+				"  Heat (1995).mkv (15 bytes)\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := json.Marshal(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Go callers get the typed result
+			got, err := ListDirectory(input)
+			if err != nil {
+				t.Fatalf("ListDirectory: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListDirectory = %+v, want %+v", got, tt.want)
+			}
+
+			// Claude gets the same result as JSON
+			text, err := ListDirectoryDefinition.Call(context.Background(), input)
+			if err != nil {
+				t.Fatalf("Call: %v", err)
+			}
+			var decoded ListDirectoryResult
+			if err := json.Unmarshal([]byte(text), &decoded); err != nil {
+				t.Fatalf("Call returned invalid JSON %q: %v", text, err)
+			}
+			if !reflect.DeepEqual(&decoded, tt.want) {
+				t.Errorf("Call = %s, want %+v", text, tt.want)
+			}
+		})
+	}
+}
+
+func TestListDirectoryOutsideLibrary(t *testing.T) {
+	setupTestLibrary(t)
+	if _, err := ListDirectoryDefinition.Call(context.Background(), json.RawMessage(`{"path":"/etc"}`)); err == nil {
+		t.Error("listing /etc succeeded, want access denied")
+	}
+}
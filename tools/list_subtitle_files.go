@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+type ListSubtitleFilesInput struct {
+	FolderPath string `json:"folder_path" jsonschema_description:"The movie or season folder containing the subtitle sidecars."`
+}
+
+var ListSubtitleFilesInputSchema = GenerateSchema[ListSubtitleFilesInput]()
+
+var ListSubtitleFilesDefinition = ToolDefinition{
+	Name:        "list_subtitle_files",
+	Description: "List the subtitle sidecars in a folder with the video each belongs to, the language detected from the file name as an ISO 639-1 code (Movie.en.srt, Movie.spa.srt and Movie.Spanish.srt are all 'es') and flags like forced or sdh. Subtitles whose language can't be read from the name are listed with an empty language. Use it with the audio languages from probe_media to set up multi-language titles.",
+	InputSchema: ListSubtitleFilesInputSchema,
+	Function:    ListSubtitleFiles,
+}
+
+type SubtitleFile struct {
+	Path     string   `json:"path"`
+	Video    string   `json:"video,omitempty"`
+	Language string   `json:"language,omitempty"`
+	Flags    []string `json:"flags,omitempty"`
+}
+
+type ListSubtitleFilesOutput struct {
+	Subtitles []SubtitleFile `json:"subtitles"`
+	// Languages lists each detected language once, in file name order
+	Languages []string `json:"languages"`
+}
+
+func ListSubtitleFiles(input json.RawMessage) (string, error) {
+	listInput := ListSubtitleFilesInput{}
+	err := json.Unmarshal(input, &listInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(listInput.FolderPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := fsys.ReadDir(listInput.FolderPath)
+	if err != nil {
+		return "", err
+	}
+
+	output := ListSubtitleFilesOutput{Subtitles: []SubtitleFile{}, Languages: []string{}}
+	videoBases := videoBaseNames(entries)
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		if entry.IsDir() || !isSubtitleFile(entry.Name()) {
+			continue
+		}
+
+		sidecar := parseSubtitleSidecar(filepath.Join(listInput.FolderPath, entry.Name()), videoBases)
+		subtitle := SubtitleFile{
+			Path:     sidecar.path,
+			Language: sidecar.language,
+			Flags:    sidecar.flags,
+		}
+		if videoFor(sidecar.base, videoBases) {
+			subtitle.Video = sidecar.base
+		}
+		output.Subtitles = append(output.Subtitles, subtitle)
+
+		if sidecar.language != "" && !seen[sidecar.language] {
+			seen[sidecar.language] = true
+			output.Languages = append(output.Languages, sidecar.language)
+		}
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// videoFor reports whether base is the name of one of the folder's videos,
+// as parseSubtitleSidecar falls back to the first name token without one
+func videoFor(base string, videoBases []string) bool {
+	for _, video := range videoBases {
+		if strings.EqualFold(base, video) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type LookupEpisodeByAirDateInput struct {
+	FileName   string `json:"file_name" jsonschema_description:"The air-date-named file, like Show.2021.03.15.mkv. Only the base name is used."`
+	SeriesID   string `json:"series_id" jsonschema_description:"The TMDb id of the series."`
+	SeriesName string `json:"series_name" jsonschema_description:"The series name to use in the proposed file name. If empty, it is taken from the part of the file name before the date."`
+}
+
+var LookupEpisodeByAirDateInputSchema = GenerateSchema[LookupEpisodeByAirDateInput]()
+
+var LookupEpisodeByAirDateDefinition = ToolDefinition{
+	Name:        "lookup_episode_by_air_date",
+	Description: "Given a file named by air date (like Show.2021.03.15.mkv) and the TMDb id of the series, find the episode that aired on that date and propose the canonical 'Show SxxEyy' file name. Requires TMDB_API_KEY.",
+	InputSchema: LookupEpisodeByAirDateInputSchema,
+	Function:    LookupEpisodeByAirDate,
+}
+
+// AiredEpisode is an episode matched by its air date
+type AiredEpisode struct {
+	Season  int    `json:"season"`
+	Episode int    `json:"episode"`
+	Title   string `json:"title"`
+	AirDate string `json:"air_date"`
+}
+
+// episodeAirDateProvider finds the episode of a series that aired on a date
+type episodeAirDateProvider interface {
+	EpisodeByAirDate(seriesID string, airDate time.Time) (AiredEpisode, error)
+}
+
+var airDateProvider episodeAirDateProvider = tmdbAirDateProvider{}
+
+var airDatePattern = regexp.MustCompile(`((?:19|20)\d{2})[.\-_ ](\d{2})[.\-_ ](\d{2})`)
+
+type LookupEpisodeByAirDateOutput struct {
+	AiredEpisode
+	ProposedName string `json:"proposed_name"`
+}
+
+func LookupEpisodeByAirDate(input json.RawMessage) (string, error) {
+	lookupInput := LookupEpisodeByAirDateInput{}
+	err := json.Unmarshal(input, &lookupInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	fileName := filepath.Base(lookupInput.FileName)
+	airDate, prefix, err := parseAirDate(fileName)
+	if err != nil {
+		return "", err
+	}
+
+	seriesName := lookupInput.SeriesName
+	if seriesName == "" {
+		seriesName = strings.Join(strings.FieldsFunc(prefix, isNameSeparator), " ")
+	}
+	if seriesName == "" {
+		return "", fmt.Errorf("could not determine the series name from %s, pass series_name", fileName)
+	}
+
+	episode, err := airDateProvider.EpisodeByAirDate(lookupInput.SeriesID, airDate)
+	if err != nil {
+		return "", err
+	}
+
+	output := LookupEpisodeByAirDateOutput{
+		AiredEpisode: episode,
+		ProposedName: fmt.Sprintf("%s S%02dE%02d%s", seriesName, episode.Season, episode.Episode, filepath.Ext(fileName)),
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// parseAirDate extracts the air date from a file name and returns the part
+// of the name before it
+func parseAirDate(fileName string) (time.Time, string, error) {
+	loc := airDatePattern.FindStringSubmatchIndex(fileName)
+	if loc == nil {
+		return time.Time{}, "", fmt.Errorf("no air date found in file name: %s", fileName)
+	}
+
+	date := fileName[loc[2]:loc[3]] + "-" + fileName[loc[4]:loc[5]] + "-" + fileName[loc[6]:loc[7]]
+	airDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid air date in file name %s: %v", fileName, err)
+	}
+
+	return airDate, fileName[:loc[0]], nil
+}
+
+func isNameSeparator(r rune) bool {
+	return r == '.' || r == '_' || r == ' ' || r == '-'
+}
+
+type tmdbAirDateProvider struct{}
+
+func (tmdbAirDateProvider) EpisodeByAirDate(seriesID string, airDate time.Time) (AiredEpisode, error) {
+	var series struct {
+		Seasons []struct {
+			SeasonNumber int    `json:"season_number"`
+			AirDate      string `json:"air_date"`
+		} `json:"seasons"`
+	}
+	if err := tmdbGet("/tv/"+url.PathEscape(seriesID), nil, &series); err != nil {
+		return AiredEpisode{}, err
+	}
+
+	// Check the latest season that started on or before the date first,
+	// then earlier ones, leaving specials for last
+	seasons := series.Seasons
+	sort.Slice(seasons, func(i, j int) bool {
+		if (seasons[i].SeasonNumber == 0) != (seasons[j].SeasonNumber == 0) {
+			return seasons[j].SeasonNumber == 0
+		}
+		return seasons[i].AirDate > seasons[j].AirDate
+	})
+
+	want := airDate.Format("2006-01-02")
+	for _, season := range seasons {
+		if season.AirDate != "" && season.AirDate > want {
+			continue
+		}
+
+		var details struct {
+			Episodes []struct {
+				SeasonNumber  int    `json:"season_number"`
+				EpisodeNumber int    `json:"episode_number"`
+				Name          string `json:"name"`
+				AirDate       string `json:"air_date"`
+			} `json:"episodes"`
+		}
+		path := fmt.Sprintf("/tv/%s/season/%d", url.PathEscape(seriesID), season.SeasonNumber)
+		if err := tmdbGet(path, nil, &details); err != nil {
+			return AiredEpisode{}, err
+		}
+
+		for _, episode := range details.Episodes {
+			if episode.AirDate == want {
+				return AiredEpisode{
+					Season:  episode.SeasonNumber,
+					Episode: episode.EpisodeNumber,
+					Title:   episode.Name,
+					AirDate: episode.AirDate,
+				}, nil
+			}
+		}
+	}
+
+	return AiredEpisode{}, fmt.Errorf("no episode of series %s aired on %s", seriesID, want)
+}
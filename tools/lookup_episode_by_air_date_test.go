@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeTMDbSeries serves the TMDb series and season endpoints of one series
+// with two seasons and a special
+func fakeTMDbSeries(t *testing.T) {
+	t.Helper()
+	responses := map[string]string{
+		"/tv/1399": `{"seasons":[
+			{"season_number":0,"air_date":"2010-12-05"},
+			{"season_number":1,"air_date":"2021-01-10"},
+			{"season_number":2,"air_date":"2022-01-09"}]}`,
+		"/tv/1399/season/0": `{"episodes":[
+			{"season_number":0,"episode_number":1,"name":"Behind the Scenes","air_date":"2021-03-20"}]}`,
+		"/tv/1399/season/1": `{"episodes":[
+			{"season_number":1,"episode_number":1,"name":"Pilot","air_date":"2021-01-10"},
+			{"season_number":1,"episode_number":10,"name":"Finale","air_date":"2021-03-15"}]}`,
+		"/tv/1399/season/2": `{"episodes":[
+			{"season_number":2,"episode_number":1,"name":"Return","air_date":"2022-01-09"}]}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != "test-key" {
+			http.Error(w, `{"status_message":"Invalid API key"}`, http.StatusUnauthorized)
+			return
+		}
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			http.Error(w, `{"status_message":"not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	baseURL := tmdbBaseURL
+	tmdbBaseURL = server.URL
+	t.Cleanup(func() { tmdbBaseURL = baseURL })
+	t.Setenv("TMDB_API_KEY", "test-key")
+}
+
+func TestLookupEpisodeByAirDate(t *testing.T) {
+	fakeTMDbSeries(t)
+
+	tests := []struct {
+		name    string
+		input   LookupEpisodeByAirDateInput
+		want    LookupEpisodeByAirDateOutput
+		wantErr string
+	}{
+		{
+			name:  "episode of the first season",
+			input: LookupEpisodeByAirDateInput{FileName: "/downloads/The.Show.2021.03.15.mkv", SeriesID: "1399"},
+			want: LookupEpisodeByAirDateOutput{
+				AiredEpisode: AiredEpisode{Season: 1, Episode: 10, Title: "Finale", AirDate: "2021-03-15"},
+				ProposedName: "The Show S01E10.mkv",
+			},
+		},
+		{
+			name:  "episode of the latest season",
+			input: LookupEpisodeByAirDateInput{FileName: "the_show_2022-01-09.mp4", SeriesID: "1399", SeriesName: "The Show (2021)"},
+			want: LookupEpisodeByAirDateOutput{
+				AiredEpisode: AiredEpisode{Season: 2, Episode: 1, Title: "Return", AirDate: "2022-01-09"},
+				ProposedName: "The Show (2021) S02E01.mp4",
+			},
+		},
+		{
+			name:  "special",
+			input: LookupEpisodeByAirDateInput{FileName: "The.Show.2021.03.20.mkv", SeriesID: "1399"},
+			want: LookupEpisodeByAirDateOutput{
+				AiredEpisode: AiredEpisode{Season: 0, Episode: 1, Title: "Behind the Scenes", AirDate: "2021-03-20"},
+				ProposedName: "The Show S00E01.mkv",
+			},
+		},
+		{
+			name:    "nothing aired that day",
+			input:   LookupEpisodeByAirDateInput{FileName: "The.Show.2021.03.16.mkv", SeriesID: "1399"},
+			wantErr: "no episode of series 1399 aired on 2021-03-16",
+		},
+		{
+			name:    "unknown series",
+			input:   LookupEpisodeByAirDateInput{FileName: "The.Show.2021.03.15.mkv", SeriesID: "42"},
+			wantErr: "404",
+		},
+		{
+			name:    "no date in the name",
+			input:   LookupEpisodeByAirDateInput{FileName: "The.Show.S01E10.mkv", SeriesID: "1399"},
+			wantErr: "no air date found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := json.Marshal(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := LookupEpisodeByAirDate(input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LookupEpisodeByAirDate: %v", err)
+			}
+
+			var got LookupEpisodeByAirDateOutput
+			if err := json.Unmarshal([]byte(result), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type LookupIMDbIDInput struct {
+	IMDbID string `json:"imdb_id" jsonschema_description:"The IMDb id to look up, e.g. 'tt0113277', as returned by search_imdb."`
+}
+
+var LookupIMDbIDInputSchema = GenerateSchema[LookupIMDbIDInput]()
+
+var LookupIMDbIDDefinition = ToolDefinition{
+	Name:        "lookup_imdb_id",
+	Description: "Look up the details of an IMDb id through the OMDb API: title, year, type, genre, plot, runtime, rating and poster URL. Use it after search_imdb to confirm the identification and to get authoritative values for folder names and NFO files. Requires OMDB_API_KEY.",
+	InputSchema: LookupIMDbIDInputSchema,
+	Function:    LookupIMDbID,
+}
+
+type OMDbDetails struct {
+	IMDbID    string  `json:"imdb_id"`
+	Title     string  `json:"title"`
+	Year      int     `json:"year,omitempty"`
+	Type      string  `json:"type,omitempty"`
+	Genre     string  `json:"genre,omitempty"`
+	Plot      string  `json:"plot,omitempty"`
+	Runtime   string  `json:"runtime,omitempty"`
+	Rating    float64 `json:"rating,omitempty"`
+	PosterURL string  `json:"poster_url,omitempty"`
+}
+
+// omdbResponse is the OMDb title response. OMDb reports errors in the body
+// with Response "False" and answers missing values with "N/A"
+type omdbResponse struct {
+	Response   string `json:"Response"`
+	Error      string `json:"Error"`
+	Title      string `json:"Title"`
+	Year       string `json:"Year"`
+	Type       string `json:"Type"`
+	Genre      string `json:"Genre"`
+	Plot       string `json:"Plot"`
+	Runtime    string `json:"Runtime"`
+	IMDbRating string `json:"imdbRating"`
+	Poster     string `json:"Poster"`
+}
+
+const omdbBaseURL = "https://www.omdbapi.com/"
+
+var omdbHTTPClient = &http.Client{Timeout: 15 * time.Second, Transport: networkTransport}
+
+func LookupIMDbID(input json.RawMessage) (string, error) {
+	lookupInput := LookupIMDbIDInput{}
+	err := json.Unmarshal(input, &lookupInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	id := strings.TrimSpace(lookupInput.IMDbID)
+	if !imdbIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid IMDb id %q, expected something like tt0113277", id)
+	}
+
+	apiKey := os.Getenv("OMDB_API_KEY")
+	if apiKey == "" {
+		return "", fmt.Errorf("OMDB_API_KEY environment variable must be set to use OMDb")
+	}
+
+	query := url.Values{"i": {id}, "plot": {"short"}, "apikey": {apiKey}}
+	resp, err := omdbHTTPClient.Get(omdbBaseURL + "?" + query.Encode())
+	if err != nil {
+		return "", fmt.Errorf("failed to query OMDb: %w", withoutURL(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("OMDb returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var omdb omdbResponse
+	if err := json.NewDecoder(resp.Body).Decode(&omdb); err != nil {
+		return "", fmt.Errorf("failed to decode OMDb response: %w", err)
+	}
+	if omdb.Response != "True" {
+		return "", fmt.Errorf("OMDb found nothing for %s: %s", id, omdb.Error)
+	}
+
+	details := OMDbDetails{
+		IMDbID:    id,
+		Title:     omdb.Title,
+		Type:      omdbValue(omdb.Type),
+		Genre:     omdbValue(omdb.Genre),
+		Plot:      omdbValue(omdb.Plot),
+		Runtime:   omdbValue(omdb.Runtime),
+		PosterURL: omdbValue(omdb.Poster),
+	}
+	// Series years look like "2008–2013", the first one is the year
+	if len(omdb.Year) >= 4 {
+		details.Year, _ = strconv.Atoi(omdb.Year[:4])
+	}
+	details.Rating, _ = strconv.ParseFloat(omdb.IMDbRating, 64)
+
+	jsonData, err := json.Marshal(details)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// omdbValue drops the "N/A" OMDb uses for missing values
+func omdbValue(value string) string {
+	if value == "N/A" {
+		return ""
+	}
+	return value
+}
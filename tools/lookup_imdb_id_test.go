@@ -0,0 +1,30 @@
+package tools
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// failingTransport fails every request, like an unreachable server
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestLookupIMDbIDHidesAPIKey(t *testing.T) {
+	t.Setenv("OMDB_API_KEY", "secret-omdb-key")
+	transport := omdbHTTPClient.Transport
+	omdbHTTPClient.Transport = failingTransport{}
+	t.Cleanup(func() { omdbHTTPClient.Transport = transport })
+
+	_, err := LookupIMDbID([]byte(`{"imdb_id":"tt0113277"}`))
+	if err == nil {
+		t.Fatal("expected the request to fail")
+	}
+	if strings.Contains(err.Error(), "secret-omdb-key") {
+		t.Errorf("error shows the API key: %v", err)
+	}
+}
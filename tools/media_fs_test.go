@@ -0,0 +1,252 @@
+package tools
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// rootedFS serves every path from below root, standing in for a remote
+// library that doesn't exist on the local disk
+type rootedFS struct {
+	root string
+}
+
+func (r rootedFS) path(path string) string { return filepath.Join(r.root, path) }
+
+func (r rootedFS) ReadDir(path string) ([]os.FileInfo, error) { return localFS{}.ReadDir(r.path(path)) }
+func (r rootedFS) Stat(path string) (os.FileInfo, error)      { return os.Stat(r.path(path)) }
+func (r rootedFS) Open(path string) (MediaFile, error)        { return os.Open(r.path(path)) }
+func (r rootedFS) Create(path string) (MediaFile, error)      { return os.Create(r.path(path)) }
+func (r rootedFS) Remove(path string) error                   { return os.Remove(r.path(path)) }
+
+func (r rootedFS) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(r.path(path), perm)
+}
+
+func (r rootedFS) Rename(oldpath, newpath string) error {
+	return os.Rename(r.path(oldpath), r.path(newpath))
+}
+
+// useMediaFS makes GetMediaFS return fsys for the rest of the test
+func useMediaFS(t *testing.T, fsys MediaFS) {
+	t.Helper()
+	previous, err := GetMediaFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaFS = fsys
+	t.Cleanup(func() { mediaFS = previous })
+}
+
+func TestWalkMediaMatchesWalkDir(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{"a/one.mkv", "a/two.srt", "b/c/three.mkv", "d.nfo"} {
+		writeTestFile(t, filepath.Join(root, path))
+	}
+
+	tests := []struct {
+		name string
+		root string
+		skip func(path string, isDir bool) error
+	}{
+		{name: "whole tree", root: root},
+		{name: "skip a folder", root: root, skip: func(path string, isDir bool) error {
+			if isDir && filepath.Base(path) == "b" {
+				return filepath.SkipDir
+			}
+			return nil
+		}},
+		{name: "skip the rest of a folder", root: root, skip: func(path string, isDir bool) error {
+			if filepath.Base(path) == "one.mkv" {
+				return filepath.SkipDir
+			}
+			return nil
+		}},
+		{name: "stop early", root: root, skip: func(path string, isDir bool) error {
+			if strings.HasSuffix(path, ".srt") {
+				return filepath.SkipAll
+			}
+			return nil
+		}},
+		{name: "missing root", root: filepath.Join(root, "missing")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visit := func(visited *[]string, path string, isDir bool, err error) error {
+				if err != nil {
+					*visited = append(*visited, "error "+path)
+					return nil
+				}
+				*visited = append(*visited, path)
+				if tt.skip != nil {
+					return tt.skip(path, isDir)
+				}
+				return nil
+			}
+
+			var want, got []string
+			wantErr := filepath.WalkDir(tt.root, func(path string, d fs.DirEntry, err error) error {
+				return visit(&want, path, d != nil && d.IsDir(), err)
+			})
+			gotErr := walkMedia(localFS{}, tt.root, func(path string, info os.FileInfo, err error) error {
+				return visit(&got, path, info != nil && info.IsDir(), err)
+			})
+
+			if gotErr != wantErr {
+				t.Errorf("error = %v, want %v", gotErr, wantErr)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("visited %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFolderLooseMoviesUsesMediaFS(t *testing.T) {
+	library := setupTestLibrary(t)
+	remote := t.TempDir()
+	useMediaFS(t, rootedFS{root: remote})
+
+	// The movie only exists on the media filesystem, not on the local disk
+	writeTestFile(t, filepath.Join(remote, library, "Movie.2009.1080p.mkv"))
+
+	input, err := json.Marshal(FolderLooseMoviesInput{Apply: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FolderLooseMovies(input); err != nil {
+		t.Fatalf("FolderLooseMovies: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(remote, library, "Movie (2009)", "Movie (2009).mkv")); err != nil {
+		t.Errorf("movie was not foldered on the media filesystem: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(library, "Movie (2009)")); !os.IsNotExist(err) {
+		t.Errorf("movie folder was created on the local disk")
+	}
+}
+
+// newTestSFTPFS serves an in-memory SFTP server over a pipe and returns an
+// sftpFS connected to it
+func newTestSFTPFS(t *testing.T) *sftpFS {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+
+	server := sftp.NewRequestServer(serverConn, sftp.InMemHandler())
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return &sftpFS{client: client}
+}
+
+func TestSFTPFS(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		renames [][2]string
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "list sorts entries by name",
+			files: []string{"/media/movies/Zodiac (2007).mkv", "/media/movies/Alien (1979).mkv", "/media/movies/Heat (1995)/Heat (1995).mkv"},
+			list:  "/media/movies",
+			want:  []string{"Alien (1979).mkv", "Heat (1995)", "Zodiac (2007).mkv"},
+		},
+		{
+			name:    "rename a file into a folder",
+			files:   []string{"/media/downloads/Heat.1995.mkv", "/media/movies/Heat (1995)/poster.jpg"},
+			renames: [][2]string{{"/media/downloads/Heat.1995.mkv", "/media/movies/Heat (1995)/Heat (1995).mkv"}},
+			list:    "/media/movies/Heat (1995)",
+			want:    []string{"Heat (1995).mkv", "poster.jpg"},
+		},
+		{
+			name:    "rename a folder",
+			files:   []string{"/media/movies/heat (1995)/heat.mkv"},
+			renames: [][2]string{{"/media/movies/heat (1995)", "/media/movies/Heat (1995)"}},
+			list:    "/media/movies",
+			want:    []string{"Heat (1995)"},
+		},
+		{
+			name:    "rename a missing file",
+			files:   []string{"/media/movies/Heat (1995).mkv"},
+			renames: [][2]string{{"/media/movies/Missing.mkv", "/media/movies/Found.mkv"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := newTestSFTPFS(t)
+			for _, file := range tt.files {
+				if err := fsys.MkdirAll(filepath.Dir(file), 0755); err != nil {
+					t.Fatalf("MkdirAll: %v", err)
+				}
+				if err := writeMediaFile(fsys, file, []byte(filepath.Base(file))); err != nil {
+					t.Fatalf("writeMediaFile: %v", err)
+				}
+			}
+
+			for _, rename := range tt.renames {
+				err := fsys.Rename(rename[0], rename[1])
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Rename error = %v, want error %v", err, tt.wantErr)
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+
+			infos, err := fsys.ReadDir(tt.list)
+			if err != nil {
+				t.Fatalf("ReadDir: %v", err)
+			}
+			var got []string
+			for _, info := range infos {
+				got = append(got, info.Name())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadDir(%s) = %v, want %v", tt.list, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSFTPFSRenameKeepsData(t *testing.T) {
+	fsys := newTestSFTPFS(t)
+	if err := fsys.MkdirAll("/media/movies", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeMediaFile(fsys, "/media/movies/heat.mkv", []byte("movie data")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fsys.Rename("/media/movies/heat.mkv", "/media/movies/Heat (1995).mkv"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+
+	data, err := readMediaFile(fsys, "/media/movies/Heat (1995).mkv")
+	if err != nil || string(data) != "movie data" {
+		t.Errorf("renamed file holds %q (%v), want the original data", data, err)
+	}
+	if _, err := fsys.Stat("/media/movies/heat.mkv"); !os.IsNotExist(err) {
+		t.Errorf("old name still exists: %v", err)
+	}
+}
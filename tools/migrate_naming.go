@@ -0,0 +1,290 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type MigrateNamingInput struct {
+	LibraryPath string            `json:"library_path" jsonschema_description:"The library root to migrate."`
+	LibraryType string            `json:"library_type" jsonschema_description:"'movies' or 'shows'."`
+	Profile     ConventionProfile `json:"profile" jsonschema_description:"The target naming convention, in the format returned by infer_naming_conventions."`
+	Apply       bool              `json:"apply" jsonschema_description:"If true, all renames are executed and rolled back together if any fails. If false, they are only previewed."`
+}
+
+var MigrateNamingInputSchema = GenerateSchema[MigrateNamingInput]()
+
+var MigrateNamingDefinition = ToolDefinition{
+	Name:           "migrate_naming",
+	Description:    "Convert a whole library from one naming scheme to another, e.g. 'Title.2009' to 'Title (2009)'. Parses every item, computes its name under the target convention profile (same format as infer_naming_conventions returns) and previews the full set of renames: movie folders and files with their sidecars, or show folders, season folders and episodes. Set apply to execute them as one transaction that is rolled back if any rename fails. Nothing is applied while there are conflicts.",
+	InputSchema:    MigrateNamingInputSchema,
+	Function:       MigrateNaming,
+	WritesOnlyWith: "apply",
+}
+
+type NamingRename struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+type MigrateNamingOutput struct {
+	Renames   []NamingRename `json:"renames"`
+	Skipped   []string       `json:"skipped"`
+	Conflicts []string       `json:"conflicts"`
+	Applied   bool           `json:"applied"`
+}
+
+// namingPlan collects renames in execution order. Files are renamed before
+// the folders containing them, so every source path is valid at the time its
+// rename runs
+type namingPlan struct {
+	fsys    MediaFS
+	output  MigrateNamingOutput
+	targets map[string]bool
+}
+
+func MigrateNaming(input json.RawMessage) (string, error) {
+	migrateInput := MigrateNamingInput{}
+	err := json.Unmarshal(input, &migrateInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(migrateInput.LibraryPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	plan := &namingPlan{
+		fsys:    fsys,
+		output:  MigrateNamingOutput{Renames: []NamingRename{}, Skipped: []string{}, Conflicts: []string{}},
+		targets: map[string]bool{},
+	}
+
+	switch migrateInput.LibraryType {
+	case "movies":
+		err = plan.planMovies(migrateInput.LibraryPath, migrateInput.Profile)
+	case "shows":
+		err = plan.planShows(migrateInput.LibraryPath, migrateInput.Profile)
+	default:
+		return "", fmt.Errorf("library_type must be 'movies' or 'shows', got %q", migrateInput.LibraryType)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	output := plan.output
+	if migrateInput.Apply && len(output.Renames) > 0 && len(output.Conflicts) > 0 {
+		return "", fmt.Errorf("not applying renames, %d conflicts need to be resolved first: %v", len(output.Conflicts), output.Conflicts)
+	}
+
+	if migrateInput.Apply && len(output.Renames) > 0 && !DryRun {
+		var tx transaction
+		for _, rename := range output.Renames {
+			source, target := rename.Source, rename.Target
+			if err := moveMedia(source, target); err != nil {
+				return "", rollbackError(fmt.Errorf("failed to rename %s: %v", source, err), tx.rollback())
+			}
+			tx.add("rename of "+source, undoMove(source, target))
+		}
+		output.Applied = true
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if migrateInput.Apply && DryRun {
+		return dryRunOutput(jsonData), nil
+	}
+
+	return string(jsonData), nil
+}
+
+func (p *namingPlan) planMovies(libraryPath string, profile ConventionProfile) error {
+	entries, err := p.fsys.ReadDir(libraryPath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || (!entry.IsDir() && !isVideoFile(name)) {
+			continue
+		}
+
+		info := parseReleaseName(name)
+		if info.Title == "" {
+			p.output.Skipped = append(p.output.Skipped, filepath.Join(libraryPath, name))
+			continue
+		}
+
+		if !entry.IsDir() {
+			newBase := profile.MovieName(info.Title, info.Year, info.Resolution)
+			if err := p.renameWithSidecars(libraryPath, name, newBase); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// The folder is named without quality, the video inside with it
+		folderPath := filepath.Join(libraryPath, name)
+		children, err := p.fsys.ReadDir(folderPath)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if child.IsDir() || !isVideoFile(child.Name()) {
+				continue
+			}
+			resolution := parseReleaseName(child.Name()).Resolution
+			if err := p.renameWithSidecars(folderPath, child.Name(), profile.MovieName(info.Title, info.Year, resolution)); err != nil {
+				return err
+			}
+		}
+
+		p.add(folderPath, filepath.Join(libraryPath, profile.MovieName(info.Title, info.Year, "")))
+	}
+
+	return nil
+}
+
+func (p *namingPlan) planShows(libraryPath string, profile ConventionProfile) error {
+	entries, err := p.fsys.ReadDir(libraryPath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		showPath := filepath.Join(libraryPath, entry.Name())
+		show := parseReleaseName(entry.Name())
+		if show.Title == "" {
+			p.output.Skipped = append(p.output.Skipped, showPath)
+			continue
+		}
+
+		seasons, err := p.fsys.ReadDir(showPath)
+		if err != nil {
+			return err
+		}
+		for _, season := range seasons {
+			if !season.IsDir() || seasonFolderFormat(season.Name()) == "" {
+				continue
+			}
+
+			seasonPath := filepath.Join(showPath, season.Name())
+			episodes, err := p.fsys.ReadDir(seasonPath)
+			if err != nil {
+				return err
+			}
+
+			seasonNumber := -1
+			for _, episode := range episodes {
+				if episode.IsDir() || !isVideoFile(episode.Name()) {
+					continue
+				}
+				info := parseReleaseName(episode.Name())
+				if !info.IsEpisode {
+					p.output.Skipped = append(p.output.Skipped, filepath.Join(seasonPath, episode.Name()))
+					continue
+				}
+				seasonNumber = info.Season
+
+				newBase := profile.EpisodeName(show.Title, info.Season, info.Episode, info.Resolution) + episodeTitleSuffix(episode.Name())
+				if err := p.renameWithSidecars(seasonPath, episode.Name(), newBase); err != nil {
+					return err
+				}
+			}
+
+			// The folder's own number wins over the episodes'
+			match := seasonFolderPattern.FindStringSubmatch(season.Name())
+			if match == nil {
+				match = shortSeasonPattern.FindStringSubmatch(season.Name())
+			}
+			if match != nil {
+				seasonNumber, _ = strconv.Atoi(match[1])
+			}
+			if seasonNumber >= 0 {
+				p.add(seasonPath, filepath.Join(showPath, profile.SeasonFolder(seasonNumber)))
+			}
+		}
+
+		p.add(showPath, filepath.Join(libraryPath, profile.MovieName(show.Title, show.Year, "")))
+	}
+
+	return nil
+}
+
+// renameWithSidecars plans the rename of a video to newBase plus its
+// extension, along with files sharing its base name like subtitles
+func (p *namingPlan) renameWithSidecars(dir, videoName, newBase string) error {
+	entries, err := p.fsys.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	base := strings.TrimSuffix(videoName, filepath.Ext(videoName))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (name != videoName && !strings.HasPrefix(name, base+".")) {
+			continue
+		}
+		if name != videoName && isVideoFile(name) {
+			continue
+		}
+		p.add(filepath.Join(dir, name), filepath.Join(dir, newBase+strings.TrimPrefix(name, base)))
+	}
+	return nil
+}
+
+func (p *namingPlan) add(source, target string) {
+	if source == target {
+		return
+	}
+
+	// Case-only renames point at the same file on case-insensitive
+	// filesystems, so they are not conflicts
+	_, statErr := p.fsys.Stat(target)
+	switch {
+	case p.targets[target]:
+		p.output.Conflicts = append(p.output.Conflicts, fmt.Sprintf("%s and another item would both be renamed to %s", source, target))
+	case statErr == nil && !strings.EqualFold(source, target):
+		p.output.Conflicts = append(p.output.Conflicts, fmt.Sprintf("%s cannot be renamed, %s already exists", source, target))
+	}
+
+	p.targets[target] = true
+	p.output.Renames = append(p.output.Renames, NamingRename{Source: source, Target: target})
+}
+
+// episodeTitleSuffix keeps an episode title written after the episode marker
+// as " - Title", without release junk, and drops anything else
+func episodeTitleSuffix(name string) string {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	loc := releaseEpisodePattern.FindStringIndex(name)
+	if loc == nil {
+		loc = releaseCrossPattern.FindStringIndex(name)
+	}
+	if loc == nil {
+		return ""
+	}
+
+	rest := name[loc[1]:]
+	if !strings.HasPrefix(rest, " - ") {
+		return ""
+	}
+	if title := cleanEpisodeTitle(rest); title != "" {
+		return " - " + title
+	}
+	return ""
+}
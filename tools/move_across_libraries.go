@@ -0,0 +1,258 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type MoveAcrossLibrariesInput struct {
+	SourcePath    string `json:"source_path" jsonschema_description:"The misfiled movie or show, as a file or a folder, in the library it is currently in."`
+	TargetLibrary string `json:"target_library" jsonschema_description:"The library the item belongs in: 'shows' or 'movies'."`
+	Title         string `json:"title" jsonschema_description:"The title for the item in its new library. If empty, it is parsed from the source name."`
+	Year          int    `json:"year" jsonschema_description:"The release year. If 0, it is parsed from the source name."`
+	Apply         bool   `json:"apply" jsonschema_description:"If true, the moves are executed and rolled back if any of them fails. If false, they are only reported."`
+}
+
+var MoveAcrossLibrariesInputSchema = GenerateSchema[MoveAcrossLibrariesInput]()
+
+var MoveAcrossLibrariesDefinition = ToolDefinition{
+	Name:           "move_across_libraries",
+	Description:    "Move an item misfiled in the wrong library into the right one, laying it out the way the destination library expects. Into shows: episodes go to 'Title (Year)/Season XX/' based on the SxxEyy in their names, and every video must carry one. Into movies: the single video and its sidecars are renamed to 'Title (Year)/Title (Year).ext'. Set apply to execute the moves, which are rolled back if any fails.",
+	InputSchema:    MoveAcrossLibrariesInputSchema,
+	Function:       MoveAcrossLibraries,
+	WritesOnlyWith: "apply",
+}
+
+type CrossLibraryMove struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+type MoveAcrossLibrariesOutput struct {
+	Destination string             `json:"destination"`
+	Moves       []CrossLibraryMove `json:"moves"`
+	Applied     bool               `json:"applied"`
+}
+
+func MoveAcrossLibraries(input json.RawMessage) (string, error) {
+	moveInput := MoveAcrossLibrariesInput{}
+	err := json.Unmarshal(input, &moveInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(moveInput.SourcePath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	var targetRoot, otherRoot string
+	switch moveInput.TargetLibrary {
+	case "shows":
+		targetRoot, otherRoot = os.Getenv("JELLYFIN_SHOWS_FOLDER"), os.Getenv("JELLYFIN_MOVIES_FOLDER")
+	case "movies":
+		targetRoot, otherRoot = os.Getenv("JELLYFIN_MOVIES_FOLDER"), os.Getenv("JELLYFIN_SHOWS_FOLDER")
+	default:
+		return "", fmt.Errorf("target_library must be 'shows' or 'movies', got %q", moveInput.TargetLibrary)
+	}
+	if targetRoot == "" {
+		return "", fmt.Errorf("the %s library folder is not configured", moveInput.TargetLibrary)
+	}
+
+	sourcePath := filepath.Clean(moveInput.SourcePath)
+	if isWithinFolder(sourcePath, targetRoot) {
+		return "", fmt.Errorf("%s is already in the %s library", sourcePath, moveInput.TargetLibrary)
+	}
+	if otherRoot != "" && filepath.Clean(otherRoot) == sourcePath {
+		return "", fmt.Errorf("source must be an item, not the library root")
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	sourceInfo, err := fsys.Stat(sourcePath)
+	if err != nil {
+		return "", fmt.Errorf("source path does not exist: %s", sourcePath)
+	}
+
+	info := parseReleaseName(filepath.Base(sourcePath))
+	title, year := moveInput.Title, moveInput.Year
+	if title == "" {
+		title = info.Title
+	}
+	if year == 0 {
+		year = info.Year
+	}
+	if title == "" {
+		return "", fmt.Errorf("could not parse a title from %s, please provide one", sourcePath)
+	}
+
+	// A single file brings along its same-named sidecars, a folder brings
+	// everything in it
+	var files []string
+	if sourceInfo.IsDir() {
+		err = walkMedia(fsys, sourcePath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				files = append(files, path)
+			}
+			return nil
+		})
+	} else {
+		files, err = sidecarFiles(fsys, sourcePath)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	itemFolder := filepath.Join(targetRoot, titleYearName(title, year))
+	var moves []CrossLibraryMove
+	if moveInput.TargetLibrary == "shows" {
+		moves, err = showLayoutMoves(files, itemFolder)
+	} else {
+		moves, err = movieLayoutMoves(files, itemFolder, titleYearName(title, year))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	targets := map[string]bool{}
+	for _, move := range moves {
+		if err := ValidatePath(move.Target); err != nil {
+			return "", fmt.Errorf("invalid target path: %v", err)
+		}
+		if _, err := fsys.Stat(move.Target); err == nil || targets[move.Target] {
+			return "", fmt.Errorf("target path already exists: %s", move.Target)
+		}
+		targets[move.Target] = true
+	}
+
+	output := MoveAcrossLibrariesOutput{Destination: itemFolder, Moves: moves}
+
+	if moveInput.Apply && !DryRun {
+		var tx transaction
+		for _, move := range moves {
+			source, target := move.Source, move.Target
+			if err := moveMedia(source, target); err != nil {
+				return "", rollbackError(fmt.Errorf("failed to move %s: %v", source, err), tx.rollback())
+			}
+			tx.add("move of "+source, undoMove(source, target))
+		}
+
+		// The old folder is left empty once everything is moved out
+		if sourceInfo.IsDir() {
+			removeEmptyDirs(fsys, sourcePath)
+		}
+		output.Applied = true
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if moveInput.Apply && DryRun {
+		return dryRunOutput(jsonData), nil
+	}
+
+	return string(jsonData), nil
+}
+
+// showLayoutMoves places episodes and their subtitles in season folders.
+// Files without episode numbering, like artwork, go to the show folder
+func showLayoutMoves(files []string, showFolder string) ([]CrossLibraryMove, error) {
+	var moves []CrossLibraryMove
+	for _, file := range files {
+		name := filepath.Base(file)
+		info := parseReleaseName(name)
+
+		target := filepath.Join(showFolder, name)
+		if info.IsEpisode {
+			target = filepath.Join(showFolder, fmt.Sprintf("Season %02d", info.Season), name)
+		} else if isVideoFile(name) {
+			return nil, fmt.Errorf("cannot place %s in a season, its name has no SxxEyy episode number", file)
+		}
+
+		moves = append(moves, CrossLibraryMove{Source: file, Target: target})
+	}
+	return moves, nil
+}
+
+// movieLayoutMoves renames the single movie video, and sidecars sharing its
+// base name, after the movie folder. Other files keep their names
+func movieLayoutMoves(files []string, movieFolder, movieName string) ([]CrossLibraryMove, error) {
+	var video string
+	for _, file := range files {
+		if !isVideoFile(file) {
+			continue
+		}
+		if video != "" {
+			return nil, fmt.Errorf("a movie must contain a single video, found %s and %s", video, file)
+		}
+		video = file
+	}
+	if video == "" {
+		return nil, fmt.Errorf("no video file found to move into the movies library")
+	}
+
+	base := strings.TrimSuffix(filepath.Base(video), filepath.Ext(video))
+	var moves []CrossLibraryMove
+	for _, file := range files {
+		name := filepath.Base(file)
+		target := filepath.Join(movieFolder, name)
+		if strings.HasPrefix(name, base+".") {
+			target = filepath.Join(movieFolder, movieName+strings.TrimPrefix(name, base))
+		}
+		moves = append(moves, CrossLibraryMove{Source: file, Target: target})
+	}
+	return moves, nil
+}
+
+// sidecarFiles returns a file together with the files next to it that share
+// its base name, like subtitles and nfo files
+func sidecarFiles(fsys MediaFS, path string) ([]string, error) {
+	entries, err := fsys.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	files := []string{path}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == filepath.Base(path) || !strings.HasPrefix(name, base+".") {
+			continue
+		}
+		files = append(files, filepath.Join(filepath.Dir(path), name))
+	}
+	return files, nil
+}
+
+// removeEmptyDirs removes a folder tree that only contains empty folders
+func removeEmptyDirs(fsys MediaFS, root string) {
+	var dirs []string
+	walkMedia(fsys, root, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		fsys.Remove(dirs[i])
+	}
+}
+
+// titleYearName formats the "Title (Year)" name Jellyfin expects for movie
+// and show folders
+func titleYearName(title string, year int) string {
+	if year > 0 {
+		return title + " (" + strconv.Itoa(year) + ")"
+	}
+	return title
+}
@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNetworkConcurrency = 4
+	defaultNetworkRate        = 5
+)
+
+// networkLimiter bounds outbound requests across all network tools, both in
+// how many run at once and in how often a new one may start
+type networkLimiter struct {
+	slots    chan struct{}
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+var (
+	sharedNetworkLimiter *networkLimiter
+	networkLimiterOnce   sync.Once
+)
+
+// networkLimit returns the shared limiter. It is created on first use, after
+// main has loaded the environment, from NETWORK_MAX_CONCURRENCY and
+// NETWORK_REQUESTS_PER_SECOND. A rate of 0 disables the spacing
+func networkLimit() *networkLimiter {
+	networkLimiterOnce.Do(func() {
+		concurrency := envInt("NETWORK_MAX_CONCURRENCY", defaultNetworkConcurrency)
+		rate := envInt("NETWORK_REQUESTS_PER_SECOND", defaultNetworkRate)
+		sharedNetworkLimiter = newNetworkLimiter(concurrency, rate)
+	})
+	return sharedNetworkLimiter
+}
+
+func newNetworkLimiter(concurrency, perSecond int) *networkLimiter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	limiter := &networkLimiter{slots: make(chan struct{}, concurrency)}
+	if perSecond > 0 {
+		limiter.interval = time.Second / time.Duration(perSecond)
+	}
+	return limiter
+}
+
+// acquire blocks until a request may start and returns the function that
+// frees its slot. It gives up with ctx's error once ctx is done
+func (l *networkLimiter) acquire(ctx context.Context) (func(), error) {
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	release := func() { once.Do(func() { <-l.slots }) }
+
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+
+	return release, nil
+}
+
+// limitedTransport passes every request through the shared limiter. The slot
+// is held until the response body is closed, so slow downloads count too
+type limitedTransport struct {
+	base http.RoundTripper
+}
+
+var networkTransport http.RoundTripper = limitedTransport{base: http.DefaultTransport}
+
+func (t limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := networkLimit().acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// envInt reads an integer environment variable, falling back to def when it
+// is unset or invalid
+func envInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// withoutURL strips the request URL from the error of a failed request.
+// Services that take their key in the query string would otherwise show it
+// in tool results and logs
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
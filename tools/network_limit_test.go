@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNetworkLimiterSpacing(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		perSecond   int
+		requests    int
+		wantGap     time.Duration
+	}{
+		{name: "concurrent requests are spaced", concurrency: 4, perSecond: 20, requests: 5, wantGap: 50 * time.Millisecond},
+		{name: "single slot", concurrency: 1, perSecond: 25, requests: 3, wantGap: 40 * time.Millisecond},
+		{name: "no rate limit", concurrency: 4, perSecond: 0, requests: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newNetworkLimiter(tt.concurrency, tt.perSecond)
+
+			var mu sync.Mutex
+			var starts []time.Time
+			var wg sync.WaitGroup
+			for i := 0; i < tt.requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					release, err := limiter.acquire(context.Background())
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					mu.Lock()
+					starts = append(starts, time.Now())
+					mu.Unlock()
+					release()
+				}()
+			}
+			wg.Wait()
+
+			sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+			for i := 1; i < len(starts); i++ {
+				// Allow for timer granularity
+				if gap := starts[i].Sub(starts[i-1]); gap < tt.wantGap-5*time.Millisecond {
+					t.Errorf("request %d started %s after the previous one, want at least %s", i+1, gap, tt.wantGap)
+				}
+			}
+		})
+	}
+}
+
+func TestNetworkLimiterConcurrency(t *testing.T) {
+	limiter := newNetworkLimiter(2, 0)
+
+	first, err := limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both slots are taken, so a third request waits until its context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire with all slots taken = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// Releasing twice must not free a second slot
+	first()
+	first()
+	if _, err := limiter.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	if len(limiter.slots) != 2 {
+		t.Errorf("%d slots taken, want 2", len(limiter.slots))
+	}
+}
+
+func TestNetworkLimiterCancelWhileSpaced(t *testing.T) {
+	limiter := newNetworkLimiter(2, 1)
+	if _, err := limiter.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next start is a second away, cancelling must not wait for it
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	began := time.Now()
+	if _, err := limiter.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if waited := time.Since(began); waited > 500*time.Millisecond {
+		t.Errorf("acquire returned after %s, want it to stop with its context", waited)
+	}
+	if len(limiter.slots) != 1 {
+		t.Errorf("cancelled request kept its slot, %d slots taken", len(limiter.slots))
+	}
+}
@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+type NormalizeEpisodePaddingInput struct {
+	ShowPath string `json:"show_path" jsonschema_description:"The show folder to scan, including its season folders. Must be within the permitted folders."`
+	Apply    bool   `json:"apply" jsonschema_description:"If true, the proposed renames are applied (and rolled back if one fails). If false, they are only reported."`
+}
+
+var NormalizeEpisodePaddingInputSchema = GenerateSchema[NormalizeEpisodePaddingInput]()
+
+var NormalizeEpisodePaddingDefinition = ToolDefinition{
+	Name:           "normalize_episode_padding",
+	Description:    "Scan a show folder for episode files (and their subtitles) whose season/episode numbers are not zero-padded consistently, like S1E1 next to S01E02, and propose renames to the canonical two-digit SxxEyy form. Proposals are validated and checked for collisions. Set apply to perform the renames.",
+	InputSchema:    NormalizeEpisodePaddingInputSchema,
+	Function:       NormalizeEpisodePadding,
+	WritesOnlyWith: "apply",
+}
+
+type PaddingRename struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Error  string `json:"error,omitempty"`
+}
+
+type NormalizeEpisodePaddingOutput struct {
+	PaddedCount   int             `json:"padded_count"`
+	UnpaddedCount int             `json:"unpadded_count"`
+	Consistent    bool            `json:"consistent"`
+	Renames       []PaddingRename `json:"renames"`
+	Applied       bool            `json:"applied"`
+}
+
+var episodeMarkerPattern = regexp.MustCompile(`(?i)S(\d{1,3})E(\d{1,3})(-?E(\d{1,3}))?`)
+
+func NormalizeEpisodePadding(input json.RawMessage) (string, error) {
+	paddingInput := NormalizeEpisodePaddingInput{}
+	err := json.Unmarshal(input, &paddingInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(paddingInput.ShowPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	output := NormalizeEpisodePaddingOutput{Renames: []PaddingRename{}}
+	targets := map[string]bool{}
+
+	err = walkMedia(fsys, paddingInput.ShowPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+
+		name := info.Name()
+		loc := episodeMarkerPattern.FindStringSubmatchIndex(name)
+		if loc == nil {
+			return nil
+		}
+
+		marker := name[loc[0]:loc[1]]
+		canonical := canonicalEpisodeMarker(name, loc)
+		if marker == canonical {
+			output.PaddedCount++
+			return nil
+		}
+		output.UnpaddedCount++
+
+		target := filepath.Join(filepath.Dir(path), name[:loc[0]]+canonical+name[loc[1]:])
+		rename := PaddingRename{Source: path, Target: target}
+
+		if err := ValidatePath(target); err != nil {
+			rename.Error = err.Error()
+		} else if _, err := fsys.Stat(target); err == nil {
+			rename.Error = "target already exists"
+		} else if targets[target] {
+			rename.Error = "another file would be renamed to the same target"
+		}
+		targets[target] = true
+
+		output.Renames = append(output.Renames, rename)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	output.Consistent = output.UnpaddedCount == 0
+
+	if paddingInput.Apply {
+		for _, rename := range output.Renames {
+			if rename.Error != "" {
+				return "", fmt.Errorf("not applying renames, %s cannot be renamed: %s", rename.Source, rename.Error)
+			}
+		}
+	}
+
+	if paddingInput.Apply && len(output.Renames) > 0 && !DryRun {
+		var tx transaction
+		for _, rename := range output.Renames {
+			source, target := rename.Source, rename.Target
+			if err := moveMedia(source, target); err != nil {
+				return "", rollbackError(fmt.Errorf("failed to rename %s: %v", source, err), tx.rollback())
+			}
+			tx.add("rename of "+source, undoMove(source, target))
+		}
+		output.Applied = true
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if paddingInput.Apply && DryRun {
+		return dryRunOutput(jsonData), nil
+	}
+
+	return string(jsonData), nil
+}
+
+// canonicalEpisodeMarker rebuilds a matched SxxEyy marker with two-digit
+// zero-padding and upper case, keeping multi-episode ranges
+func canonicalEpisodeMarker(name string, loc []int) string {
+	season, _ := strconv.Atoi(name[loc[2]:loc[3]])
+	episode, _ := strconv.Atoi(name[loc[4]:loc[5]])
+
+	canonical := fmt.Sprintf("S%02dE%02d", season, episode)
+	if loc[6] >= 0 {
+		lastEpisode, _ := strconv.Atoi(name[loc[8]:loc[9]])
+		canonical += fmt.Sprintf("-E%02d", lastEpisode)
+	}
+
+	return canonical
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// QueuedOperation is a move or copy waiting to be committed as part of a batch
+type QueuedOperation struct {
+	Type   string `json:"type" jsonschema:"enum=move,enum=copy" jsonschema_description:"The operation to queue: move (rename) or copy."`
+	Source string `json:"source" jsonschema_description:"The source file or folder path."`
+	Target string `json:"target" jsonschema_description:"The target path. Must be within Jellyfin media directories."`
+}
+
+type CommitQueueInput struct{}
+
+var EnqueueOperationInputSchema = GenerateSchema[QueuedOperation]()
+
+var CommitQueueInputSchema = GenerateSchema[CommitQueueInput]()
+
+// OperationQueue holds operations until the user approves them all at once
+type OperationQueue struct {
+	mu         sync.Mutex
+	operations []QueuedOperation
+	confirm    func(prompt string) bool
+}
+
+// NewOperationQueue creates a queue that asks confirm before committing
+func NewOperationQueue(confirm func(prompt string) bool) *OperationQueue {
+	return &OperationQueue{confirm: confirm}
+}
+
+// Definitions returns the enqueue_operation and commit_queue tools bound to
+// this queue
+func (q *OperationQueue) Definitions() []ToolDefinition {
+	return []ToolDefinition{
+		{
+			Name:        "enqueue_operation",
+			Description: "Queue a move or copy instead of running it immediately. Queue every operation of the plan, then call commit_queue once so the user can approve the whole batch with a single confirmation.",
+			InputSchema: EnqueueOperationInputSchema,
+			Function:    q.Enqueue,
+		},
+		{
+			Name:        "commit_queue",
+			Description: "Ask the user to approve all queued operations at once and execute them in order. If any operation fails, the completed ones are rolled back. The queue is emptied after a commit attempt.",
+			InputSchema: CommitQueueInputSchema,
+			Contextual:  q.Commit,
+		},
+	}
+}
+
+func (q *OperationQueue) Enqueue(input json.RawMessage) (string, error) {
+	operation := QueuedOperation{}
+	err := json.Unmarshal(input, &operation)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if operation.Type != "move" && operation.Type != "copy" {
+		return "", fmt.Errorf("unsupported operation type %q, expected move or copy", operation.Type)
+	}
+
+	// Validate up front so mistakes surface before the batch is approved.
+	// Targets get the same checks as rename_jellyfin_media and copy_file
+	target, err := sanitizePath(operation.Target)
+	if err != nil {
+		return "", err
+	}
+	operation.Target = target
+
+	if err := ValidatePath(operation.Source); err != nil {
+		return "", fmt.Errorf("invalid source path: %v", err)
+	}
+	if err := ValidateLibraryPath(operation.Target); err != nil {
+		return "", fmt.Errorf("invalid target path: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+	if _, err := fsys.Stat(operation.Source); os.IsNotExist(err) {
+		return "", fmt.Errorf("source path does not exist: %s", operation.Source)
+	}
+	if _, err := fsys.Stat(operation.Target); err == nil {
+		return "", fmt.Errorf("target path already exists: %s", operation.Target)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, queued := range q.operations {
+		if queued.Target == operation.Target {
+			return "", fmt.Errorf("target path %s is already used by queued operation #%d", operation.Target, i+1)
+		}
+	}
+
+	q.operations = append(q.operations, operation)
+	return fmt.Sprintf("Queued operation #%d: %s %s to %s", len(q.operations), operation.Type, operation.Source, operation.Target), nil
+}
+
+func (q *OperationQueue) Commit(ctx context.Context, input json.RawMessage) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.operations) == 0 {
+		return "The queue is empty, nothing to commit", nil
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "%d queued operations:\n", len(q.operations))
+	for i, operation := range q.operations {
+		fmt.Fprintf(&summary, "  %d. %s %s -> %s\n", i+1, operation.Type, operation.Source, operation.Target)
+	}
+	summary.WriteString("Apply all of them?")
+
+	if q.confirm == nil || !q.confirm(summary.String()) {
+		return "The user declined the batch. The queue was kept, ask the user what to change", nil
+	}
+
+	operations := q.operations
+	q.operations = nil
+
+	var tx transaction
+	for i, operation := range operations {
+		source, target := operation.Source, operation.Target
+
+		switch operation.Type {
+		case "move":
+			_, err := moveMediaReplacing(ctx, source, target, false)
+			if err != nil {
+				return "", rollbackError(fmt.Errorf("operation #%d failed: %v", i+1, err), tx.rollback())
+			}
+			tx.add("move of "+source, undoMove(source, target))
+		case "copy":
+			err := copyMedia(ctx, source, target, false)
+			if err != nil {
+				return "", rollbackError(fmt.Errorf("operation #%d failed: %v", i+1, err), tx.rollback())
+			}
+			tx.add("copy to "+target, undoCopy(source, target))
+		}
+	}
+
+	if DryRun {
+		return fmt.Sprintf("%swould commit %d queued operations, nothing was changed", dryRunPrefix, len(operations)), nil
+	}
+	return fmt.Sprintf("Successfully committed %d queued operations", len(operations)), nil
+}
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOperationQueueEnqueue(t *testing.T) {
+	library := setupTestLibrary(t)
+	downloads := filepath.Join(filepath.Dir(library), "downloads")
+	t.Setenv("SOURCE_FOLDER", downloads)
+
+	movie := filepath.Join(downloads, "Movie.2009.mkv")
+	existing := filepath.Join(library, "Existing (2001)", "Existing (2001).mkv")
+	writeTestFile(t, movie)
+	writeTestFile(t, existing)
+
+	tests := []struct {
+		name       string
+		operations []QueuedOperation
+		wantErr    string
+		wantTarget string
+	}{
+		{
+			name:       "move into the library",
+			operations: []QueuedOperation{{Type: "move", Source: movie, Target: filepath.Join(library, "Movie (2009).mkv")}},
+			wantTarget: filepath.Join(library, "Movie (2009).mkv"),
+		},
+		{
+			name:       "target name is sanitized",
+			operations: []QueuedOperation{{Type: "copy", Source: movie, Target: filepath.Join(library, "Movie: Part 1 (2009).mkv")}},
+			wantTarget: filepath.Join(library, "Movie - Part 1 (2009).mkv"),
+		},
+		{
+			name:       "unsupported type",
+			operations: []QueuedOperation{{Type: "delete", Source: movie, Target: filepath.Join(library, "Movie (2009).mkv")}},
+			wantErr:    "unsupported operation type",
+		},
+		{
+			name:       "target in the downloads folder",
+			operations: []QueuedOperation{{Type: "move", Source: existing, Target: filepath.Join(downloads, "Existing.mkv")}},
+			wantErr:    "invalid target path",
+		},
+		{
+			name:       "copy source outside the permitted folders",
+			operations: []QueuedOperation{{Type: "copy", Source: "/etc/passwd", Target: filepath.Join(library, "passwd")}},
+			wantErr:    "invalid source path",
+		},
+		{
+			name:       "missing source",
+			operations: []QueuedOperation{{Type: "move", Source: filepath.Join(downloads, "Missing.mkv"), Target: filepath.Join(library, "Missing.mkv")}},
+			wantErr:    "does not exist",
+		},
+		{
+			name:       "existing target",
+			operations: []QueuedOperation{{Type: "copy", Source: movie, Target: existing}},
+			wantErr:    "already exists",
+		},
+		{
+			name: "same target queued twice",
+			operations: []QueuedOperation{
+				{Type: "copy", Source: movie, Target: filepath.Join(library, "Movie (2009).mkv")},
+				{Type: "copy", Source: existing, Target: filepath.Join(library, "Movie (2009).mkv")},
+			},
+			wantErr: "already used by queued operation #1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := NewOperationQueue(nil)
+
+			var err error
+			for _, operation := range tt.operations {
+				input, marshalErr := json.Marshal(operation)
+				if marshalErr != nil {
+					t.Fatal(marshalErr)
+				}
+				if _, err = queue.Enqueue(input); err != nil {
+					break
+				}
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Enqueue error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Enqueue: %v", err)
+			}
+			if got := queue.operations[len(queue.operations)-1].Target; got != tt.wantTarget {
+				t.Errorf("queued target = %q, want %q", got, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestOperationQueueCommit(t *testing.T) {
+	tests := []struct {
+		name string
+		// blockCopy creates the copy's target after it was queued, so the
+		// commit fails on the second operation
+		blockCopy  bool
+		wantErr    bool
+		wantMoved  bool
+		wantCopied bool
+	}{
+		{name: "all operations succeed", wantMoved: true, wantCopied: true},
+		{name: "failure rolls back completed operations", blockCopy: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			library := setupTestLibrary(t)
+			first := filepath.Join(library, "first.mkv")
+			second := filepath.Join(library, "second.mkv")
+			moved := filepath.Join(library, "First (2001)", "First (2001).mkv")
+			copied := filepath.Join(library, "Second (2002)", "Second (2002).mkv")
+			writeTestFile(t, first)
+			writeTestFile(t, second)
+
+			var prompt string
+			queue := NewOperationQueue(func(p string) bool {
+				prompt = p
+				return true
+			})
+			for _, operation := range []QueuedOperation{
+				{Type: "move", Source: first, Target: moved},
+				{Type: "copy", Source: second, Target: copied},
+			} {
+				input, err := json.Marshal(operation)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := queue.Enqueue(input); err != nil {
+					t.Fatalf("Enqueue: %v", err)
+				}
+			}
+
+			if tt.blockCopy {
+				writeTestFile(t, copied)
+			}
+
+			_, err := queue.Commit(context.Background(), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Commit error = %v, want error %v", err, tt.wantErr)
+			}
+			if !strings.Contains(prompt, "2 queued operations") {
+				t.Errorf("confirmation prompt %q does not list the batch", prompt)
+			}
+			if len(queue.operations) != 0 {
+				t.Errorf("queue still holds %d operations after the commit", len(queue.operations))
+			}
+
+			if _, err := os.Stat(moved); (err == nil) != tt.wantMoved {
+				t.Errorf("%s exists = %v, want %v", moved, err == nil, tt.wantMoved)
+			}
+			if _, err := os.Stat(first); (err == nil) == tt.wantMoved {
+				t.Errorf("%s exists = %v, want %v", first, err == nil, !tt.wantMoved)
+			}
+			if data, err := os.ReadFile(copied); tt.wantCopied && string(data) != "second.mkv" {
+				t.Errorf("copy holds %q (%v), want the source's data", data, err)
+			}
+			if _, err := os.Stat(second); err != nil {
+				t.Errorf("copy source is gone: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type ParseReleaseNameInput struct {
+	Name string `json:"name" jsonschema_description:"The file or folder name to parse, e.g. 'Show.Name.S01E02.1080p.WEB-DL.x265-GROUP.mkv'. Only the name is parsed, nothing is read from disk."`
+}
+
+var ParseReleaseNameInputSchema = GenerateSchema[ParseReleaseNameInput]()
+
+var ParseReleaseNameDefinition = ToolDefinition{
+	Name:        "parse_release_name",
+	Description: "Parse a scene or P2P release name into structured metadata: title, year, season, episode, resolution, source (BluRay, WEB-DL, ...), codec and release group. Use it instead of reading these off the file name by eye.",
+	InputSchema: ParseReleaseNameInputSchema,
+	Function:    ParseReleaseName,
+}
+
+func ParseReleaseName(input json.RawMessage) (string, error) {
+	parseInput := ParseReleaseNameInput{}
+	err := json.Unmarshal(input, &parseInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if parseInput.Name == "" {
+		return "", fmt.Errorf("name must not be empty")
+	}
+
+	jsonData, err := json.Marshal(parseReleaseName(parseInput.Name))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
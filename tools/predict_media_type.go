@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type PredictMediaTypeInput struct {
+	Library string `json:"library" jsonschema_description:"The library the item is meant for: 'movies' or 'shows'."`
+	Name    string `json:"name" jsonschema_description:"The proposed path relative to the library root, e.g. 'Series Name (2020)/Season 01/Series Name S01E01.mkv', or just a file name."`
+}
+
+var PredictMediaTypeInputSchema = GenerateSchema[PredictMediaTypeInput]()
+
+var PredictMediaTypeDefinition = ToolDefinition{
+	Name:        "predict_media_type",
+	Description: "Predict whether Jellyfin will read a proposed name as a movie or a TV episode, following the Jellyfin naming rules, and warn when that conflicts with the library it is going into. Catches movies whose names look like SxxEyy episodes, episodes without an episode number, and episodes outside a series folder or in the wrong season folder.",
+	InputSchema: PredictMediaTypeInputSchema,
+	Function:    PredictMediaType,
+}
+
+type PredictMediaTypeOutput struct {
+	PredictedType string   `json:"predicted_type"`
+	Season        int      `json:"season,omitempty"`
+	Episode       int      `json:"episode,omitempty"`
+	AirDate       string   `json:"air_date,omitempty"`
+	Conflict      bool     `json:"conflict"`
+	Warnings      []string `json:"warnings"`
+}
+
+func PredictMediaType(input json.RawMessage) (string, error) {
+	predictInput := PredictMediaTypeInput{}
+	err := json.Unmarshal(input, &predictInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if predictInput.Library != "movies" && predictInput.Library != "shows" {
+		return "", fmt.Errorf("library must be 'movies' or 'shows', got %q", predictInput.Library)
+	}
+	if predictInput.Name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+
+	jsonData, err := json.Marshal(predictMediaType(predictInput.Library, predictInput.Name))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// predictMediaType classifies a library relative path the way Jellyfin's
+// resolvers would and collects warnings for the intended library
+func predictMediaType(library, name string) PredictMediaTypeOutput {
+	output := PredictMediaTypeOutput{PredictedType: "movie", Warnings: []string{}}
+
+	name = filepath.ToSlash(filepath.Clean(name))
+	parts := strings.Split(name, "/")
+	fileName := parts[len(parts)-1]
+	folders := parts[:len(parts)-1]
+
+	seasonFolder := -1
+	for _, folder := range folders {
+		if match := seasonFolderPattern.FindStringSubmatch(folder); match != nil {
+			seasonFolder, _ = strconv.Atoi(match[1])
+		}
+	}
+
+	info := parseReleaseName(fileName)
+	switch {
+	case info.IsEpisode:
+		output.PredictedType = "episode"
+		output.Season = info.Season
+		output.Episode = info.Episode
+	case airDatePattern.MatchString(fileName):
+		match := airDatePattern.FindStringSubmatch(fileName)
+		output.PredictedType = "episode"
+		output.AirDate = match[1] + "-" + match[2] + "-" + match[3]
+	}
+
+	warn := func(conflict bool, format string, args ...any) {
+		output.Warnings = append(output.Warnings, fmt.Sprintf(format, args...))
+		output.Conflict = output.Conflict || conflict
+	}
+
+	if library == "movies" {
+		if output.PredictedType == "episode" {
+			warn(true, "%s looks like a TV episode, the movie scanner will match it against the wrong title; drop the episode numbering or move it to the shows library", fileName)
+		}
+		if seasonFolder >= 0 {
+			warn(true, "movies should not be inside a season folder")
+		}
+		return output
+	}
+
+	if output.PredictedType != "episode" {
+		warn(true, "%s has no SxxEyy episode number or air date, Jellyfin can't tell which episode it is", fileName)
+	}
+	if len(folders) == 0 || (len(folders) == 1 && seasonFolder >= 0) {
+		warn(true, "episodes must be inside a series folder, like 'Series Name (year)/Season 01/'")
+	}
+	if seasonFolder >= 0 && output.Season > 0 && seasonFolder != output.Season {
+		warn(true, "season folder %d doesn't match season %d in the file name", seasonFolder, output.Season)
+	}
+	if seasonFolder < 0 && len(folders) > 0 {
+		warn(false, "no season folder, Jellyfin will place the episode using its file name alone")
+	}
+
+	return output
+}
@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+type ProbeMediaInput struct {
+	Path string `json:"path" jsonschema_description:"The video file to probe. Must be within the permitted folders."`
+}
+
+var ProbeMediaInputSchema = GenerateSchema[ProbeMediaInput]()
+
+var ProbeMediaDefinition = ToolDefinition{
+	Name:        "probe_media",
+	Description: "Read the technical metadata of a video file with ffprobe: duration, resolution, video codec and every audio and subtitle track with its codec and language. The audio languages are also listed as ISO 639-1 codes where known, along with the default audio language, to check that a foreign film plays in its original language. Use it instead of guessing the resolution or codec from the file name, and to decide which of two copies of the same title is better. Fails with a clear message when ffprobe isn't installed.",
+	InputSchema: ProbeMediaInputSchema,
+	Contextual:  ProbeMedia,
+}
+
+type MediaTrack struct {
+	Index    int    `json:"index"`
+	Codec    string `json:"codec"`
+	Language string `json:"language,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Channels int    `json:"channels,omitempty"`
+	Default  bool   `json:"default,omitempty"`
+	Forced   bool   `json:"forced,omitempty"`
+}
+
+type ProbeMediaOutput struct {
+	Path           string       `json:"path"`
+	Duration       float64      `json:"duration_seconds,omitempty"`
+	Width          int          `json:"width,omitempty"`
+	Height         int          `json:"height,omitempty"`
+	Resolution     string       `json:"resolution,omitempty"`
+	VideoCodec     string       `json:"video_codec,omitempty"`
+	AudioTracks    []MediaTrack `json:"audio_tracks"`
+	SubtitleTracks []MediaTrack `json:"subtitle_tracks"`
+	// AudioLanguages lists each audio language once, in track order
+	AudioLanguages       []string `json:"audio_languages"`
+	DefaultAudioLanguage string   `json:"default_audio_language,omitempty"`
+}
+
+// ffprobeMediaInfo is the subset of `ffprobe -show_format -show_streams`
+// output that describes a file's tracks
+type ffprobeMediaInfo struct {
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+	Streams []struct {
+		Index       int               `json:"index"`
+		CodecType   string            `json:"codec_type"`
+		CodecName   string            `json:"codec_name"`
+		Width       int               `json:"width"`
+		Height      int               `json:"height"`
+		Channels    int               `json:"channels"`
+		Tags        map[string]string `json:"tags"`
+		Disposition struct {
+			Default         int `json:"default"`
+			Forced          int `json:"forced"`
+			AttachedPicture int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+}
+
+// mediaProbeArgs read the container and all its streams
+var mediaProbeArgs = []string{"-show_format", "-show_streams", "-print_format", "json"}
+
+func ProbeMedia(ctx context.Context, input json.RawMessage) (string, error) {
+	probeInput := ProbeMediaInput{}
+	err := json.Unmarshal(input, &probeInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(probeInput.Path); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	if IsRemoteMedia() {
+		return "", fmt.Errorf("probing is only supported for local media")
+	}
+
+	if err := checkFFprobe(); err != nil {
+		return "", err
+	}
+
+	probe, err := runFFprobe(ctx, probeInput.Path, mediaProbeArgs...)
+	if err != nil {
+		return "", fmt.Errorf("ffprobe failed: %v", err)
+	}
+
+	output, err := parseMediaProbe(probe)
+	if err != nil {
+		return "", err
+	}
+	output.Path = probeInput.Path
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// parseMediaProbe interprets ffprobe JSON output. The first video stream
+// that isn't embedded cover art is taken as the main one
+func parseMediaProbe(probe []byte) (ProbeMediaOutput, error) {
+	var parsed ffprobeMediaInfo
+	if err := json.Unmarshal(probe, &parsed); err != nil {
+		return ProbeMediaOutput{}, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+
+	output := ProbeMediaOutput{AudioTracks: []MediaTrack{}, SubtitleTracks: []MediaTrack{}}
+	output.Duration, _ = strconv.ParseFloat(parsed.Format.Duration, 64)
+
+	for _, stream := range parsed.Streams {
+		track := MediaTrack{
+			Index:    stream.Index,
+			Codec:    stream.CodecName,
+			Language: stream.Tags["language"],
+			Title:    stream.Tags["title"],
+			Channels: stream.Channels,
+			Default:  stream.Disposition.Default == 1,
+			Forced:   stream.Disposition.Forced == 1,
+		}
+		// "und" is how containers say the language wasn't set
+		if track.Language == "und" {
+			track.Language = ""
+		}
+
+		switch stream.CodecType {
+		case "video":
+			if output.VideoCodec == "" && stream.Disposition.AttachedPicture == 0 {
+				output.VideoCodec = stream.CodecName
+				output.Width = stream.Width
+				output.Height = stream.Height
+				output.Resolution = resolutionLabel(stream.Width, stream.Height)
+			}
+		case "audio":
+			output.AudioTracks = append(output.AudioTracks, track)
+		case "subtitle":
+			output.SubtitleTracks = append(output.SubtitleTracks, track)
+		}
+	}
+
+	if output.VideoCodec == "" {
+		return ProbeMediaOutput{}, fmt.Errorf("no video stream found")
+	}
+
+	output.AudioLanguages, output.DefaultAudioLanguage = audioLanguages(output.AudioTracks)
+
+	return output, nil
+}
+
+// audioLanguages returns the distinct languages of the audio tracks as ISO
+// 639-1 codes, keeping tags that aren't known as they are, and the language
+// of the default track. Without a default flag players take the first track
+func audioLanguages(tracks []MediaTrack) ([]string, string) {
+	languages := []string{}
+	seen := map[string]bool{}
+	for _, track := range tracks {
+		language := trackLanguage(track)
+		if language != "" && !seen[language] {
+			seen[language] = true
+			languages = append(languages, language)
+		}
+	}
+
+	if len(tracks) == 0 {
+		return languages, ""
+	}
+	main := tracks[0]
+	for _, track := range tracks {
+		if track.Default {
+			main = track
+			break
+		}
+	}
+	return languages, trackLanguage(main)
+}
+
+// trackLanguage returns the ISO 639-1 code of a track's language tag, or
+// the tag itself when it isn't a known language
+func trackLanguage(track MediaTrack) string {
+	if code, ok := lookupLanguage(track.Language); ok {
+		return code
+	}
+	return track.Language
+}
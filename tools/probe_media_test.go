@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMediaProbe(t *testing.T) {
+	tests := []struct {
+		name            string
+		probe           string
+		wantErr         bool
+		wantResolution  string
+		wantCodec       string
+		wantLanguages   []string
+		wantDefault     string
+		wantSubtitles   int
+		wantDurationSec float64
+	}{
+		{
+			name: "movie with two audio tracks",
+			probe: `{"format":{"duration":"5400.5"},"streams":[
+				{"index":0,"codec_type":"video","codec_name":"hevc","width":3840,"height":1600},
+				{"index":1,"codec_type":"audio","codec_name":"eac3","channels":6,"tags":{"language":"jpn"}},
+				{"index":2,"codec_type":"audio","codec_name":"aac","channels":2,"tags":{"language":"eng"},"disposition":{"default":1}},
+				{"index":3,"codec_type":"subtitle","codec_name":"subrip","tags":{"language":"und"}}]}`,
+			wantResolution:  "2160p",
+			wantCodec:       "hevc",
+			wantLanguages:   []string{"ja", "en"},
+			wantDefault:     "en",
+			wantSubtitles:   1,
+			wantDurationSec: 5400.5,
+		},
+		{
+			name: "cover art is not the video",
+			probe: `{"streams":[
+				{"index":0,"codec_type":"video","codec_name":"mjpeg","width":600,"height":900,"disposition":{"attached_pic":1}},
+				{"index":1,"codec_type":"video","codec_name":"h264","width":1920,"height":1080},
+				{"index":2,"codec_type":"audio","codec_name":"ac3","tags":{"language":"spa"}}]}`,
+			wantResolution: "1080p",
+			wantCodec:      "h264",
+			wantLanguages:  []string{"es"},
+			wantDefault:    "es",
+		},
+		{
+			name:    "no video",
+			probe:   `{"streams":[{"index":0,"codec_type":"audio","codec_name":"flac"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid output",
+			probe:   `not json`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMediaProbe([]byte(tt.probe))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Resolution != tt.wantResolution || got.VideoCodec != tt.wantCodec || got.Duration != tt.wantDurationSec {
+				t.Errorf("got %s %s %v, want %s %s %v", got.Resolution, got.VideoCodec, got.Duration, tt.wantResolution, tt.wantCodec, tt.wantDurationSec)
+			}
+			if !reflect.DeepEqual(got.AudioLanguages, tt.wantLanguages) || got.DefaultAudioLanguage != tt.wantDefault {
+				t.Errorf("audio languages %v (default %q), want %v (default %q)", got.AudioLanguages, got.DefaultAudioLanguage, tt.wantLanguages, tt.wantDefault)
+			}
+			if len(got.SubtitleTracks) != tt.wantSubtitles {
+				t.Errorf("got %d subtitle tracks, want %d", len(got.SubtitleTracks), tt.wantSubtitles)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type QuickRenameInput struct {
+	SourcePath string `json:"source_path" jsonschema_description:"The video file to rename, named like a scene/P2P release."`
+	Apply      bool   `json:"apply" jsonschema_description:"If true and the parse is confident, the file and its sidecars are moved into place. If false, the proposal is only reported."`
+}
+
+var QuickRenameInputSchema = GenerateSchema[QuickRenameInput]()
+
+var QuickRenameDefinition = ToolDefinition{
+	Name:           "quick_rename",
+	Description:    "Fast path for clearly named files: parse a release name like Movie.Name.2009.1080p.BluRay.x264-GRP.mkv or Show.Name.S01E02.720p.WEB.mkv without any metadata lookup and compute its Jellyfin location, reusing an existing show folder when there is one. Only confident parses are applied. When confident is false, fall back to searching IMDb/TMDb before renaming.",
+	InputSchema:    QuickRenameInputSchema,
+	Function:       QuickRename,
+	WritesOnlyWith: "apply",
+}
+
+type QuickRenameOutput struct {
+	Confident bool               `json:"confident"`
+	Reasons   []string           `json:"reasons"`
+	Parsed    ReleaseInfo        `json:"parsed"`
+	Moves     []CrossLibraryMove `json:"moves,omitempty"`
+	Applied   bool               `json:"applied"`
+}
+
+// suspiciousTitlePattern matches leftovers that suggest the title boundary
+// was guessed wrong, like brackets, a lone number or a group tag
+var suspiciousTitlePattern = regexp.MustCompile(`[\[\]{}()]|^\d+$|-\w+$`)
+
+func QuickRename(input json.RawMessage) (string, error) {
+	renameInput := QuickRenameInput{}
+	err := json.Unmarshal(input, &renameInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	sourcePath := renameInput.SourcePath
+	if err := ValidatePath(sourcePath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+	if !isVideoFile(sourcePath) {
+		return "", fmt.Errorf("not a video file: %s", sourcePath)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	info := parseReleaseName(filepath.Base(sourcePath))
+	output := QuickRenameOutput{Parsed: info, Reasons: releaseConfidenceIssues(info)}
+	output.Confident = len(output.Reasons) == 0
+
+	if output.Confident {
+		var targetBase, targetDir string
+		if info.IsEpisode {
+			showsFolder := os.Getenv("JELLYFIN_SHOWS_FOLDER")
+			showFolder := existingShowFolder(fsys, showsFolder, info.Title)
+			if showFolder == "" {
+				showFolder = info.Title
+			}
+			targetDir = filepath.Join(showsFolder, showFolder, fmt.Sprintf("Season %02d", info.Season))
+			targetBase = fmt.Sprintf("%s S%02dE%02d", info.Title, info.Season, info.Episode)
+		} else {
+			movieName := titleYearName(info.Title, info.Year)
+			targetDir = filepath.Join(os.Getenv("JELLYFIN_MOVIES_FOLDER"), movieName)
+			targetBase = movieName
+		}
+
+		files, err := sidecarFiles(fsys, sourcePath)
+		if err != nil {
+			return "", err
+		}
+		sourceBase := strings.TrimSuffix(filepath.Base(sourcePath), filepath.Ext(sourcePath))
+		for _, file := range files {
+			target := filepath.Join(targetDir, targetBase+strings.TrimPrefix(filepath.Base(file), sourceBase))
+			if _, err := fsys.Stat(target); err == nil {
+				output.Confident = false
+				output.Reasons = append(output.Reasons, "target already exists: "+target)
+			}
+			output.Moves = append(output.Moves, CrossLibraryMove{Source: file, Target: target})
+		}
+	}
+
+	if renameInput.Apply && output.Confident && !DryRun {
+		var tx transaction
+		for _, move := range output.Moves {
+			source, target := move.Source, move.Target
+			if err := moveMedia(source, target); err != nil {
+				return "", rollbackError(fmt.Errorf("failed to move %s: %v", source, err), tx.rollback())
+			}
+			tx.add("move of "+source, undoMove(source, target))
+		}
+		output.Applied = true
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if renameInput.Apply && DryRun {
+		return dryRunOutput(jsonData), nil
+	}
+
+	return string(jsonData), nil
+}
+
+// releaseConfidenceIssues lists the reasons a parse can't be trusted without
+// a metadata lookup. An empty list means the parse is confident
+func releaseConfidenceIssues(info ReleaseInfo) []string {
+	issues := []string{}
+	if info.Title == "" {
+		return append(issues, "no title could be parsed")
+	}
+	if suspiciousTitlePattern.MatchString(info.Title) {
+		issues = append(issues, fmt.Sprintf("title %q has leftovers that suggest a wrong parse", info.Title))
+	}
+	if !info.IsEpisode && info.Year == 0 {
+		issues = append(issues, "movie name has no year, the title may be ambiguous")
+	}
+	if info.IsEpisode && info.Episode == 0 {
+		issues = append(issues, "episode number is 0")
+	}
+	return issues
+}
+
+// existingShowFolder returns the name of the show folder in the library
+// that matches title exactly after normalization, if any
+func existingShowFolder(fsys MediaFS, showsFolder, title string) string {
+	entries, err := fsys.ReadDir(showsFolder)
+	if err != nil {
+		return ""
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	for _, collision := range findTitleCollisions(title, 0, names, 1) {
+		if collision.Kind == "exact" {
+			return collision.Path
+		}
+	}
+	return ""
+}
@@ -2,39 +2,60 @@ package tools
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
 	"strings"
 )
 
 type ReadFileInput struct {
-	Path  string `json:"path" jsonschema_description:"The file path to read. Can be absolute or relative path. File should not be an image or video."`
-	Bytes int    `json:"bytes" jsonschema_description:"Number of bytes to read from the start of the file. If 0, reads the entire file."`
+	Path    string `json:"path" jsonschema_description:"The file path to read. Can be absolute or relative path. File should not be an image or video."`
+	Type    string `json:"type" jsonschema_description:"Optional folder a relative path is resolved against: 'shows', 'movies' or 'source' (the incoming downloads in SOURCE_FOLDER)."`
+	Bytes   int    `json:"bytes" jsonschema_description:"Number of bytes to read. If 0, reads the rest of the file."`
+	Offset  int64  `json:"offset" jsonschema_description:"Byte offset from the start of the file to begin reading at. Defaults to 0."`
+	FromEnd bool   `json:"from_end" jsonschema_description:"If true, reads the last 'bytes' bytes of the file instead, e.g. to check the end of a log or container. Requires bytes and can't be combined with offset."`
 }
 
 var ReadFileInputSchema = GenerateSchema[ReadFileInput]()
 
 var ReadFileDefinition = ToolDefinition{
 	Name:        "read_file",
-	Description: "Read the contents of a file. Can read entire file or a specified number of bytes from the start. Access is restricted to files within JELLYFIN_SHOWS_FOLDER, JELLYFIN_MOVIES_FOLDER, or SOURCE_FOLDER.",
+	Description: "Read the contents of a file. Can read entire file, a specified number of bytes from the start or from an offset, or the last bytes of the file with from_end. Access is restricted to files within JELLYFIN_SHOWS_FOLDER, JELLYFIN_MOVIES_FOLDER, or SOURCE_FOLDER.",
 	InputSchema: ReadFileInputSchema,
-	Function:    ReadFile,
+	Structured:  func(input json.RawMessage) (any, error) { return ReadFile(input) },
 }
 
-func ReadFile(input json.RawMessage) (string, error) {
+type ReadFileResult struct {
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	Offset    int64  `json:"offset,omitempty"`
+	BytesRead int    `json:"bytes_read"`
+}
+
+func ReadFile(input json.RawMessage) (*ReadFileResult, error) {
 	readFileInput := ReadFileInput{}
 	err := json.Unmarshal(input, &readFileInput)
 	if err != nil {
-		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal input: %v", err)
 	}
 
-	filePath := readFileInput.Path
+	filePath, err := pathInFolder(readFileInput.Type, readFileInput.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if readFileInput.FromEnd && (readFileInput.Bytes <= 0 || readFileInput.Offset != 0) {
+		return nil, fmt.Errorf("from_end requires bytes and can't be combined with offset")
+	}
+	if readFileInput.Offset < 0 || readFileInput.Bytes < 0 {
+		return nil, fmt.Errorf("offset and bytes must not be negative")
+	}
 
 	// Validate that the path is within allowed directories
 	err = ValidatePath(filePath)
 	if err != nil {
-		return "", fmt.Errorf("access denied: %v", err)
+		return nil, fmt.Errorf("access denied: %v", err)
 	}
 
 	// Check if the file is an image or video
@@ -42,32 +63,54 @@ func ReadFile(input json.RawMessage) (string, error) {
 	imageVideoExts := []string{".jpg", ".jpeg", ".png", ".gif", ".bmp", ".webp", ".svg", ".tiff", ".tif", ".ico", ".mp4", ".avi", ".mkv", ".mov", ".wmv", ".flv", ".webm", ".m4v", ".3gp", ".ogv", ".vob", ".ts", ".mts", ".m2ts"}
 	for _, blockedExt := range imageVideoExts {
 		if ext == blockedExt {
-			return "", fmt.Errorf("cannot read image or video files: %s", filePath)
+			return nil, fmt.Errorf("cannot read image or video files: %s", filePath)
 		}
 	}
 
 	// Path validation is already done by ValidateJellyfinPath
 
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := fsys.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	offset := readFileInput.Offset
+	if readFileInput.FromEnd {
+		info, err := file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		offset = max(info.Size()-int64(readFileInput.Bytes), 0)
+	}
+	if offset > 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to seek to offset %d: %v", offset, err)
+		}
+	}
+
 	if readFileInput.Bytes == 0 {
 		// Read entire file
-		content, err := os.ReadFile(filePath)
+		content, err := io.ReadAll(file)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		return string(content), nil
+		return &ReadFileResult{Path: filePath, Content: string(content), Offset: offset, BytesRead: len(content)}, nil
 	} else {
 		// Read specified number of bytes
-		file, err := os.Open(filePath)
-		if err != nil {
-			return "", err
-		}
-		defer file.Close()
 
+		// A single Read may return less than asked for, ReadFull keeps
+		// reading until the buffer is full or the file ends
 		buffer := make([]byte, readFileInput.Bytes)
-		n, err := file.Read(buffer)
-		if err != nil && err.Error() != "EOF" {
-			return "", err
+		n, err := io.ReadFull(file, buffer)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, err
 		}
-		return string(buffer[:n]), nil
+		return &ReadFileResult{Path: filePath, Content: string(buffer[:n]), Offset: offset, BytesRead: n}, nil
 	}
 }
@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadFileResults(t *testing.T) {
+	library := setupTestLibrary(t)
+	nfo := filepath.Join(library, "Heat (1995)", "movie.nfo")
+	if err := os.MkdirAll(filepath.Dir(nfo), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(nfo, []byte("<movie><title>Heat</title></movie>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		input   ReadFileInput
+		want    ReadFileResult
+		wantErr string
+	}{
+		{
+			name:  "whole file",
+			input: ReadFileInput{Path: nfo},
+			want:  ReadFileResult{Path: nfo, Content: "<movie><title>Heat</title></movie>", BytesRead: 34},
+		},
+		{
+			name:  "bytes from an offset",
+			input: ReadFileInput{Path: "Heat (1995)/movie.nfo", Type: "movies", Offset: 7, Bytes: 7},
+			want:  ReadFileResult{Path: nfo, Content: "<title>", Offset: 7, BytesRead: 7},
+		},
+		{
+			name:  "end of the file",
+			input: ReadFileInput{Path: nfo, Bytes: 8, FromEnd: true},
+			want:  ReadFileResult{Path: nfo, Content: "</movie>", Offset: 26, BytesRead: 8},
+		},
+		{
+			name:  "more bytes than the file holds",
+			input: ReadFileInput{Path: nfo, Offset: 30, Bytes: 100},
+			want:  ReadFileResult{Path: nfo, Content: "vie>", Offset: 30, BytesRead: 4},
+		},
+		{
+			name:    "video file",
+			input:   ReadFileInput{Path: filepath.Join(library, "Heat (1995)", "Heat (1995).mkv")},
+			wantErr: "cannot read image or video files",
+		},
+		{
+			name:    "outside the library",
+			input:   ReadFileInput{Path: "/etc/passwd"},
+			wantErr: "access denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := json.Marshal(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Go callers get the typed result
+			got, err := ReadFile(input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadFile error = %v, want %q", err, tt.wantErr)
+				}
+				if _, err := ReadFileDefinition.Call(context.Background(), input); err == nil {
+					t.Errorf("Call succeeded, want %q", tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadFile: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ReadFile = %+v, want %+v", *got, tt.want)
+			}
+
+			// Claude gets the same result as JSON
+			text, err := ReadFileDefinition.Call(context.Background(), input)
+			if err != nil {
+				t.Fatalf("Call: %v", err)
+			}
+			var decoded ReadFileResult
+			if err := json.Unmarshal([]byte(text), &decoded); err != nil {
+				t.Fatalf("Call returned invalid JSON %q: %v", text, err)
+			}
+			if decoded != tt.want {
+				t.Errorf("Call = %s, want %+v", text, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ReleaseInfo is the metadata encoded in a scene or P2P release name
+type ReleaseInfo struct {
+	Title        string `json:"title"`
+	Year         int    `json:"year,omitempty"`
+	IsEpisode    bool   `json:"is_episode"`
+	Season       int    `json:"season,omitempty"`
+	Episode      int    `json:"episode,omitempty"`
+	Resolution   string `json:"resolution,omitempty"`
+	Source       string `json:"source,omitempty"`
+	Codec        string `json:"codec,omitempty"`
+	ReleaseGroup string `json:"release_group,omitempty"`
+}
+
+var (
+	// releaseYearPattern only matches the digits, so neighbouring years
+	// like 1917.2019 don't compete for the dot between them. The delimiters
+	// around a match are checked by releaseYears
+	releaseYearPattern       = regexp.MustCompile(`(?:19|20)\d{2}`)
+	releaseEpisodePattern    = regexp.MustCompile(`(?i)(?:^|[ ._\-])S(\d{1,3})[ ._\-]?E(\d{1,3})`)
+	releaseCrossPattern      = regexp.MustCompile(`(?i)(?:^|[ ._\-])(\d{1,2})x(\d{2,3})(?:$|[ ._\-])`)
+	releaseResolutionPattern = regexp.MustCompile(`(?i)(?:^|[ ._\-\[(])(2160p|1080p|1080i|720p|576p|480p|4k|uhd)(?:$|[ ._\-\])])`)
+
+	// releaseTagPattern matches tags that never belong to a title, so the
+	// title ends where the first of them after the first word starts
+	releaseTagPattern = regexp.MustCompile(`(?i)(?:^|[ ._\-\[(])(bluray|blu-ray|bdrip|brrip|web-?dl|webrip|web|hdtv|dvdrip|dvd|remux|hdrip|x264|x265|h\.?264|h\.?265|hevc|xvid|proper|repack|extended|unrated|remastered|imax)(?:$|[ ._\-\])])`)
+
+	releaseSourcePattern = regexp.MustCompile(`(?i)(?:^|[ ._\-\[(])(blu-?ray|bdrip|brrip|bdremux|remux|web-?dl|web-?rip|web|hdtv|dvdrip|dvd|hdrip)(?:$|[ ._\-\])])`)
+	releaseCodecPattern  = regexp.MustCompile(`(?i)(?:^|[ ._\-\[(])(x264|x265|h\.?264|h\.?265|hevc|avc|xvid|divx|av1|vp9)(?:$|[ ._\-\])])`)
+	releaseGroupPattern  = regexp.MustCompile(`-([A-Za-z0-9]+)(?:\[[^\]]*\])?$`)
+
+	// releaseSources normalizes the spellings of a release source
+	releaseSources = map[string]string{
+		"bluray": "BluRay", "blu-ray": "BluRay", "bdrip": "BDRip", "brrip": "BRRip",
+		"bdremux": "Remux", "remux": "Remux", "web-dl": "WEB-DL", "webdl": "WEB-DL",
+		"webrip": "WEBRip", "web-rip": "WEBRip", "web": "WEB", "hdtv": "HDTV",
+		"dvdrip": "DVDRip", "dvd": "DVD", "hdrip": "HDRip",
+	}
+
+	subtitleExtensions = []string{".srt", ".ass", ".ssa", ".sub", ".idx", ".vtt", ".sup"}
+)
+
+// parseReleaseName extracts the title, year, episode numbering, resolution,
+// source, codec and group from a release name like
+// Movie.Name.2009.1080p.BluRay.x264-GROUP.mkv
+func parseReleaseName(name string) ReleaseInfo {
+	ext := strings.ToLower(filepath.Ext(name))
+	if isVideoFile(name) || isSubtitleFile(name) || ext == ".nfo" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	info := ReleaseInfo{}
+	titleEnd := len(name)
+
+	if loc := releaseEpisodePattern.FindStringSubmatchIndex(name); loc != nil {
+		info.IsEpisode = true
+		info.Season, _ = strconv.Atoi(name[loc[2]:loc[3]])
+		info.Episode, _ = strconv.Atoi(name[loc[4]:loc[5]])
+		titleEnd = min(titleEnd, loc[0])
+	} else if loc := releaseCrossPattern.FindStringSubmatchIndex(name); loc != nil {
+		info.IsEpisode = true
+		info.Season, _ = strconv.Atoi(name[loc[2]:loc[3]])
+		info.Episode, _ = strconv.Atoi(name[loc[4]:loc[5]])
+		titleEnd = min(titleEnd, loc[0])
+	}
+
+	// The last year wins so titles like "2001 A Space Odyssey 1968" work. A
+	// lone number at the very start is part of the title, as in "1917"
+	years := releaseYears(name)
+	if len(years) > 0 {
+		loc := years[len(years)-1]
+		if loc[0] > 0 {
+			info.Year, _ = strconv.Atoi(name[loc[0]:loc[1]])
+			titleEnd = min(titleEnd, loc[0])
+		}
+	}
+
+	if loc := releaseTagIndex(releaseResolutionPattern, name); loc != nil {
+		info.Resolution = strings.ToLower(name[loc[2]:loc[3]])
+		if info.Resolution == "4k" || info.Resolution == "uhd" {
+			info.Resolution = "2160p"
+		}
+		titleEnd = min(titleEnd, loc[0])
+	}
+
+	if loc := releaseTagIndex(releaseTagPattern, name); loc != nil {
+		titleEnd = min(titleEnd, loc[0])
+	}
+
+	source := releaseTagIndex(releaseSourcePattern, name)
+	if source != nil {
+		info.Source = releaseSources[strings.ToLower(name[source[2]:source[3]])]
+	}
+	if loc := releaseTagIndex(releaseCodecPattern, name); loc != nil {
+		info.Codec = strings.ReplaceAll(strings.ToLower(name[loc[2]:loc[3]]), ".", "")
+	}
+
+	// The group closes the name after the release tags. A dash within the
+	// title, as in Spider-Man, is not a group, and neither is the one in a
+	// source like WEB-DL that ends the name
+	if loc := releaseGroupPattern.FindStringSubmatchIndex(name); loc != nil && loc[0] > titleEnd {
+		if source == nil || loc[0] < source[2] || loc[0] >= source[3] {
+			info.ReleaseGroup = name[loc[2]:loc[3]]
+		}
+	}
+
+	info.Title = cleanReleaseTitle(name[:titleEnd])
+	return info
+}
+
+// releaseYears returns where the plausible years in a name start and end.
+// A year must stand on its own between delimiters or the ends of the name
+func releaseYears(name string) [][]int {
+	var years [][]int
+	for _, loc := range releaseYearPattern.FindAllStringIndex(name, -1) {
+		if loc[0] > 0 && !strings.ContainsRune(" ._-([", rune(name[loc[0]-1])) {
+			continue
+		}
+		if loc[1] < len(name) && !strings.ContainsRune(" ._-)]", rune(name[loc[1]])) {
+			continue
+		}
+		years = append(years, loc)
+	}
+	return years
+}
+
+// releaseTagIndex returns the submatch indexes of the first match of a tag
+// pattern after the first word. The first word always belongs to the title,
+// as in Extended.Family.2020 or Web.Of.Lies.2019
+func releaseTagIndex(pattern *regexp.Regexp, name string) []int {
+	// Each search resumes right after the skipped word, before the delimiter
+	// its match consumed
+	offset := 0
+	for offset < len(name) {
+		loc := pattern.FindStringSubmatchIndex(name[offset:])
+		if loc == nil {
+			return nil
+		}
+		for i := range loc {
+			loc[i] += offset
+		}
+		if loc[2] > 0 {
+			return loc
+		}
+		offset = loc[3]
+	}
+	return nil
+}
+
+// cleanReleaseTitle turns separators into spaces and drops leftover brackets
+// and dashes around the title
+func cleanReleaseTitle(title string) string {
+	title = providerIDTagPattern.ReplaceAllString(title, " ")
+	title = strings.NewReplacer(".", " ", "_", " ").Replace(title)
+	title = strings.Join(strings.Fields(title), " ")
+	return strings.Trim(title, " -([{")
+}
+
+// isSubtitleFile reports whether a file name has a known subtitle extension
+func isSubtitleFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, subtitleExt := range subtitleExtensions {
+		if ext == subtitleExt {
+			return true
+		}
+	}
+	return false
+}
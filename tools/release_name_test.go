@@ -0,0 +1,75 @@
+package tools
+
+import "testing"
+
+func TestParseReleaseName(t *testing.T) {
+	tests := []struct {
+		name string
+		want ReleaseInfo
+	}{
+		{
+			name: "Movie.Name.2009.1080p.BluRay.x264-GROUP.mkv",
+			want: ReleaseInfo{Title: "Movie Name", Year: 2009, Resolution: "1080p", Source: "BluRay", Codec: "x264", ReleaseGroup: "GROUP"},
+		},
+		{
+			name: "1917.2019.1080p.BluRay.x264-GRP",
+			want: ReleaseInfo{Title: "1917", Year: 2019, Resolution: "1080p", Source: "BluRay", Codec: "x264", ReleaseGroup: "GRP"},
+		},
+		{
+			name: "1917 (2019)",
+			want: ReleaseInfo{Title: "1917", Year: 2019},
+		},
+		{
+			name: "2001.A.Space.Odyssey.1968.REMASTERED.mkv",
+			want: ReleaseInfo{Title: "2001 A Space Odyssey", Year: 1968},
+		},
+		{
+			name: "Extended.Family.2020.720p.WEB.h264",
+			want: ReleaseInfo{Title: "Extended Family", Year: 2020, Resolution: "720p", Source: "WEB", Codec: "h264"},
+		},
+		{
+			name: "Web.Of.Lies.2019.HDTV",
+			want: ReleaseInfo{Title: "Web Of Lies", Year: 2019, Source: "HDTV"},
+		},
+		{
+			name: "Remux.Remastered.2019",
+			want: ReleaseInfo{Title: "Remux", Year: 2019, Source: ""},
+		},
+		{
+			name: "Show.Name.S01E02.720p.HDTV.x264.mkv",
+			want: ReleaseInfo{Title: "Show Name", IsEpisode: true, Season: 1, Episode: 2, Resolution: "720p", Source: "HDTV", Codec: "x264"},
+		},
+		{
+			name: "Show Name - 2x05 - Title.mkv",
+			want: ReleaseInfo{Title: "Show Name", IsEpisode: true, Season: 2, Episode: 5},
+		},
+		{
+			name: "Spider-Man.No.Way.Home.2021.2160p.WEB-DL",
+			want: ReleaseInfo{Title: "Spider-Man No Way Home", Year: 2021, Resolution: "2160p", Source: "WEB-DL"},
+		},
+		{
+			name: "Spider-Man.No.Way.Home.2021.2160p.WEB-DL-GRP",
+			want: ReleaseInfo{Title: "Spider-Man No Way Home", Year: 2021, Resolution: "2160p", Source: "WEB-DL", ReleaseGroup: "GRP"},
+		},
+		{
+			name: "Movie.2020.1080p.Blu-Ray",
+			want: ReleaseInfo{Title: "Movie", Year: 2020, Resolution: "1080p", Source: "BluRay"},
+		},
+		{
+			name: "Movie.2020.720p.WEB-Rip.x264-GRP[rarbg]",
+			want: ReleaseInfo{Title: "Movie", Year: 2020, Resolution: "720p", Source: "WEBRip", Codec: "x264", ReleaseGroup: "GRP"},
+		},
+		{
+			name: "Movie.12019.mkv",
+			want: ReleaseInfo{Title: "Movie 12019"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseReleaseName(tt.name); got != tt.want {
+				t.Errorf("parseReleaseName(%q) =\n%+v, want\n%+v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
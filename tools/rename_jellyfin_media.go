@@ -1,27 +1,31 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type RenameJellyfinMediaInput struct {
-	SourcePath string `json:"source_path" jsonschema_description:"The source file or folder path to move/rename. Must be within Jellyfin media directories."`
-	TargetPath string `json:"target_path" jsonschema_description:"The target file or folder path. Must be within Jellyfin media directories."`
+	SourcePath   string `json:"source_path" jsonschema_description:"The source file or folder path to move/rename. Must be within SOURCE_FOLDER or the Jellyfin media directories."`
+	TargetPath   string `json:"target_path" jsonschema_description:"The target file or folder path. Must be within JELLYFIN_SHOWS_FOLDER or JELLYFIN_MOVIES_FOLDER."`
+	Overwrite    bool   `json:"overwrite" jsonschema_description:"If true, an existing target is replaced, e.g. when re-running over a half-organized folder where a file was already placed. A file can replace a file or an empty folder, and a folder can replace an empty folder. Defaults to false, which fails when the target exists."`
+	MoveSidecars bool   `json:"move_sidecars" jsonschema_description:"If true and the source is a video file, files next to it sharing its base name, like subtitles (movie.srt, movie.en.srt, movie.ass) and nfo files, are moved along and renamed to the target's base name. Defaults to false."`
 }
 
 var RenameJellyfinMediaInputSchema = GenerateSchema[RenameJellyfinMediaInput]()
 
 var RenameJellyfinMediaDefinition = ToolDefinition{
 	Name:        "rename_jellyfin_media",
-	Description: "Move or rename files and folders within Jellyfin media directories. Both source and target paths must be within JELLYFIN_SHOWS_FOLDER or JELLYFIN_MOVIES_FOLDER. Works like 'mv' command but restricted to Jellyfin media folders.",
+	Description: "Move or rename files and folders into or within the Jellyfin media directories. The source may be in SOURCE_FOLDER or a library, the target must be within JELLYFIN_SHOWS_FOLDER or JELLYFIN_MOVIES_FOLDER. Works like 'mv' command but restricted to Jellyfin media folders.",
 	InputSchema: RenameJellyfinMediaInputSchema,
-	Function:    RenameJellyfinMedia,
+	Contextual:  RenameJellyfinMedia,
 }
 
-func RenameJellyfinMedia(input json.RawMessage) (string, error) {
+func RenameJellyfinMedia(ctx context.Context, input json.RawMessage) (string, error) {
 	renameInput := RenameJellyfinMediaInput{}
 	err := json.Unmarshal(input, &renameInput)
 	if err != nil {
@@ -29,40 +33,227 @@ func RenameJellyfinMedia(input json.RawMessage) (string, error) {
 	}
 
 	sourcePath := renameInput.SourcePath
-	targetPath := renameInput.TargetPath
+	targetPath, err := sanitizePath(renameInput.TargetPath)
+	if err != nil {
+		return "", err
+	}
+
+	// Moves import into the libraries, never into the downloads folder
+	if err := ValidateLibraryPath(targetPath); err != nil {
+		return "", fmt.Errorf("invalid target path: %v", err)
+	}
+
+	// Re-runs often ask to move files that are already organized, which is
+	// not worth an error
+	if alreadyInPlace(sourcePath, targetPath) {
+		return fmt.Sprintf("%s is already in place, nothing was moved", targetPath), nil
+	}
+
+	var sidecars []CrossLibraryMove
+	if renameInput.MoveSidecars && isVideoFile(sourcePath) {
+		sidecars, err = sidecarMoves(sourcePath, targetPath, renameInput.Overwrite)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	move, err := moveMediaReplacing(ctx, sourcePath, targetPath, renameInput.Overwrite)
+	if err != nil {
+		return "", err
+	}
+
+	// A sidecar that can't be moved takes the video back with it, so the
+	// video never ends up without its subtitles
+	var tx transaction
+	tx.add("move of "+sourcePath, undoMove(sourcePath, targetPath))
+	var moved []string
+	for _, sidecar := range sidecars {
+		if _, err := moveMediaReplacing(ctx, sidecar.Source, sidecar.Target, renameInput.Overwrite); err != nil {
+			return "", rollbackError(fmt.Errorf("failed to move the sidecar %s: %v", sidecar.Source, err), tx.rollback())
+		}
+		tx.add("move of "+sidecar.Source, undoMove(sidecar.Source, sidecar.Target))
+		moved = append(moved, filepath.Base(sidecar.Target))
+	}
+
+	var message string
+	switch {
+	case DryRun:
+		message = fmt.Sprintf("%swould move %s to %s", dryRunPrefix, sourcePath, targetPath)
+	case move.Copied:
+		message = fmt.Sprintf("Successfully moved %s to %s with a slow copy and delete, as they are on different devices. Transferred %d files, %d bytes", sourcePath, targetPath, move.Files, move.Bytes)
+	default:
+		message = fmt.Sprintf("Successfully moved/renamed %s to %s with a fast rename", sourcePath, targetPath)
+	}
+	switch {
+	case len(moved) > 0 && DryRun:
+		message += fmt.Sprintf(". Sidecars that would move along: %s", strings.Join(moved, ", "))
+	case len(moved) > 0:
+		message += fmt.Sprintf(". Sidecars moved along: %s", strings.Join(moved, ", "))
+	}
+	if targetPath != renameInput.TargetPath {
+		message += fmt.Sprintf(". The target name was sanitized, it is %s", filepath.Base(targetPath))
+	}
+	return message, nil
+}
+
+// alreadyInPlace reports whether source and target resolve to the same
+// existing path
+func alreadyInPlace(sourcePath, targetPath string) bool {
+	source, err := ResolvePath(sourcePath)
+	if err != nil {
+		return false
+	}
+	target, err := ResolvePath(targetPath)
+	if err != nil || source != target {
+		return false
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return false
+	}
+	_, err = fsys.Stat(source)
+	return err == nil
+}
+
+// sidecarMoves pairs the sidecars of a video file with their targets next to
+// targetPath. The base name is swapped and the rest kept, so movie.en.srt
+// becomes "Movie (2009).en.srt". All targets are checked up front, so a
+// clash doesn't leave the video moved without its subtitles
+func sidecarMoves(sourcePath, targetPath string, overwrite bool) ([]CrossLibraryMove, error) {
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return nil, err
+	}
+	files, err := sidecarFiles(fsys, sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sidecar files: %v", err)
+	}
+
+	sourceBase := strings.TrimSuffix(filepath.Base(sourcePath), filepath.Ext(sourcePath))
+	targetBase := strings.TrimSuffix(targetPath, filepath.Ext(targetPath))
+	var moves []CrossLibraryMove
+	for _, file := range files[1:] {
+		target := targetBase + strings.TrimPrefix(filepath.Base(file), sourceBase)
+		if err := ValidateLibraryPath(target); err != nil {
+			return nil, fmt.Errorf("invalid sidecar target path: %v", err)
+		}
+		if _, err := fsys.Stat(target); err == nil && !overwrite {
+			return nil, fmt.Errorf("sidecar target path already exists: %s", target)
+		}
+		moves = append(moves, CrossLibraryMove{Source: file, Target: target})
+	}
+	return moves, nil
+}
+
+// moveMedia validates both paths and moves sourcePath to targetPath,
+// creating the target's parent directory when needed. In dry-run mode it
+// stops after the checks. It can't be cancelled
+func moveMedia(sourcePath, targetPath string) error {
+	_, err := moveMediaReplacing(context.Background(), sourcePath, targetPath, false)
+	return err
+}
+
+// moveMediaReplacing is moveMedia that, when overwrite is set, replaces an
+// existing target instead of failing. Files on another device are copied and
+// deleted instead of renamed, which cancelling ctx aborts
+func moveMediaReplacing(ctx context.Context, sourcePath, targetPath string, overwrite bool) (mediaMove, error) {
+	move, err := relocateMedia(ctx, sourcePath, targetPath, overwrite)
+	if err == nil && !DryRun {
+		recordOperation("move", sourcePath, targetPath)
+	}
+	return move, err
+}
 
+// relocateMedia does the work of moveMediaReplacing without recording the
+// move in the operation log
+func relocateMedia(ctx context.Context, sourcePath, targetPath string, overwrite bool) (mediaMove, error) {
 	// Validate both source and target paths are within Jellyfin directories
-	err = ValidatePath(renameInput.SourcePath)
+	err := ValidatePath(sourcePath)
 	if err != nil {
-		return "", fmt.Errorf("invalid source path: %v", err)
+		return mediaMove{}, fmt.Errorf("invalid source path: %v", err)
 	}
 
-	err = ValidatePath(renameInput.TargetPath)
+	err = ValidatePath(targetPath)
 	if err != nil {
-		return "", fmt.Errorf("invalid target path: %v", err)
+		return mediaMove{}, fmt.Errorf("invalid target path: %v", err)
 	}
 
-	// Check if source exists
-	if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
-		return "", fmt.Errorf("source path does not exist: %s", sourcePath)
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return mediaMove{}, err
 	}
 
-	// Create target directory if it doesn't exist (for the parent directory)
-	targetDir := filepath.Dir(targetPath)
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create target directory: %v", err)
+	// Check if source exists
+	sourceInfo, err := fsys.Stat(sourcePath)
+	if os.IsNotExist(err) {
+		return mediaMove{}, fmt.Errorf("source path does not exist: %s", sourcePath)
+	} else if err != nil {
+		return mediaMove{}, fmt.Errorf("failed to read source path: %v", err)
 	}
 
 	// Check if target already exists
-	if _, err := os.Stat(targetPath); err == nil {
-		return "", fmt.Errorf("target path already exists: %s", targetPath)
+	targetInfo, err := fsys.Stat(targetPath)
+	replaceDir := false
+	if err == nil {
+		if !overwrite {
+			return mediaMove{}, fmt.Errorf("target path already exists: %s", targetPath)
+		}
+		if replaceDir, err = checkReplaceable(fsys, sourceInfo, targetInfo, targetPath); err != nil {
+			return mediaMove{}, err
+		}
+	}
+
+	if DryRun {
+		return mediaMove{}, nil
+	}
+
+	// Files are replaced atomically by the rename itself, an empty folder
+	// has to be removed first
+	if replaceDir {
+		if err := fsys.Remove(targetPath); err != nil {
+			return mediaMove{}, fmt.Errorf("failed to remove the existing target folder: %v", err)
+		}
+	}
+
+	// Create target directory if it doesn't exist (for the parent directory)
+	targetDir := filepath.Dir(targetPath)
+	if err := fsys.MkdirAll(targetDir, 0755); err != nil {
+		return mediaMove{}, fmt.Errorf("failed to create target directory: %v", err)
 	}
 
 	// Perform the move/rename operation
-	err = os.Rename(sourcePath, targetPath)
+	var move mediaMove
+	err = fsys.Rename(sourcePath, targetPath)
+	if isCrossDevice(err) {
+		move, err = moveAcrossDevices(ctx, fsys, sourcePath, sourceInfo, targetPath)
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to move/rename: %v", err)
+		return mediaMove{}, fmt.Errorf("failed to move/rename: %v", err)
 	}
 
-	return fmt.Sprintf("Successfully moved/renamed %s to %s", sourcePath, targetPath), nil
+	return move, nil
+}
+
+// checkReplaceable reports whether an existing target may be overwritten by
+// the source, and whether it is an empty folder that must be removed first
+func checkReplaceable(fsys MediaFS, sourceInfo, targetInfo os.FileInfo, targetPath string) (bool, error) {
+	if !targetInfo.IsDir() {
+		if sourceInfo.IsDir() {
+			return false, fmt.Errorf("cannot overwrite the file %s with a folder", targetPath)
+		}
+		return false, nil
+	}
+
+	entries, err := fsys.ReadDir(targetPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read the existing target folder: %v", err)
+	}
+	if len(entries) > 0 {
+		if sourceInfo.IsDir() {
+			return false, fmt.Errorf("cannot overwrite the non-empty folder %s, merge its contents instead", targetPath)
+		}
+		return false, fmt.Errorf("cannot overwrite the non-empty folder %s with a file", targetPath)
+	}
+	return true, nil
 }
@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func renameInput(t *testing.T, input RenameJellyfinMediaInput) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestRenameJellyfinMediaSidecars(t *testing.T) {
+	tests := []struct {
+		name        string
+		dryRun      bool
+		blockSubs   bool
+		wantErr     bool
+		wantMessage string
+		wantMoved   bool
+	}{
+		{name: "moves sidecars", wantMessage: "Sidecars moved along: Movie (2009).en.srt", wantMoved: true},
+		{name: "dry-run", dryRun: true, wantMessage: "Sidecars that would move along: Movie (2009).en.srt"},
+		{name: "failed sidecar rolls the video back", blockSubs: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			library := setupTestLibrary(t)
+			video := filepath.Join(library, "downloads", "movie.mkv")
+			subtitle := filepath.Join(library, "downloads", "movie.en.srt")
+			target := filepath.Join(library, "Movie (2009)", "Movie (2009).mkv")
+			writeTestFile(t, video)
+			writeTestFile(t, subtitle)
+			if tt.blockSubs {
+				// A non-empty folder can't be replaced by the subtitle
+				writeTestFile(t, filepath.Join(library, "Movie (2009)", "Movie (2009).en.srt", "file"))
+			}
+
+			dryRun := DryRun
+			DryRun = tt.dryRun
+			defer func() { DryRun = dryRun }()
+
+			message, err := RenameJellyfinMedia(context.Background(), renameInput(t, RenameJellyfinMediaInput{
+				SourcePath:   video,
+				TargetPath:   target,
+				Overwrite:    tt.blockSubs,
+				MoveSidecars: true,
+			}))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !strings.Contains(message, tt.wantMessage) {
+				t.Errorf("message %q doesn't contain %q", message, tt.wantMessage)
+			}
+
+			_, videoErr := os.Stat(video)
+			_, subtitleErr := os.Stat(subtitle)
+			if tt.wantMoved != (videoErr != nil) || tt.wantMoved != (subtitleErr != nil) {
+				t.Errorf("video or subtitle moved = %v/%v, want %v", videoErr != nil, subtitleErr != nil, tt.wantMoved)
+			}
+		})
+	}
+}
+
+func TestRenameJellyfinMediaAlreadyInPlace(t *testing.T) {
+	library := setupTestLibrary(t)
+	path := filepath.Join(library, "Movie (2009)", "Movie (2009).mkv")
+	writeTestFile(t, path)
+
+	message, err := RenameJellyfinMedia(context.Background(), renameInput(t, RenameJellyfinMediaInput{
+		SourcePath: path,
+		TargetPath: filepath.Join(library, ".", "Movie (2009)", "Movie (2009).mkv"),
+	}))
+	if err != nil || !strings.Contains(message, "already in place") {
+		t.Errorf("got %q, %v, want an already in place result", message, err)
+	}
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type SanitizeFilenameInput struct {
+	Name  string `json:"name" jsonschema_description:"The file or folder name to clean up, e.g. 'Mission: Impossible (1996)'. Only a single name, not a path."`
+	Rules string `json:"rules" jsonschema_description:"'windows' for names that must work on Windows and SMB shares, 'posix' for Linux and macOS file systems. If empty, FILENAME_RULES is used, defaulting to windows."`
+}
+
+var SanitizeFilenameInputSchema = GenerateSchema[SanitizeFilenameInput]()
+
+var SanitizeFilenameDefinition = ToolDefinition{
+	Name:        "sanitize_filename",
+	Description: "Turn a proposed file or folder name into one that is safe on the library's file system: characters like : ? * are replaced or removed, whitespace is collapsed and trailing dots and spaces are trimmed. rename_jellyfin_media and copy_file already apply this to the last component of their target, use this tool to preview the name.",
+	InputSchema: SanitizeFilenameInputSchema,
+	Function:    SanitizeFilename,
+}
+
+var (
+	// windowsReplacer keeps names readable, "Mission: Impossible" becomes
+	// "Mission - Impossible" rather than "Mission Impossible"
+	windowsReplacer = strings.NewReplacer(
+		":", " - ", "/", "-", `\`, "-", "|", "-",
+		"?", "", "*", "", `"`, "'", "<", "", ">", "",
+	)
+	posixReplacer = strings.NewReplacer("/", "-", "\x00", "")
+
+	controlCharPattern  = regexp.MustCompile(`[\x00-\x1f\x7f]`)
+	windowsReservedName = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[1-9]|lpt[1-9])(\..*)?$`)
+)
+
+func SanitizeFilename(input json.RawMessage) (string, error) {
+	sanitizeInput := SanitizeFilenameInput{}
+	err := json.Unmarshal(input, &sanitizeInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	rules, err := filenameRules(sanitizeInput.Rules)
+	if err != nil {
+		return "", err
+	}
+
+	name := sanitizeFilename(sanitizeInput.Name, rules)
+	if name == "" {
+		return "", fmt.Errorf("nothing is left of %q once sanitized", sanitizeInput.Name)
+	}
+	return name, nil
+}
+
+// filenameRules returns the naming rules to apply, rules or FILENAME_RULES,
+// defaulting to windows as libraries are often served from SMB shares
+func filenameRules(rules string) (string, error) {
+	if rules == "" {
+		rules = os.Getenv("FILENAME_RULES")
+	}
+	switch rules {
+	case "":
+		return "windows", nil
+	case "windows", "posix":
+		return rules, nil
+	}
+	return "", fmt.Errorf("filename rules must be 'windows' or 'posix', got %q", rules)
+}
+
+// sanitizeFilename makes a single name safe under the given rules, collapsing
+// whitespace and trimming it
+func sanitizeFilename(name, rules string) string {
+	name = controlCharPattern.ReplaceAllString(name, " ")
+	if rules == "windows" {
+		name = windowsReplacer.Replace(name)
+	} else {
+		name = posixReplacer.Replace(name)
+	}
+	name = strings.Join(strings.Fields(name), " ")
+
+	if rules == "windows" {
+		// Windows drops trailing dots and spaces, so "Movie." and "Movie"
+		// would clash
+		name = strings.TrimRight(name, ". ")
+		if windowsReservedName.MatchString(name) {
+			name = "_" + name
+		}
+	}
+	return name
+}
+
+// sanitizePath sanitizes the last component of path with the configured
+// rules, leaving the folders above it alone
+func sanitizePath(path string) (string, error) {
+	rules, err := filenameRules("")
+	if err != nil {
+		return "", err
+	}
+	name := sanitizeFilename(filepath.Base(path), rules)
+	if name == "" {
+		return "", fmt.Errorf("nothing is left of the name of %s once sanitized", path)
+	}
+	return filepath.Join(filepath.Dir(path), name), nil
+}
@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Names used for in-flight work. Anything still carrying them after a run
+// is a leftover of an interrupted operation
+const (
+	partialSuffix    = ".partial"
+	tempRenameSuffix = ".ojm-tmp"
+	throughputPrefix = ".ojm-throughput-"
+	trashDirName     = ".trash"
+	trashSuffix      = ".trash"
+)
+
+type ScanInterruptedRunsInput struct {
+	MinAgeMinutes int `json:"min_age_minutes" jsonschema_description:"Only report artifacts at least this old, to leave operations still in progress alone. If 0, everything is reported."`
+}
+
+var ScanInterruptedRunsInputSchema = GenerateSchema[ScanInterruptedRunsInput]()
+
+var ScanInterruptedRunsDefinition = ToolDefinition{
+	Name:        "scan_interrupted_runs",
+	Description: "Scan the shows, movies and source folders for leftovers of interrupted runs: partial copies (*.partial), trash items (.trash folders or *.trash files), temporary rename names (*.ojm-tmp) and throughput test files. Reports each with its kind, size and age so the user can decide to clean up or resume.",
+	InputSchema: ScanInterruptedRunsInputSchema,
+	Function:    ScanInterruptedRuns,
+}
+
+type RunArtifact struct {
+	Path     string    `json:"path"`
+	Kind     string    `json:"kind"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Age      string    `json:"age"`
+}
+
+type ScanInterruptedRunsOutput struct {
+	Artifacts []RunArtifact  `json:"artifacts"`
+	Counts    map[string]int `json:"counts"`
+}
+
+func ScanInterruptedRuns(input json.RawMessage) (string, error) {
+	scanInput := ScanInterruptedRunsInput{}
+	err := json.Unmarshal(input, &scanInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	minAge := time.Duration(scanInput.MinAgeMinutes) * time.Minute
+	now := time.Now()
+	output := ScanInterruptedRunsOutput{Artifacts: []RunArtifact{}, Counts: map[string]int{}}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	seen := map[string]bool{}
+	for _, root := range []string{os.Getenv("JELLYFIN_SHOWS_FOLDER"), os.Getenv("JELLYFIN_MOVIES_FOLDER"), os.Getenv("SOURCE_FOLDER")} {
+		if root == "" || seen[root] {
+			continue
+		}
+		seen[root] = true
+
+		err := walkMedia(fsys, root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			kind := classifyArtifact(info.Name(), info.IsDir())
+			if kind == "" {
+				return nil
+			}
+
+			size := info.Size()
+			if info.IsDir() {
+				size = treeSize(fsys, path)
+			}
+
+			age := now.Sub(info.ModTime())
+			if age >= minAge {
+				output.Artifacts = append(output.Artifacts, RunArtifact{
+					Path:     path,
+					Kind:     kind,
+					Size:     size,
+					Modified: info.ModTime(),
+					Age:      age.Round(time.Second).String(),
+				})
+				output.Counts[kind]++
+			}
+
+			// A trash folder is reported as a whole
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	sort.Slice(output.Artifacts, func(i, j int) bool { return output.Artifacts[i].Path < output.Artifacts[j].Path })
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// classifyArtifact returns the kind of leftover a name is, or "" for
+// regular files and folders
+func classifyArtifact(name string, isDir bool) string {
+	switch {
+	case isDir && name == trashDirName, strings.HasSuffix(name, trashSuffix):
+		return "trash"
+	case strings.HasSuffix(name, partialSuffix):
+		return "partial_copy"
+	case strings.HasSuffix(name, tempRenameSuffix):
+		return "temp_rename"
+	case !isDir && strings.HasPrefix(name, throughputPrefix):
+		return "throughput_test"
+	}
+	return ""
+}
+
+// treeSize returns the total size of the files below a folder
+func treeSize(fsys MediaFS, root string) int64 {
+	var total int64
+	walkMedia(fsys, root, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
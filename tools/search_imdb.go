@@ -1,27 +1,129 @@
 package tools
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
 )
 
 type SearchIMDbInput struct {
 	SearchTerm string `json:"search_term" jsonschema_description:"The search term to look for on IMDb."`
+	MaxResults int    `json:"max_results" jsonschema_description:"The maximum number of results to return. Defaults to 10."`
 }
 
 var SearchIMDbInputSchema = GenerateSchema[SearchIMDbInput]()
 
 var SearchIMDbDefinition = ToolDefinition{
 	Name:        "search_imdb",
-	Description: "Search for a term on IMDB. You should only use this tool ONCE. Results will have the movie/show name, the year and the director or cast. Text might be glued together. Take ONE guess to figure out which show you're looking for and get the id in ONE shot. The important thing is to get the id",
+	Description: "Search for a term on IMDB. You should only use this tool ONCE. Returns up to max_results results, flagged as truncated when there were more. Results have the title, the year, the type (movie, series, episode or another kind like tv special) and the IMDb id. Take ONE guess to figure out which show you're looking for and get the id in ONE shot. The important thing is to get the id",
 	InputSchema: SearchIMDbInputSchema,
 	Function:    SearchIMDb,
 }
 
+type IMDbResult struct {
+	Title string `json:"title"`
+	Year  int    `json:"year,omitempty"`
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+}
+
+// SearchIMDbOutput flags truncated results so Claude knows a more specific
+// query could find other matches
+type SearchIMDbOutput struct {
+	Results   []IMDbResult `json:"results"`
+	Truncated bool         `json:"truncated,omitempty"`
+	Note      string       `json:"note,omitempty"`
+}
+
+const defaultIMDbMaxResults = 10
+
+const defaultIMDbCacheTTL = 300
+
+// imdbCache keeps search results for IMDB_CACHE_TTL_SECONDS, as the same
+// show is often searched several times in one session
+var imdbCache = struct {
+	sync.Mutex
+	entries map[string]imdbCacheEntry
+}{entries: map[string]imdbCacheEntry{}}
+
+type imdbCacheEntry struct {
+	result  string
+	expires time.Time
+}
+
+// imdbCacheKey normalizes case and spacing of the search term, so trivially
+// different searches share an entry
+func imdbCacheKey(term string, maxResults int) string {
+	return strings.Join(strings.Fields(strings.ToLower(term)), " ") + "|" + strconv.Itoa(maxResults)
+}
+
+func cachedIMDbSearch(key string) (string, bool) {
+	imdbCache.Lock()
+	defer imdbCache.Unlock()
+
+	entry, ok := imdbCache.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		delete(imdbCache.entries, key)
+		return "", false
+	}
+	return entry.result, true
+}
+
+func cacheIMDbSearch(key, result string) {
+	ttl := envInt("IMDB_CACHE_TTL_SECONDS", defaultIMDbCacheTTL)
+	if ttl <= 0 {
+		return
+	}
+
+	imdbCache.Lock()
+	defer imdbCache.Unlock()
+	imdbCache.entries[key] = imdbCacheEntry{result: result, expires: time.Now().Add(time.Duration(ttl) * time.Second)}
+}
+
+const (
+	imdbRequestDelay   = 1 * time.Second
+	imdbRandomDelay    = 1 * time.Second
+	imdbRequestTimeout = 20 * time.Second
+)
+
+var (
+	imdbBaseCollector     *colly.Collector
+	imdbBaseCollectorOnce sync.Once
+)
+
+// imdbCollector returns a collector for one search. Clones share the HTTP
+// backend of a single base collector, so the per-domain delay spaces out
+// searches across the whole session and not just within one search
+func imdbCollector() *colly.Collector {
+	imdbBaseCollectorOnce.Do(func() {
+		imdbBaseCollector = colly.NewCollector(
+			colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"),
+			colly.AllowURLRevisit(),
+		)
+		imdbBaseCollector.WithTransport(networkTransport)
+		imdbBaseCollector.SetRequestTimeout(imdbRequestTimeout)
+		imdbBaseCollector.Limit(&colly.LimitRule{
+			DomainGlob:  "*imdb.com",
+			Delay:       imdbRequestDelay,
+			RandomDelay: imdbRandomDelay,
+		})
+	})
+	return imdbBaseCollector.Clone()
+}
+
+var imdbYearPattern = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+
 func SearchIMDb(input json.RawMessage) (string, error) {
 	searchInput := SearchIMDbInput{}
 	err := json.Unmarshal(input, &searchInput)
@@ -29,56 +131,147 @@ func SearchIMDb(input json.RawMessage) (string, error) {
 		return "", err
 	}
 
-	c := colly.NewCollector(
-		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"),
-	)
-
-	type IMDbResult struct {
-		RawResult string `json:"rawResult"`
-		ID        string `json:"id"`
+	maxResults := searchInput.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultIMDbMaxResults
 	}
 
-	var results []IMDbResult
-
-	// Scrape each search result
-	c.OnHTML(".ipc-metadata-list-summary-item__tc", func(e *colly.HTMLElement) {
-		textContent := e.DOM.Text()
+	cacheKey := imdbCacheKey(searchInput.SearchTerm, maxResults)
+	if cached, ok := cachedIMDbSearch(cacheKey); ok {
+		return cached, nil
+	}
 
-		// Get the ID from the first child anchor tag
-		href := e.ChildAttr("a", "href")
+	c := imdbCollector()
 
-		// Extract ID from href like "/title/tt4955642/?ref_=fn_all_ttl_1"
-		var id string
-		if href != "" {
-			parts := strings.Split(href, "/")
-			if len(parts) > 2 {
-				// Get the third part and remove query parameters
-				idPart := strings.Split(parts[2], "?")[0]
-				id = idPart
-			}
+	// A block answers 403 or 429, which must not read as "no results"
+	blockedStatus := 0
+	c.OnError(func(r *colly.Response, err error) {
+		if r.StatusCode == http.StatusForbidden || r.StatusCode == http.StatusTooManyRequests {
+			blockedStatus = r.StatusCode
 		}
+	})
 
-		results = append(results, IMDbResult{
-			RawResult: textContent,
-			ID:        id,
-		})
+	// The page is parsed once fetched, so the parsing doesn't depend on colly
+	var page imdbSearchPage
+	var parseErr error
+	c.OnResponse(func(r *colly.Response) {
+		page, parseErr = parseIMDbSearchPage(bytes.NewReader(r.Body))
 	})
 
 	// Construct IMDB search URL
 	searchURL := fmt.Sprintf("https://www.imdb.com/find/?q=%s&ref_=nv_sr_sm", url.QueryEscape(searchInput.SearchTerm))
 
 	err = c.Visit(searchURL)
+	if blockedStatus != 0 {
+		return "", fmt.Errorf("IMDB refused the search with HTTP %d, it is likely rate limiting this IP. This does not mean the title doesn't exist, wait a while before searching IMDB again or use search_tmdb instead", blockedStatus)
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to scrape IMDB: %w", err)
 	}
+	if parseErr != nil {
+		return "", fmt.Errorf("failed to parse the IMDB search page: %w", parseErr)
+	}
+
+	// A page with neither results nor IMDb's own "no results" notice means
+	// the scraper no longer understands the page, not that nothing matched
+	results := page.Results
+	if len(results) == 0 && !page.NoResultsNotice {
+		return "", fmt.Errorf("the IMDB search page loaded but no results could be read from it and it doesn't say that nothing was found. The page layout probably changed or the request was blocked, so this does not mean the title doesn't exist. Use search_tmdb instead")
+	}
+
+	output := SearchIMDbOutput{Results: results}
+	if len(results) > maxResults {
+		output.Results = results[:maxResults]
+		output.Truncated = true
+	}
+	if len(results) == 0 {
+		output.Note = "IMDB found no titles matching this search"
+	}
 
 	// Convert results to JSON
-	jsonData, err := json.Marshal(results)
+	jsonData, err := json.Marshal(output)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal results: %w", err)
 	}
 
 	fmt.Printf("found :%+v\n", string(jsonData))
+	cacheIMDbSearch(cacheKey, string(jsonData))
 
 	return string(jsonData), nil
 }
+
+// imdbSearchPage is what was read from an IMDb search page.
+// NoResultsNotice is set when the page says that nothing matched
+type imdbSearchPage struct {
+	Results         []IMDbResult
+	NoResultsNotice bool
+}
+
+var imdbNoResultsPattern = regexp.MustCompile(`(?i)no results found for`)
+
+// parseIMDbSearchPage extracts the results from the HTML of an IMDb search
+// page, wherever it came from
+func parseIMDbSearchPage(r io.Reader) (imdbSearchPage, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return imdbSearchPage{}, err
+	}
+
+	results := []IMDbResult{}
+	doc.Find(".ipc-metadata-list-summary-item__tc").Each(func(_ int, item *goquery.Selection) {
+		// The metadata line lists the year and, for anything but movies,
+		// the kind of title, e.g. "2015–2019 · TV Series"
+		var metadata []string
+		item.Find(".ipc-metadata-list-summary-item__tl li").Each(func(_ int, li *goquery.Selection) {
+			metadata = append(metadata, strings.TrimSpace(li.Text()))
+		})
+
+		title := strings.TrimSpace(item.Find(".ipc-metadata-list-summary-item__t").Text())
+		if title == "" {
+			title = strings.TrimSpace(item.Find("a").Text())
+		}
+
+		href, _ := item.Find("a").First().Attr("href")
+		results = append(results, parseIMDbResult(imdbIDFromHref(href), title, metadata))
+	})
+
+	return imdbSearchPage{
+		Results:         results,
+		NoResultsNotice: imdbNoResultsPattern.MatchString(doc.Find("body").Text()),
+	}, nil
+}
+
+// imdbIDFromHref extracts the ID from a link like
+// "/title/tt4955642/?ref_=fn_all_ttl_1"
+func imdbIDFromHref(href string) string {
+	parts := strings.Split(href, "/")
+	if len(parts) <= 2 {
+		return ""
+	}
+	return strings.Split(parts[2], "?")[0]
+}
+
+// parseIMDbResult builds a result from a search item's title and metadata
+// line. Items without a type label are movies
+func parseIMDbResult(id, title string, metadata []string) IMDbResult {
+	result := IMDbResult{Title: title, Type: "movie", ID: id}
+	for _, item := range metadata {
+		if result.Year == 0 {
+			if year := imdbYearPattern.FindString(item); year != "" {
+				result.Year, _ = strconv.Atoi(year)
+				continue
+			}
+		}
+
+		lower := strings.ToLower(item)
+		switch {
+		case strings.Contains(lower, "episode"):
+			result.Type = "episode"
+		case strings.Contains(lower, "series"):
+			result.Type = "series"
+		case strings.Contains(lower, "short"), strings.Contains(lower, "video"), strings.Contains(lower, "special"):
+			result.Type = lower
+		}
+	}
+	return result
+}
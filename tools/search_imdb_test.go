@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseIMDbSearchPage(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    imdbSearchPage
+	}{
+		{
+			fixture: "imdb_search.html",
+			want: imdbSearchPage{Results: []IMDbResult{
+				{Title: "Mr. Robot", Year: 2015, Type: "series", ID: "tt4158110"},
+				{Title: "Mr. Nobody", Year: 2009, Type: "movie", ID: "tt0485947"},
+				{Title: "eps1.0_hellofriend.mov", Year: 2015, Type: "episode", ID: "tt4730986"},
+				{Title: "Mr. Robot: Hacking the Series", Year: 2016, Type: "tv special", ID: "tt5765644"},
+			}},
+		},
+		{
+			fixture: "imdb_no_results.html",
+			want:    imdbSearchPage{Results: []IMDbResult{}, NoResultsNotice: true},
+		},
+		{
+			// A layout the selectors don't know yields neither results nor
+			// the notice, which SearchIMDb reports as an error
+			fixture: "imdb_changed_layout.html",
+			want:    imdbSearchPage{Results: []IMDbResult{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			file, err := os.Open(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			got, err := parseIMDbSearchPage(file)
+			if err != nil {
+				t.Fatalf("parseIMDbSearchPage: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseIMDbSearchPage = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIMDbResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		href     string
+		title    string
+		metadata []string
+		want     IMDbResult
+	}{
+		{name: "movie", href: "/title/tt0113277/?ref_=fn_all_ttl_1", title: "Heat", metadata: []string{"1995"}, want: IMDbResult{Title: "Heat", Year: 1995, Type: "movie", ID: "tt0113277"}},
+		{name: "mini series", href: "/title/tt7366338/", title: "Chernobyl", metadata: []string{"2019", "TV Mini Series"}, want: IMDbResult{Title: "Chernobyl", Year: 2019, Type: "series", ID: "tt7366338"}},
+		{name: "short", href: "/title/tt0108968/", title: "Bottle Rocket", metadata: []string{"1994", "Short"}, want: IMDbResult{Title: "Bottle Rocket", Year: 1994, Type: "short", ID: "tt0108968"}},
+		{name: "video", href: "/title/tt1234567/", title: "Making Of", metadata: []string{"2010", "Video"}, want: IMDbResult{Title: "Making Of", Year: 2010, Type: "video", ID: "tt1234567"}},
+		{name: "no year", href: "/title/tt7654321/", title: "Upcoming", metadata: []string{"TV Series"}, want: IMDbResult{Title: "Upcoming", Type: "series", ID: "tt7654321"}},
+		{name: "no link", title: "Broken", want: IMDbResult{Title: "Broken", Type: "movie"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseIMDbResult(imdbIDFromHref(tt.href), tt.title, tt.metadata)
+			if got != tt.want {
+				t.Errorf("parseIMDbResult = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
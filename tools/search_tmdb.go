@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+type SearchTMDbInput struct {
+	Query     string `json:"query" jsonschema_description:"The movie or show title to search for."`
+	Year      int    `json:"year" jsonschema_description:"Optional release or first air year to narrow the search, useful to tell remakes apart."`
+	MediaType string `json:"media_type" jsonschema_description:"Optional 'movie' or 'tv' to only search one kind of title. Both are searched when empty."`
+}
+
+var SearchTMDbInputSchema = GenerateSchema[SearchTMDbInput]()
+
+var SearchTMDbDefinition = ToolDefinition{
+	Name:        "search_tmdb",
+	Description: "Search TMDb for movies and shows. Returns structured results with title, year, TMDb id, media_type (movie or tv) and overview, most relevant first. Prefer this over search_imdb when TMDB_API_KEY is configured, as the results are stable and carry proper years to tell remakes apart.",
+	InputSchema: SearchTMDbInputSchema,
+	Function:    SearchTMDb,
+}
+
+type TMDbSearchResult struct {
+	Title     string `json:"title"`
+	Year      int    `json:"year,omitempty"`
+	ID        int    `json:"id"`
+	MediaType string `json:"media_type"`
+	Overview  string `json:"overview,omitempty"`
+}
+
+// tmdbSearchResponse covers the movie, tv and multi search endpoints. Movies
+// use title and release_date, shows name and first_air_date
+type tmdbSearchResponse struct {
+	Results []struct {
+		ID           int    `json:"id"`
+		MediaType    string `json:"media_type"`
+		Title        string `json:"title"`
+		Name         string `json:"name"`
+		ReleaseDate  string `json:"release_date"`
+		FirstAirDate string `json:"first_air_date"`
+		Overview     string `json:"overview"`
+	} `json:"results"`
+}
+
+const maxTMDbSearchResults = 10
+
+func SearchTMDb(input json.RawMessage) (string, error) {
+	searchInput := SearchTMDbInput{}
+	err := json.Unmarshal(input, &searchInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if searchInput.Query == "" {
+		return "", fmt.Errorf("query must not be empty")
+	}
+
+	query := url.Values{}
+	query.Set("query", searchInput.Query)
+
+	path := "/search/multi"
+	switch searchInput.MediaType {
+	case "":
+		if searchInput.Year > 0 {
+			query.Set("year", strconv.Itoa(searchInput.Year))
+		}
+	case "movie":
+		path = "/search/movie"
+		if searchInput.Year > 0 {
+			query.Set("year", strconv.Itoa(searchInput.Year))
+		}
+	case "tv":
+		path = "/search/tv"
+		if searchInput.Year > 0 {
+			query.Set("first_air_date_year", strconv.Itoa(searchInput.Year))
+		}
+	default:
+		return "", fmt.Errorf("media_type must be 'movie', 'tv' or empty, got %q", searchInput.MediaType)
+	}
+
+	var response tmdbSearchResponse
+	if err := tmdbGet(path, query, &response); err != nil {
+		return "", err
+	}
+
+	results := []TMDbSearchResult{}
+	for _, item := range response.Results {
+		mediaType := item.MediaType
+		if mediaType == "" {
+			mediaType = searchInput.MediaType
+		}
+		// Multi search also returns people
+		if mediaType != "movie" && mediaType != "tv" {
+			continue
+		}
+
+		result := TMDbSearchResult{ID: item.ID, MediaType: mediaType, Title: item.Title, Overview: item.Overview}
+		date := item.ReleaseDate
+		if mediaType == "tv" {
+			result.Title, date = item.Name, item.FirstAirDate
+		}
+		if len(date) >= 4 {
+			result.Year, _ = strconv.Atoi(date[:4])
+		}
+
+		results = append(results, result)
+		if len(results) == maxTMDbSearchResults {
+			break
+		}
+	}
+
+	jsonData, err := json.Marshal(results)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
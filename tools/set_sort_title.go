@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+type SetSortTitleInput struct {
+	NFOPath     string `json:"nfo_path" jsonschema_description:"The movie.nfo or tvshow.nfo to update."`
+	Title       string `json:"title" jsonschema_description:"The title to derive the sort title from. If empty, the NFO's <title> is used."`
+	ArticleMode string `json:"article_mode" jsonschema_description:"'move' turns 'The Matrix' into 'Matrix, The', 'drop' into 'Matrix'. If empty, SORT_TITLE_ARTICLES is used, defaulting to move."`
+	DryRun      bool   `json:"dry_run" jsonschema_description:"If true, only report the computed sort title without writing the NFO."`
+}
+
+var SetSortTitleInputSchema = GenerateSchema[SetSortTitleInput]()
+
+var SetSortTitleDefinition = ToolDefinition{
+	Name:        "set_sort_title",
+	Description: "Compute the Jellyfin sort title for a movie or show, moving a leading English article (The, A, An) to the end or dropping it, and write it as <sorttitle> into the NFO so 'The Matrix' sorts under M. Titles without a leading article need no sort title and are left alone.",
+	InputSchema: SetSortTitleInputSchema,
+	Function:    SetSortTitle,
+	PreviewWith: "dry_run",
+}
+
+type SetSortTitleOutput struct {
+	Title     string `json:"title"`
+	SortTitle string `json:"sort_title,omitempty"`
+	Written   bool   `json:"written"`
+	Note      string `json:"note,omitempty"`
+}
+
+var sortArticlePattern = regexp.MustCompile(`(?i)^(the|a|an)\s+(.+)$`)
+
+func SetSortTitle(input json.RawMessage) (string, error) {
+	sortInput := SetSortTitleInput{}
+	err := json.Unmarshal(input, &sortInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(sortInput.NFOPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	mode := sortInput.ArticleMode
+	if mode == "" {
+		mode = os.Getenv("SORT_TITLE_ARTICLES")
+	}
+	if mode == "" {
+		mode = "move"
+	}
+	if mode != "move" && mode != "drop" {
+		return "", fmt.Errorf("article_mode must be 'move' or 'drop', got %q", mode)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	content, err := readMediaFile(fsys, sortInput.NFOPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read NFO: %v", err)
+	}
+	root, elements, err := parseNFOElements(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse NFO: %v", err)
+	}
+
+	title := sortInput.Title
+	for _, element := range elements {
+		if title == "" && element.name == "title" {
+			title = element.value
+		}
+	}
+	if title == "" {
+		return "", fmt.Errorf("no title given and the NFO has no <title>")
+	}
+
+	output := SetSortTitleOutput{Title: title, SortTitle: sortTitle(title, mode)}
+	if output.SortTitle == "" {
+		output.Note = "title has no leading article, it already sorts correctly"
+	} else if !sortInput.DryRun && !DryRun {
+		rendered, _ := applyNFOFields(root, elements, map[string]string{"sorttitle": output.SortTitle})
+		if err := writeMediaFile(fsys, sortInput.NFOPath, []byte(rendered)); err != nil {
+			return "", fmt.Errorf("failed to write NFO: %v", err)
+		}
+		output.Written = true
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if DryRun && !sortInput.DryRun {
+		return dryRunOutput(jsonData), nil
+	}
+
+	return string(jsonData), nil
+}
+
+// sortTitle moves or drops a leading article. It returns "" when the title
+// doesn't start with one
+func sortTitle(title, mode string) string {
+	match := sortArticlePattern.FindStringSubmatch(strings.TrimSpace(title))
+	if match == nil {
+		return ""
+	}
+	if mode == "drop" {
+		return match[2]
+	}
+	return match[2] + ", " + match[1]
+}
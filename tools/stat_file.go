@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type StatFileInput struct {
+	Path string `json:"path" jsonschema_description:"The file or folder to describe. Must be within the permitted folders."`
+}
+
+var StatFileInputSchema = GenerateSchema[StatFileInput]()
+
+var StatFileDefinition = ToolDefinition{
+	Name:        "stat_file",
+	Description: "Return metadata for a file or folder: size, mode, modification time, whether it is a folder and its extension. Useful to spot recent downloads, pick the largest file of a folder as the main feature or recognize sidecar files.",
+	InputSchema: StatFileInputSchema,
+	Function:    StatFile,
+}
+
+type StatFileOutput struct {
+	Path      string    `json:"path"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	Mode      string    `json:"mode"`
+	ModTime   time.Time `json:"mod_time"`
+	IsDir     bool      `json:"is_dir"`
+	Extension string    `json:"extension,omitempty"`
+}
+
+func StatFile(input json.RawMessage) (string, error) {
+	statInput := StatFileInput{}
+	err := json.Unmarshal(input, &statInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(statInput.Path); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	info, err := fsys.Stat(statInput.Path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("path does not exist: %s", statInput.Path)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to stat %s: %v", statInput.Path, err)
+	}
+
+	output := StatFileOutput{
+		Path:    statInput.Path,
+		Name:    info.Name(),
+		Size:    info.Size(),
+		Mode:    info.Mode().String(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}
+	if !info.IsDir() {
+		output.Extension = strings.ToLower(filepath.Ext(info.Name()))
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
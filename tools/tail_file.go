@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type TailFileInput struct {
+	Path  string `json:"path" jsonschema_description:"The text file to read. Must be within the permitted folders."`
+	Lines int    `json:"lines" jsonschema_description:"Number of lines to return from the end of the file. If 0, defaults to 20."`
+}
+
+var TailFileInputSchema = GenerateSchema[TailFileInput]()
+
+var TailFileDefinition = ToolDefinition{
+	Name:        "tail_file",
+	Description: "Return the last N lines of a text file, like 'tail -n'. Reads backwards from the end, so it is cheap even on very large log or NFO files. Windows (CRLF) line endings are handled.",
+	InputSchema: TailFileInputSchema,
+	Function:    TailFile,
+}
+
+// tailChunkSize is how much is read per step when scanning backwards
+const tailChunkSize = 64 << 10
+
+func TailFile(input json.RawMessage) (string, error) {
+	tailInput := TailFileInput{}
+	err := json.Unmarshal(input, &tailInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if err := ValidatePath(tailInput.Path); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	if isVideoFile(tailInput.Path) {
+		return "", fmt.Errorf("cannot read video files: %s", tailInput.Path)
+	}
+
+	lines := tailInput.Lines
+	if lines <= 0 {
+		lines = 20
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+
+	file, err := fsys.Open(tailInput.Path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return tailLines(file, lines)
+}
+
+// tailLines returns the last n lines of r, reading backwards from the end in
+// chunks until enough line breaks have been seen
+func tailLines(r io.ReadSeeker, n int) (string, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return "", err
+	}
+
+	var tail []byte
+	offset := size
+	for offset > 0 {
+		chunk := int64(tailChunkSize)
+		if offset < chunk {
+			chunk = offset
+		}
+		offset -= chunk
+
+		buffer := make([]byte, chunk)
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return "", err
+		}
+		if _, err := io.ReadFull(r, buffer); err != nil {
+			return "", err
+		}
+		tail = append(buffer, tail...)
+
+		// The newline ending the last line doesn't start a new one, so one
+		// more break than requested is needed to know the first line is whole
+		if bytes.Count(bytes.TrimSuffix(tail, []byte("\n")), []byte("\n")) >= n {
+			break
+		}
+	}
+
+	text := strings.TrimSuffix(string(tail), "\n")
+	text = strings.TrimSuffix(text, "\r")
+	all := strings.Split(text, "\n")
+	if len(all) > n {
+		all = all[len(all)-n:]
+	}
+	for i, line := range all {
+		all[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	return strings.Join(all, "\n"), nil
+}
@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+type TitleCaseFolderInput struct {
+	FolderPath string `json:"folder_path" jsonschema_description:"The movie or show folder to recapitalize. Must be within the permitted folders."`
+	Apply      bool   `json:"apply" jsonschema_description:"If true, the folder is renamed. If false, the proposed name is only reported."`
+}
+
+var TitleCaseFolderInputSchema = GenerateSchema[TitleCaseFolderInput]()
+
+var TitleCaseFolderDefinition = ToolDefinition{
+	Name:           "title_case_folder",
+	Description:    "Propose consistent English title capitalization for a folder name, like 'the lord of the rings (2001)' -> 'The Lord of the Rings (2001)'. Small words (a, the, of, and...) stay lowercase except at the start or end, acronyms (FBI) and roman numerals (II, IV) are upper case, and years and provider id tags are kept as they are. Set apply to rename the folder after validation and a collision check.",
+	InputSchema:    TitleCaseFolderInputSchema,
+	Function:       TitleCaseFolder,
+	WritesOnlyWith: "apply",
+}
+
+type TitleCaseFolderOutput struct {
+	Path     string `json:"path"`
+	Current  string `json:"current"`
+	Proposed string `json:"proposed"`
+	Changed  bool   `json:"changed"`
+	Applied  bool   `json:"applied"`
+}
+
+var (
+	titleSmallWords = map[string]bool{
+		"a": true, "an": true, "the": true, "and": true, "but": true, "or": true, "nor": true,
+		"for": true, "so": true, "yet": true, "as": true, "at": true, "by": true, "in": true,
+		"of": true, "off": true, "on": true, "per": true, "to": true, "up": true, "via": true,
+		"vs": true, "from": true, "into": true, "with": true,
+	}
+
+	romanNumeralPattern = regexp.MustCompile(`^(?i)M{0,3}(CM|CD|D?C{0,3})(XC|XL|L?X{0,3})(IX|IV|V?I{0,3})$`)
+
+	// titleProtectedPattern matches parts of a name that are not title words
+	titleProtectedPattern = regexp.MustCompile(`(?i)\([^)]*\)|[\[{](imdb|tmdb|tvdb)(id)?-[^\]}]+[\]}]`)
+)
+
+func TitleCaseFolder(input json.RawMessage) (string, error) {
+	titleInput := TitleCaseFolderInput{}
+	err := json.Unmarshal(input, &titleInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	folderPath := filepath.Clean(titleInput.FolderPath)
+	if err := ValidatePath(folderPath); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	current := filepath.Base(folderPath)
+	proposed := titleCaseName(current)
+	target := filepath.Join(filepath.Dir(folderPath), proposed)
+
+	output := TitleCaseFolderOutput{
+		Path:     folderPath,
+		Current:  current,
+		Proposed: proposed,
+		Changed:  current != proposed,
+	}
+
+	if titleInput.Apply && output.Changed {
+		fsys, err := GetMediaFS()
+		if err != nil {
+			return "", err
+		}
+		if _, err := fsys.Stat(folderPath); err != nil {
+			return "", fmt.Errorf("source path does not exist: %s", folderPath)
+		}
+
+		// Only a case change keeps the same name on case-insensitive
+		// filesystems, so it goes through a temporary name
+		caseOnly := strings.EqualFold(current, proposed)
+		if !caseOnly {
+			if _, err := fsys.Stat(target); err == nil {
+				return "", fmt.Errorf("target path already exists: %s", target)
+			}
+		}
+
+		switch {
+		case DryRun:
+			// The temporary name never exists in a dry run, so only the
+			// checks of the final rename can run
+			if err := ValidatePath(target); err != nil {
+				return "", fmt.Errorf("invalid target path: %v", err)
+			}
+		case caseOnly:
+			temporary := folderPath + tempRenameSuffix
+			if err := moveMedia(folderPath, temporary); err != nil {
+				return "", err
+			}
+			if err := moveMedia(temporary, target); err != nil {
+				moveMedia(temporary, folderPath)
+				return "", err
+			}
+			output.Applied = true
+		default:
+			if err := moveMedia(folderPath, target); err != nil {
+				return "", err
+			}
+			output.Applied = true
+		}
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if titleInput.Apply && DryRun {
+		return dryRunOutput(jsonData), nil
+	}
+
+	return string(jsonData), nil
+}
+
+// titleCaseName title-cases the words of a name, leaving parentheticals like
+// the year and provider id tags untouched
+func titleCaseName(name string) string {
+	var out strings.Builder
+	last := 0
+	for _, loc := range titleProtectedPattern.FindAllStringIndex(name, -1) {
+		out.WriteString(titleCase(name[last:loc[0]]))
+		out.WriteString(name[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	out.WriteString(titleCase(name[last:]))
+	return out.String()
+}
+
+// titleCase applies English title case to space separated words. Words that
+// are already all upper case are kept as acronyms, unless the whole text is
+// upper case
+func titleCase(text string) string {
+	words := strings.Split(text, " ")
+	shouting := strings.ToUpper(text) == text
+
+	first, last := -1, -1
+	for i, word := range words {
+		if strings.IndexFunc(word, unicode.IsLetter) >= 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+
+	for i, word := range words {
+		if word == "" {
+			continue
+		}
+
+		// A word after a colon starts a subtitle
+		startsPhrase := i == first || (i > 0 && strings.HasSuffix(words[i-1], ":"))
+
+		parts := strings.Split(word, "-")
+		for j, part := range parts {
+			parts[j] = titleCaseWord(part, shouting, startsPhrase || i == last || j > 0)
+		}
+		words[i] = strings.Join(parts, "-")
+	}
+
+	return strings.Join(words, " ")
+}
+
+func titleCaseWord(word string, shouting, forceCapital bool) string {
+	letters := strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) })
+	if letters == "" {
+		return word
+	}
+
+	lower := strings.ToLower(word)
+	switch {
+	case isRomanNumeral(letters, shouting):
+		return strings.ToUpper(word)
+	case !shouting && len([]rune(letters)) > 1 && strings.ToUpper(letters) == letters:
+		return word
+	case !shouting && hasInnerCapital(letters):
+		// Names like McQueen or iPhone keep their casing
+		return word
+	case !forceCapital && titleSmallWords[strings.ToLower(letters)]:
+		return lower
+	}
+
+	runes := []rune(lower)
+	for i, r := range runes {
+		if unicode.IsLetter(r) {
+			runes[i] = unicode.ToUpper(r)
+			break
+		}
+	}
+	return string(runes)
+}
+
+// isRomanNumeral reports whether a word reads as a roman numeral. Lower case
+// words only count when made of I, V and X, so words like "mix" or "civic"
+// are left alone
+func isRomanNumeral(word string, shouting bool) bool {
+	if !romanNumeralPattern.MatchString(word) {
+		return false
+	}
+	if strings.ToUpper(word) == word && !shouting {
+		return true
+	}
+	return strings.Trim(strings.ToUpper(word), "IVX") == ""
+}
+
+func hasInnerCapital(word string) bool {
+	for i, r := range []rune(word) {
+		if i > 0 && unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
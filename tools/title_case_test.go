@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTitleCaseName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "the lord of the rings (2001)", want: "The Lord of the Rings (2001)"},
+		{name: "a fish called wanda", want: "A Fish Called Wanda"},
+		{name: "what we live for", want: "What We Live For"},
+		{name: "star wars: the empire strikes back", want: "Star Wars: The Empire Strikes Back"},
+		{name: "the man from U.N.C.L.E.", want: "The Man from U.N.C.L.E."},
+		{name: "FBI files", want: "FBI Files"},
+		{name: "THE GODFATHER PART II", want: "The Godfather Part II"},
+		{name: "rocky iv", want: "Rocky IV"},
+		{name: "civic duty", want: "Civic Duty"},
+		{name: "mission impossible iii", want: "Mission Impossible III"},
+		{name: "spider-man into the spider-verse", want: "Spider-Man into the Spider-Verse"},
+		{name: "McQueen", want: "McQueen"},
+		{name: "the office (us) [tvdbid-73244]", want: "The Office (us) [tvdbid-73244]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := titleCaseName(tt.name); got != tt.want {
+				t.Errorf("titleCaseName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTitleCaseFolderApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		folder      string
+		dryRun      bool
+		wantDryRun  bool
+		wantApplied bool
+		wantFolder  string
+	}{
+		{name: "case-only rename", folder: "the lord of the rings (2001)", wantApplied: true, wantFolder: "The Lord of the Rings (2001)"},
+		{name: "case-only rename in dry-run", folder: "the lord of the rings (2001)", dryRun: true, wantDryRun: true, wantFolder: "the lord of the rings (2001)"},
+		{name: "rename in dry-run", folder: "THE GODFATHER PART II", dryRun: true, wantDryRun: true, wantFolder: "THE GODFATHER PART II"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			library := setupTestLibrary(t)
+			folder := filepath.Join(library, tt.folder)
+			writeTestFile(t, filepath.Join(folder, "movie.mkv"))
+
+			dryRun := DryRun
+			DryRun = tt.dryRun
+			defer func() { DryRun = dryRun }()
+
+			result, err := TitleCaseFolder([]byte(`{"folder_path":"` + folder + `","apply":true}`))
+			if err != nil {
+				t.Fatalf("TitleCaseFolder: %v", err)
+			}
+			if strings.HasPrefix(result, dryRunPrefix) != tt.wantDryRun {
+				t.Errorf("result %q, want dry-run marker %v", result, tt.wantDryRun)
+			}
+			if strings.Contains(result, `"applied":true`) != tt.wantApplied {
+				t.Errorf("result %q, want applied %v", result, tt.wantApplied)
+			}
+
+			entries, err := os.ReadDir(library)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Name() != tt.wantFolder {
+				t.Errorf("library holds %v, want only %q", entries, tt.wantFolder)
+			}
+		})
+	}
+}
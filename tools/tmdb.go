@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// tmdbBaseURL is a variable so tests can point it at a fake server
+var tmdbBaseURL = "https://api.themoviedb.org/3"
+
+var tmdbHTTPClient = &http.Client{Timeout: 15 * time.Second, Transport: networkTransport}
+
+// tmdbGet performs a GET against the TMDb API and decodes the JSON response
+// into out. TMDB_API_KEY may hold either a v3 API key or a v4 read token
+func tmdbGet(path string, query url.Values, out any) error {
+	apiKey := os.Getenv("TMDB_API_KEY")
+	if apiKey == "" {
+		return fmt.Errorf("TMDB_API_KEY environment variable must be set to use TMDb")
+	}
+
+	if query == nil {
+		query = url.Values{}
+	}
+
+	// v4 read access tokens are JWTs and go in the Authorization header. v3
+	// keys only work as a query parameter, so errors must never carry the URL
+	bearer := strings.HasPrefix(apiKey, "eyJ")
+	if !bearer {
+		query.Set("api_key", apiKey)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, tmdbBaseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build TMDb request: %w", withoutURL(err))
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := tmdbHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query TMDb: %w", withoutURL(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("TMDb returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode TMDb response: %w", err)
+	}
+
+	return nil
+}
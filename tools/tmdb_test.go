@@ -0,0 +1,22 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTMDbGetHidesAPIKey(t *testing.T) {
+	t.Setenv("TMDB_API_KEY", "secret-tmdb-key")
+	transport := tmdbHTTPClient.Transport
+	tmdbHTTPClient.Transport = failingTransport{}
+	t.Cleanup(func() { tmdbHTTPClient.Transport = transport })
+
+	var out map[string]any
+	err := tmdbGet("/search/movie", nil, &out)
+	if err == nil {
+		t.Fatal("expected the request to fail")
+	}
+	if strings.Contains(err.Error(), "secret-tmdb-key") {
+		t.Errorf("error shows the API key: %v", err)
+	}
+}
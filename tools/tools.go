@@ -1,9 +1,79 @@
 package tools
 
+// AllTools lists every tool available to Claude. New tools are registered
+// by adding their definition here
 var AllTools = []ToolDefinition{
 	ReadFileDefinition,
 	ListDirectoryDefinition,
 	SearchIMDbDefinition,
 	CopyFileDefinition,
 	RenameJellyfinMediaDefinition,
+	InferNamingConventionsDefinition,
+	FindDuplicateShowsDefinition,
+	LookupEpisodeByAirDateDefinition,
+	CheckNFOArtworkDefinition,
+	ExtractProviderIDsDefinition,
+	NormalizeEpisodePaddingDefinition,
+	FolderLooseMoviesDefinition,
+	DiffNFODefinition,
+	DetectHDRFormatDefinition,
+	MoveAcrossLibrariesDefinition,
+	LastRunSummaryDefinition,
+	CheckTargetCollisionDefinition,
+	PredictMediaTypeDefinition,
+	EstimateCopyTimeDefinition,
+	ConvertSubtitleEncodingDefinition,
+	GenerateHashManifestDefinition,
+	VerifyHashManifestDefinition,
+	TitleCaseFolderDefinition,
+	TailFileDefinition,
+	MigrateNamingDefinition,
+	DedupeSubtitlesDefinition,
+	FetchChaptersDefinition,
+	CompareQualitiesDefinition,
+	QuickRenameDefinition,
+	SetLibraryProfileDefinition,
+	CheckLibraryProfileDefinition,
+	ScanInterruptedRunsDefinition,
+	SetSortTitleDefinition,
+	VerifyJellyfinIndexedDefinition,
+	CreateStrmDefinition,
+	CleanEpisodeTitleDefinition,
+	DeleteFileDefinition,
+	SearchTMDbDefinition,
+	UndoLastOperationDefinition,
+	CreateDirectoryDefinition,
+	HashFileDefinition,
+	StatFileDefinition,
+	FindMainVideoDefinition,
+	ParseReleaseNameDefinition,
+	WriteNFODefinition,
+	TriggerJellyfinScanDefinition,
+	JellyfinSearchLibraryDefinition,
+	LookupIMDbIDDefinition,
+	DownloadArtworkDefinition,
+	SanitizeFilenameDefinition,
+	ValidateJellyfinNameDefinition,
+	BuildMoviePathDefinition,
+	GroupMoviePartsDefinition,
+	BatchRenameDefinition,
+	ProbeMediaDefinition,
+	ListSubtitleFilesDefinition,
+}
+
+var allToolsByName = IndexTools(AllTools)
+
+// ToolByName looks up a tool of AllTools by its name
+func ToolByName(name string) (ToolDefinition, bool) {
+	tool, ok := allToolsByName[name]
+	return tool, ok
+}
+
+// IndexTools maps tool definitions by name for constant time lookups
+func IndexTools(defs []ToolDefinition) map[string]ToolDefinition {
+	index := make(map[string]ToolDefinition, len(defs))
+	for _, def := range defs {
+		index[def.Name] = def
+	}
+	return index
 }
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+)
+
+// transaction records how to undo each completed filesystem step of a
+// multi-step operation, so a failure part way through can put everything
+// back the way it was
+type transaction struct {
+	steps []transactionStep
+}
+
+type transactionStep struct {
+	description string
+	undo        func() error
+}
+
+// add records a completed step along with how to reverse it
+func (t *transaction) add(description string, undo func() error) {
+	t.steps = append(t.steps, transactionStep{description: description, undo: undo})
+}
+
+// rollback undoes the recorded steps in reverse order and returns a
+// description of every step that could not be undone
+func (t *transaction) rollback() []string {
+	var failures []string
+	for i := len(t.steps) - 1; i >= 0; i-- {
+		step := t.steps[i]
+		if err := step.undo(); err != nil {
+			failures = append(failures, fmt.Sprintf("failed to undo %s: %v", step.description, err))
+		}
+	}
+	t.steps = nil
+	return failures
+}
+
+// undoMove returns the step that reverses a move of source to target. The
+// move back is recorded as an undo, which cancels the move in the operation
+// log, so undo_last_operation never offers a rolled back move again
+func undoMove(source, target string) func() error {
+	return func() error {
+		if DryRun {
+			return nil
+		}
+		if _, err := relocateMedia(context.Background(), target, source, false); err != nil {
+			return err
+		}
+		recordOperation("undo", source, target)
+		return nil
+	}
+}
+
+// undoCopy returns the step that removes a copy made to target, recording
+// it as an undo of the copy like undoMove
+func undoCopy(source, target string) func() error {
+	return func() error {
+		if DryRun {
+			return nil
+		}
+		fsys, err := GetMediaFS()
+		if err != nil {
+			return err
+		}
+		if err := fsys.Remove(target); err != nil {
+			return err
+		}
+		recordOperation("undo", source, target)
+		return nil
+	}
+}
+
+// rollbackError describes a batch failure and the outcome of rolling back
+func rollbackError(cause error, failures []string) error {
+	if len(failures) == 0 {
+		return fmt.Errorf("%v; all completed operations were rolled back", cause)
+	}
+	return fmt.Errorf("%v; rollback was incomplete: %v", cause, failures)
+}
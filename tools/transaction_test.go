@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// setupTestLibrary points the movies library and the operation log at a
+// fresh temporary folder and returns the library
+func setupTestLibrary(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	library := filepath.Join(dir, "movies")
+	if err := os.Mkdir(library, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("JELLYFIN_MOVIES_FOLDER", library)
+	t.Setenv("JELLYFIN_SHOWS_FOLDER", "")
+	t.Setenv("SOURCE_FOLDER", "")
+	t.Setenv("REMOTE_MEDIA_URL", "")
+	t.Setenv("OPERATIONS_LOG", filepath.Join(dir, "operations.log"))
+	return library
+}
+
+func writeTestFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(filepath.Base(path)), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionRollbackOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		steps        []string
+		failing      string
+		wantOrder    []string
+		wantFailures int
+	}{
+		{name: "empty", wantOrder: nil},
+		{name: "reverse order", steps: []string{"a", "b", "c"}, wantOrder: []string{"c", "b", "a"}},
+		{name: "failure doesn't stop the rest", steps: []string{"a", "b", "c"}, failing: "b", wantOrder: []string{"c", "b", "a"}, wantFailures: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tx transaction
+			var order []string
+			for _, step := range tt.steps {
+				tx.add(step, func() error {
+					order = append(order, step)
+					if step == tt.failing {
+						return errors.New("undo failed")
+					}
+					return nil
+				})
+			}
+
+			failures := tx.rollback()
+			if !reflect.DeepEqual(order, tt.wantOrder) {
+				t.Errorf("undo order = %v, want %v", order, tt.wantOrder)
+			}
+			if len(failures) != tt.wantFailures {
+				t.Errorf("failures = %v, want %d", failures, tt.wantFailures)
+			}
+			if len(tx.rollback()) != 0 || len(tx.steps) != 0 {
+				t.Errorf("rollback should clear the steps")
+			}
+		})
+	}
+}
+
+func TestTransactionRollbackRestoresFiles(t *testing.T) {
+	library := setupTestLibrary(t)
+	sources := []string{
+		filepath.Join(library, "a.mkv"),
+		filepath.Join(library, "b.mkv"),
+	}
+	for _, source := range sources {
+		writeTestFile(t, source)
+	}
+
+	var tx transaction
+	for _, source := range sources {
+		target := filepath.Join(library, "Moved", filepath.Base(source))
+		if err := moveMedia(source, target); err != nil {
+			t.Fatalf("moveMedia(%s): %v", source, err)
+		}
+		tx.add("move of "+source, undoMove(source, target))
+	}
+
+	// A move failing part way through, as the missing source can't be moved
+	if err := moveMedia(filepath.Join(library, "missing.mkv"), filepath.Join(library, "Moved", "missing.mkv")); err == nil {
+		t.Fatal("moving a missing file should fail")
+	}
+	if failures := tx.rollback(); len(failures) != 0 {
+		t.Fatalf("rollback failures: %v", failures)
+	}
+
+	for _, source := range sources {
+		if _, err := os.Stat(source); err != nil {
+			t.Errorf("%s was not moved back: %v", source, err)
+		}
+		if _, err := os.Stat(filepath.Join(library, "Moved", filepath.Base(source))); !os.IsNotExist(err) {
+			t.Errorf("%s is still at its target", source)
+		}
+	}
+
+	// The rolled back moves must not be offered to undo_last_operation
+	entries, err := readLedger(ledgerPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry, ok := lastUndoableEntry(entries); ok {
+		t.Errorf("rolled back moves left %+v to undo", entry)
+	}
+}
@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type TriggerJellyfinScanInput struct{}
+
+var TriggerJellyfinScanInputSchema = GenerateSchema[TriggerJellyfinScanInput]()
+
+var TriggerJellyfinScanDefinition = ToolDefinition{
+	Name:        "trigger_jellyfin_scan",
+	Description: "Ask the Jellyfin server to rescan all libraries, so reorganized media shows up without a manual refresh. Call it once after all moves are done, not after every move. The scan runs in the background on the server, use verify_jellyfin_indexed later to check the result. Requires JELLYFIN_SERVER_URL and JELLYFIN_API_KEY.",
+	InputSchema: TriggerJellyfinScanInputSchema,
+	Function:    TriggerJellyfinScan,
+}
+
+func TriggerJellyfinScan(input json.RawMessage) (string, error) {
+	scanInput := TriggerJellyfinScanInput{}
+	err := json.Unmarshal(input, &scanInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if DryRun {
+		return fmt.Sprintf("%swould ask Jellyfin to rescan all libraries", dryRunPrefix), nil
+	}
+
+	status, err := jellyfinPost("/Library/Refresh", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to trigger a library scan: %w", err)
+	}
+
+	return fmt.Sprintf("Jellyfin accepted the library scan (HTTP %d %s). It runs in the background and may take a few minutes for large libraries", status, http.StatusText(status)), nil
+}
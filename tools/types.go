@@ -1,7 +1,9 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/invopop/jsonschema"
@@ -12,6 +14,58 @@ type ToolDefinition struct {
 	Description string                         `json:"description"`
 	InputSchema anthropic.ToolInputSchemaParam `json:"input_schema"`
 	Function    func(input json.RawMessage) (string, error)
+	// Structured is used instead of Function by tools with a typed result.
+	// Call serializes the result to JSON for Claude, while Go callers can
+	// use the typed value directly
+	Structured func(input json.RawMessage) (any, error)
+	// Contextual is used instead of Function by long running tools, like
+	// copies, that stop early once ctx is cancelled
+	Contextual func(ctx context.Context, input json.RawMessage) (string, error)
+	// WritesOnlyWith names a boolean input, like apply, without which a
+	// call only reports what it would change
+	WritesOnlyWith string
+	// PreviewWith names a boolean input, like dry_run, that limits a call
+	// to a report
+	PreviewWith string
+}
+
+// IsPreview reports whether a call with input only reports, leaving the
+// files as they are
+func (t ToolDefinition) IsPreview(input json.RawMessage) bool {
+	if t.WritesOnlyWith == "" && t.PreviewWith == "" {
+		return false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(input, &fields); err != nil {
+		return false
+	}
+	if t.WritesOnlyWith != "" && string(fields[t.WritesOnlyWith]) != "true" {
+		return true
+	}
+	return t.PreviewWith != "" && string(fields[t.PreviewWith]) == "true"
+}
+
+// Call runs the tool and returns its output as text for Claude
+func (t ToolDefinition) Call(ctx context.Context, input json.RawMessage) (string, error) {
+	if t.Contextual != nil {
+		return t.Contextual(ctx, input)
+	}
+	if t.Structured == nil {
+		return t.Function(input)
+	}
+
+	result, err := t.Structured(input)
+	if err != nil {
+		return "", err
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
 }
 
 func GenerateSchema[T any]() anthropic.ToolInputSchemaParam {
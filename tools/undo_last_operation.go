@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type UndoLastOperationInput struct{}
+
+var UndoLastOperationInputSchema = GenerateSchema[UndoLastOperationInput]()
+
+var UndoLastOperationDefinition = ToolDefinition{
+	Name:        "undo_last_operation",
+	Description: "Reverse the most recent operation in the operation log that hasn't been undone yet: a move is moved back and a copy or created file is deleted. Calling it again undoes the operation before that. Deletions, and copies that replaced an existing file, can't be undone and are skipped. Both paths are validated before anything is changed.",
+	InputSchema: UndoLastOperationInputSchema,
+	Function:    UndoLastOperation,
+}
+
+// undoableOperations are the ledger operations undo can reverse, plus the
+// undo entries that cancel them
+var undoableOperations = map[string]bool{"move": true, "copy": true, "create": true, "undo": true}
+
+func UndoLastOperation(input json.RawMessage) (string, error) {
+	undoInput := UndoLastOperationInput{}
+	err := json.Unmarshal(input, &undoInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	entries, err := readLedger(ledgerPath())
+	if err != nil {
+		return "", fmt.Errorf("failed to read the operation log: %v", err)
+	}
+
+	entry, ok := lastUndoableEntry(entries)
+	if !ok {
+		return "There are no operations left to undo", nil
+	}
+
+	var done string
+	switch entry.Operation {
+	case "move":
+		if DryRun {
+			return fmt.Sprintf("%swould move %s back to %s", dryRunPrefix, entry.Target, entry.Source), nil
+		}
+		if _, err := relocateMedia(context.Background(), entry.Target, entry.Source, false); err != nil {
+			return "", fmt.Errorf("failed to undo the move of %s: %v", entry.Source, err)
+		}
+		done = fmt.Sprintf("Moved %s back to %s", entry.Target, entry.Source)
+	case "copy", "create":
+		if err := ValidatePath(entry.Target); err != nil {
+			return "", fmt.Errorf("access denied: %v", err)
+		}
+		if DryRun {
+			return fmt.Sprintf("%swould delete %s", dryRunPrefix, entry.Target), nil
+		}
+		fsys, err := GetMediaFS()
+		if err != nil {
+			return "", err
+		}
+		if err := fsys.Remove(entry.Target); err != nil {
+			return "", fmt.Errorf("failed to undo the %s of %s: %v", entry.Operation, entry.Target, err)
+		}
+		done = fmt.Sprintf("Deleted %s, undoing its %s", entry.Target, entry.Operation)
+	default:
+		return "", fmt.Errorf("the last operation, %s of %s at %s, can't be undone", entry.Operation, entry.Source, entry.Time.Format("2006-01-02 15:04:05"))
+	}
+
+	recordOperation("undo", entry.Source, entry.Target)
+
+	return done, nil
+}
+
+// lastUndoableEntry walks the log backwards, where every undo entry cancels
+// the closest earlier operation that wasn't undone yet. Rollbacks log their
+// steps as undo entries too, so rolled back operations are skipped. So are
+// deletions and overwrites, which can't be reversed and must not block the
+// operations before them
+func lastUndoableEntry(entries []LedgerEntry) (LedgerEntry, bool) {
+	pending := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		switch {
+		case !undoableOperations[entries[i].Operation]:
+			continue
+		case entries[i].Operation == "undo":
+			pending++
+		case pending > 0:
+			pending--
+		default:
+			return entries[i], true
+		}
+	}
+	return LedgerEntry{}, false
+}
@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLastUndoableEntry(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations []string
+		wantIndex  int
+	}{
+		{name: "empty log", operations: nil, wantIndex: -1},
+		{name: "last operation", operations: []string{"move", "copy"}, wantIndex: 1},
+		{name: "undo cancels the latest", operations: []string{"move", "copy", "undo"}, wantIndex: 0},
+		{name: "everything undone", operations: []string{"move", "copy", "undo", "undo"}, wantIndex: -1},
+		{name: "rolled back batch", operations: []string{"create", "move", "move", "undo", "undo"}, wantIndex: 0},
+		{name: "delete is skipped", operations: []string{"move", "delete"}, wantIndex: 0},
+		{name: "overwrite is skipped", operations: []string{"copy", "overwrite", "delete"}, wantIndex: 0},
+		{name: "undo after a delete", operations: []string{"copy", "move", "delete", "undo"}, wantIndex: 0},
+		{name: "only irreversible", operations: []string{"delete", "overwrite"}, wantIndex: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []LedgerEntry
+			for i, operation := range tt.operations {
+				entries = append(entries, LedgerEntry{Operation: operation, Source: string(rune('a' + i))})
+			}
+
+			entry, ok := lastUndoableEntry(entries)
+			if tt.wantIndex < 0 {
+				if ok {
+					t.Errorf("got %+v, want nothing to undo", entry)
+				}
+				return
+			}
+			if !ok || entry != entries[tt.wantIndex] {
+				t.Errorf("got %+v (%v), want %+v", entry, ok, entries[tt.wantIndex])
+			}
+		})
+	}
+}
+
+func TestUndoRoundTrip(t *testing.T) {
+	library := setupTestLibrary(t)
+	movie := filepath.Join(library, "movie.mkv")
+	moved := filepath.Join(library, "Movie (2009)", "Movie (2009).mkv")
+	copied := filepath.Join(library, "Copy", "movie.mkv")
+	writeTestFile(t, movie)
+
+	if err := copyMedia(context.Background(), movie, copied, false); err != nil {
+		t.Fatalf("copyMedia: %v", err)
+	}
+	if err := moveMedia(movie, moved); err != nil {
+		t.Fatalf("moveMedia: %v", err)
+	}
+
+	// The move is undone first, then the copy
+	if _, err := UndoLastOperation([]byte(`{}`)); err != nil {
+		t.Fatalf("undoing the move: %v", err)
+	}
+	if _, err := os.Stat(movie); err != nil {
+		t.Errorf("move was not undone: %v", err)
+	}
+	if _, err := UndoLastOperation([]byte(`{}`)); err != nil {
+		t.Fatalf("undoing the copy: %v", err)
+	}
+	if _, err := os.Stat(copied); !os.IsNotExist(err) {
+		t.Errorf("copy was not deleted")
+	}
+
+	result, err := UndoLastOperation([]byte(`{}`))
+	if err != nil || !strings.Contains(result, "no operations left") {
+		t.Errorf("got %q, %v, want nothing left to undo", result, err)
+	}
+}
+
+func TestUndoSkipsIrreversibleOperations(t *testing.T) {
+	library := setupTestLibrary(t)
+	movie := filepath.Join(library, "movie.mkv")
+	moved := filepath.Join(library, "Movie (2009)", "Movie (2009).mkv")
+	existing := filepath.Join(library, "old.mkv")
+	writeTestFile(t, movie)
+	writeTestFile(t, existing)
+
+	if err := moveMedia(movie, moved); err != nil {
+		t.Fatalf("moveMedia: %v", err)
+	}
+	for _, entry := range []LedgerEntry{
+		{Operation: "delete", Source: filepath.Join(library, "deleted.srt")},
+		{Operation: "overwrite", Source: movie, Target: existing},
+	} {
+		if err := appendLedgerEntry(ledgerPath(), entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The move before the delete and the overwrite is still undone
+	if _, err := UndoLastOperation([]byte(`{}`)); err != nil {
+		t.Fatalf("undoing the move: %v", err)
+	}
+	if _, err := os.Stat(movie); err != nil {
+		t.Errorf("move was not undone: %v", err)
+	}
+	if _, err := os.Stat(existing); err != nil {
+		t.Errorf("the overwritten file was deleted: %v", err)
+	}
+
+	result, err := UndoLastOperation([]byte(`{}`))
+	if err != nil || !strings.Contains(result, "no operations left") {
+		t.Errorf("got %q, %v, want nothing left to undo", result, err)
+	}
+}
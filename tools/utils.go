@@ -9,34 +9,156 @@ import (
 
 // ValidatePath validates that a path is within allowed Jellyfin directories
 func ValidatePath(inputPath string) error {
-	showsFolder := os.Getenv("JELLYFIN_SHOWS_FOLDER")
-	moviesFolder := os.Getenv("JELLYFIN_MOVIES_FOLDER")
-	sourceFolder := os.Getenv("SOURCE_FOLDER")
+	_, err := ResolvePath(inputPath)
+	return err
+}
+
+// ValidateLibraryPath validates that a path is within the shows or movies
+// library, for destinations that must not end up in SOURCE_FOLDER
+func ValidateLibraryPath(inputPath string) error {
+	_, err := resolveWithin(inputPath, os.Getenv("JELLYFIN_SHOWS_FOLDER"), os.Getenv("JELLYFIN_MOVIES_FOLDER"))
+	return err
+}
+
+// ResolvePath is the single path check all tools rely on. It accepts
+// absolute and, for local media, relative paths, rejects traversal and
+// returns the absolute path with symlinks resolved once it is known to be
+// within the permitted folders
+func ResolvePath(inputPath string) (string, error) {
+	return resolveWithin(inputPath, os.Getenv("JELLYFIN_SHOWS_FOLDER"), os.Getenv("JELLYFIN_MOVIES_FOLDER"), os.Getenv("SOURCE_FOLDER"))
+}
 
-	// Check for path traversal attempts
-	if strings.Contains(inputPath, "..") {
-		return fmt.Errorf("path contains invalid directory traversal: %s", inputPath)
+// resolveWithin implements ResolvePath for a given set of permitted folders
+func resolveWithin(inputPath string, permittedFolders ...string) (string, error) {
+	// Check for path traversal attempts. Only whole ".." components count,
+	// so names like "Vol.. 2" are fine
+	if hasTraversal(inputPath) {
+		return "", fmt.Errorf("path contains invalid directory traversal: %s", inputPath)
+	}
+
+	// Remote paths can't be resolved against the local working directory
+	if IsRemoteMedia() && !filepath.IsAbs(inputPath) {
+		return "", fmt.Errorf("remote media paths must be absolute: %s", inputPath)
 	}
 
 	// Get absolute path
 	absPath, err := filepath.Abs(inputPath)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	// Check where symlinks really point, so a link inside a permitted folder
+	// can't lead out of it
+	if !IsRemoteMedia() {
+		absPath, err = resolveSymlinks(absPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve symlinks: %w", err)
+		}
 	}
 
 	// Check if path is within permitted folders
-	permittedFolders := []string{showsFolder, moviesFolder, sourceFolder}
 	for _, folder := range permittedFolders {
 		if folder != "" {
 			absFolderPath, err := filepath.Abs(folder)
 			if err != nil {
 				continue
 			}
-			if strings.HasPrefix(absPath, absFolderPath) {
-				return nil
+			if !IsRemoteMedia() {
+				if resolved, err := resolveSymlinks(absFolderPath); err == nil {
+					absFolderPath = resolved
+				}
 			}
+			// A plain prefix check would let /media/movies-secret pass for
+			// /media/movies
+			if isWithinFolder(absPath, absFolderPath) {
+				return absPath, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("path is not within permitted folders: %s", inputPath)
+}
+
+// isWithinFolder reports whether path is folder or somewhere below it
+func isWithinFolder(path, folder string) bool {
+	rel, err := filepath.Rel(filepath.Clean(folder), filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// libraryFolders maps the folder types tools accept to their env vars
+var libraryFolders = map[string]string{
+	"shows":  "JELLYFIN_SHOWS_FOLDER",
+	"movies": "JELLYFIN_MOVIES_FOLDER",
+	"source": "SOURCE_FOLDER",
+}
+
+// pathInFolder resolves path against the folder of the given type: shows,
+// movies or source. Absolute paths and an empty type leave path as it is
+func pathInFolder(folderType, path string) (string, error) {
+	if folderType == "" {
+		return path, nil
+	}
+	envVar, ok := libraryFolders[folderType]
+	if !ok {
+		return "", fmt.Errorf("type must be 'shows', 'movies' or 'source', got %q", folderType)
+	}
+	folder := os.Getenv(envVar)
+	if folder == "" {
+		return "", fmt.Errorf("%s is not set, so the %s folder can't be used", envVar, folderType)
+	}
+	if filepath.IsAbs(path) {
+		return path, nil
+	}
+	return filepath.Join(folder, path), nil
+}
+
+// hasTraversal reports whether a path has a ".." component
+func hasTraversal(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == ".." {
+			return true
 		}
 	}
+	return false
+}
 
-	return fmt.Errorf("path is not within permitted folders: %s", inputPath)
+// resolveSymlinks returns an absolute path with its symlinks resolved. Trailing
+// components that don't exist yet, like a move target, are kept as they are
+// after the resolved part that exists. A dangling symlink is an error, as
+// writing through it would create its target wherever it points
+func resolveSymlinks(path string) (string, error) {
+	existing := path
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if info, err := os.Lstat(existing); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("%s is a dangling symlink", existing)
+		}
+
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return path, nil
+		}
+		missing = append([]string{filepath.Base(existing)}, missing...)
+		existing = parent
+	}
+}
+
+var videoExtensions = []string{".mkv", ".mp4", ".avi", ".mov", ".wmv", ".flv", ".webm", ".m4v", ".3gp", ".ogv", ".vob", ".ts", ".mts", ".m2ts", ".mpg", ".mpeg", ".iso"}
+
+// isVideoFile reports whether a file name has a known video extension
+func isVideoFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, videoExt := range videoExtensions {
+		if ext == videoExt {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,225 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsWithinFolder(t *testing.T) {
+	tests := []struct {
+		path   string
+		folder string
+		want   bool
+	}{
+		{path: "/media/Movies", folder: "/media/Movies", want: true},
+		{path: "/media/Movies/Heat (1995)/Heat (1995).mkv", folder: "/media/Movies", want: true},
+		{path: "/media/Movies/", folder: "/media/Movies", want: true},
+		{path: "/media/Movies/..hidden", folder: "/media/Movies", want: true},
+		{path: "/media/Movies2", folder: "/media/Movies", want: false},
+		{path: "/media/Movies2/Heat (1995).mkv", folder: "/media/Movies", want: false},
+		{path: "/media/Movies-secret", folder: "/media/Movies", want: false},
+		{path: "/media", folder: "/media/Movies", want: false},
+		{path: "/media/Movies/../Shows", folder: "/media/Movies", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := isWithinFolder(tt.path, tt.folder); got != tt.want {
+				t.Errorf("isWithinFolder(%q, %q) = %v, want %v", tt.path, tt.folder, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePathSiblingPrefix(t *testing.T) {
+	library := setupTestLibrary(t)
+	parent := filepath.Dir(library)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "library", path: library},
+		{name: "inside the library", path: filepath.Join(library, "Heat (1995)", "Heat (1995).mkv")},
+		{name: "sibling with a suffix", path: library + "2", wantErr: true},
+		{name: "file in a sibling with a suffix", path: filepath.Join(library+"2", "Heat (1995).mkv"), wantErr: true},
+		{name: "sibling with a dashed suffix", path: library + "-secret", wantErr: true},
+		{name: "parent", path: parent, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePath(tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePath(%q) = %v, want error %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatePathSymlinkEscape(t *testing.T) {
+	library := setupTestLibrary(t)
+	outside := t.TempDir()
+	writeTestFile(t, filepath.Join(outside, "passwd"))
+	writeTestFile(t, filepath.Join(library, "Heat (1995)", "Heat (1995).mkv"))
+
+	links := map[string]string{
+		"escape.nfo":  filepath.Join(outside, "passwd"),
+		"Escape":      outside,
+		"Dangling":    filepath.Join(outside, "missing"),
+		"Heat.mkv":    filepath.Join(library, "Heat (1995)", "Heat (1995).mkv"),
+		"Heat Folder": filepath.Join(library, "Heat (1995)"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(library, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "file linking outside", path: filepath.Join(library, "escape.nfo"), wantErr: true},
+		{name: "folder linking outside", path: filepath.Join(library, "Escape", "passwd"), wantErr: true},
+		{name: "new file in a folder linking outside", path: filepath.Join(library, "Escape", "Movie (2009)", "Movie (2009).mkv"), wantErr: true},
+		{name: "dangling link", path: filepath.Join(library, "Dangling"), wantErr: true},
+		{name: "new file below a dangling link", path: filepath.Join(library, "Dangling", "Movie.mkv"), wantErr: true},
+		{name: "file linking inside", path: filepath.Join(library, "Heat.mkv")},
+		{name: "folder linking inside", path: filepath.Join(library, "Heat Folder", "Heat (1995).mkv")},
+		{name: "new file in a real folder", path: filepath.Join(library, "Movie (2009)", "Movie (2009).mkv")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePath(tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePath(%q) = %v, want error %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("read_file refuses the escaping link", func(t *testing.T) {
+		input := json.RawMessage(`{"path":"` + filepath.Join(library, "escape.nfo") + `"}`)
+		if result, err := ReadFile(input); err == nil {
+			t.Errorf("ReadFile read %q through the link", result.Content)
+		}
+	})
+
+	t.Run("copy_file refuses a destination through the link", func(t *testing.T) {
+		source := filepath.Join(library, "Heat (1995)", "Heat (1995).mkv")
+		input := json.RawMessage(`{"initial_path":"` + source + `","ending_path":"` + filepath.Join(library, "Escape", "copy.mkv") + `"}`)
+		if _, err := CopyFile(context.Background(), input); err == nil {
+			t.Error("CopyFile wrote through the link")
+		}
+		if _, err := os.Stat(filepath.Join(outside, "copy.mkv")); !os.IsNotExist(err) {
+			t.Errorf("a file was created outside the library")
+		}
+	})
+}
+
+func TestResolvePath(t *testing.T) {
+	movies := setupTestLibrary(t)
+	root := filepath.Dir(movies)
+	shows := filepath.Join(root, "shows")
+	source := filepath.Join(root, "downloads")
+	for _, folder := range []string{shows, source} {
+		if err := os.Mkdir(folder, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("JELLYFIN_SHOWS_FOLDER", shows)
+	t.Setenv("SOURCE_FOLDER", source)
+
+	// Relative paths resolve against the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(movies); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	tests := []struct {
+		name    string
+		path    string
+		remote  bool
+		want    string
+		wantErr string
+	}{
+		{name: "movies folder", path: movies, want: movies},
+		{name: "file in the shows folder", path: filepath.Join(shows, "Dark (2017)", "Season 01"), want: filepath.Join(shows, "Dark (2017)", "Season 01")},
+		{name: "file in the source folder", path: filepath.Join(source, "Heat.1995.mkv"), want: filepath.Join(source, "Heat.1995.mkv")},
+		{name: "unclean path", path: movies + "//Heat (1995)/./Heat.mkv", want: filepath.Join(movies, "Heat (1995)", "Heat.mkv")},
+		{name: "relative path", path: "Heat (1995)/Heat.mkv", want: filepath.Join(movies, "Heat (1995)", "Heat.mkv")},
+		{name: "relative dot", path: ".", want: movies},
+		{name: "dots inside a name", path: filepath.Join(movies, "Vol.. 2", "Part..1.mkv"), want: filepath.Join(movies, "Vol.. 2", "Part..1.mkv")},
+		{name: "traversal out of the library", path: movies + "/../../etc", wantErr: "traversal"},
+		{name: "traversal that stays inside", path: movies + "/a/../b", wantErr: "traversal"},
+		{name: "relative traversal", path: "../shows", wantErr: "traversal"},
+		{name: "outside every folder", path: "/etc/passwd", wantErr: "not within permitted folders"},
+		{name: "parent of the folders", path: root, wantErr: "not within permitted folders"},
+		{name: "sibling sharing a prefix", path: source + "-old", wantErr: "not within permitted folders"},
+		{name: "remote absolute path", path: "/srv/media/movies", remote: true, wantErr: "not within permitted folders"},
+		{name: "remote path in a folder", path: filepath.Join(movies, "Heat.mkv"), remote: true, want: filepath.Join(movies, "Heat.mkv")},
+		{name: "remote relative path", path: "Heat.mkv", remote: true, wantErr: "must be absolute"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.remote {
+				t.Setenv("REMOTE_MEDIA_URL", "sftp://media@nas.local")
+			}
+
+			got, err := ResolvePath(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolvePath(%q) = %q, %v, want error %q", tt.path, got, err, tt.wantErr)
+				}
+				if ValidatePath(tt.path) == nil {
+					t.Errorf("ValidatePath(%q) accepted what ResolvePath rejected", tt.path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolvePath(%q): %v", tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolvePath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+			if err := ValidatePath(tt.path); err != nil {
+				t.Errorf("ValidatePath(%q) = %v, want it to agree with ResolvePath", tt.path, err)
+			}
+		})
+	}
+}
+
+func TestValidateLibraryPath(t *testing.T) {
+	movies := setupTestLibrary(t)
+	source := filepath.Join(filepath.Dir(movies), "downloads")
+	t.Setenv("SOURCE_FOLDER", source)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "library", path: filepath.Join(movies, "Heat (1995)", "Heat (1995).mkv")},
+		{name: "source folder", path: filepath.Join(source, "Heat.1995.mkv"), wantErr: true},
+		{name: "traversal", path: movies + "/../downloads", wantErr: true},
+		{name: "outside", path: "/tmp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateLibraryPath(tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateLibraryPath(%q) = %v, want error %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
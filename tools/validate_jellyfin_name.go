@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type ValidateJellyfinNameInput struct {
+	Path      string `json:"path" jsonschema_description:"The proposed absolute path. For movies the video file, e.g. '/media/movies/Heat (1995)/Heat (1995).mkv', or the movie folder. For episodes the video file, e.g. '/media/shows/Lost (2004)/Season 01/Lost S01E02.mkv'. The path doesn't have to exist."`
+	MediaType string `json:"media_type" jsonschema_description:"'movie' or 'episode'."`
+}
+
+var ValidateJellyfinNameInputSchema = GenerateSchema[ValidateJellyfinNameInput]()
+
+var ValidateJellyfinNameDefinition = ToolDefinition{
+	Name:        "validate_jellyfin_name",
+	Description: "Check a proposed path against the Jellyfin naming convention before moving anything: 'Movie (Year)/Movie (Year).ext' for movies and 'Show (Year)/Season 01/Show S01E02.ext' for episodes. Returns whether it is valid and every problem found, like a missing year, unpadded season numbers or a file name that doesn't match its folder. Call it on each target path before renaming.",
+	InputSchema: ValidateJellyfinNameInputSchema,
+	Function:    ValidateJellyfinName,
+}
+
+type ValidateJellyfinNameOutput struct {
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
+}
+
+var (
+	// jellyfinItemFolderPattern is "Name (Year) [provider-id]", where the year
+	// and the id are optional
+	jellyfinItemFolderPattern = regexp.MustCompile(`^(.+?)(?: \(((?:19|20)\d{2})\))?(?: \[(?:imdbid|tmdbid|tvdbid)-[^\]]+\])?$`)
+	jellyfinSeasonPattern     = regexp.MustCompile(`^Season (\d+)$`)
+	jellyfinEpisodePattern    = regexp.MustCompile(`S(\d+)E(\d+)(?:-E(\d+))?`)
+)
+
+func ValidateJellyfinName(input json.RawMessage) (string, error) {
+	validateInput := ValidateJellyfinNameInput{}
+	err := json.Unmarshal(input, &validateInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	path := filepath.Clean(validateInput.Path)
+	var problems []string
+	switch validateInput.MediaType {
+	case "movie":
+		problems = movieNameProblems(path)
+	case "episode":
+		problems = episodeNameProblems(path)
+	default:
+		return "", fmt.Errorf("media_type must be 'movie' or 'episode', got %q", validateInput.MediaType)
+	}
+
+	output := ValidateJellyfinNameOutput{Valid: len(problems) == 0, Problems: problems}
+	if output.Problems == nil {
+		output.Problems = []string{}
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// movieNameProblems checks a movie file or folder. The file must sit in its
+// own folder in the movies library and share the folder's name, optionally
+// followed by version or quality tags
+func movieNameProblems(path string) []string {
+	folder := path
+	if isVideoFile(path) {
+		folder = filepath.Dir(path)
+	}
+
+	problems := itemFolderProblems(filepath.Base(folder), "movie")
+	if library := os.Getenv("JELLYFIN_MOVIES_FOLDER"); library != "" && filepath.Dir(folder) != filepath.Clean(library) {
+		problems = append(problems, fmt.Sprintf("the movie folder should be directly in the movies library %s", library))
+	}
+
+	if folder != path {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if !strings.HasPrefix(name, filepath.Base(folder)) {
+			problems = append(problems, fmt.Sprintf("the file name %q should start with the folder name %q", name, filepath.Base(folder)))
+		}
+	}
+	return problems
+}
+
+// episodeNameProblems checks an episode file, which belongs in a padded
+// season folder of the show folder and carries the show name and SxxEyy
+func episodeNameProblems(path string) []string {
+	var problems []string
+	if !isVideoFile(path) {
+		problems = append(problems, "the path should be a video file, e.g. 'Show (2004)/Season 01/Show S01E02.mkv'")
+	}
+
+	seasonFolder := filepath.Base(filepath.Dir(path))
+	showFolder := filepath.Dir(filepath.Dir(path))
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	season := -1
+	if match := jellyfinSeasonPattern.FindStringSubmatch(seasonFolder); match == nil {
+		problems = append(problems, fmt.Sprintf("the episode should be in a season folder named like 'Season 01', not %q", seasonFolder))
+	} else {
+		season, _ = strconv.Atoi(match[1])
+		if len(match[1]) < 2 {
+			problems = append(problems, fmt.Sprintf("pad the season folder number: 'Season %02d' instead of %q", season, seasonFolder))
+		}
+	}
+
+	problems = append(problems, itemFolderProblems(filepath.Base(showFolder), "show")...)
+	if library := os.Getenv("JELLYFIN_SHOWS_FOLDER"); library != "" && filepath.Dir(showFolder) != filepath.Clean(library) {
+		problems = append(problems, fmt.Sprintf("the show folder should be directly in the shows library %s", library))
+	}
+
+	match := jellyfinEpisodePattern.FindStringSubmatch(name)
+	if match == nil {
+		problems = append(problems, fmt.Sprintf("the file name %q has no episode number like S01E02", name))
+		return problems
+	}
+	episodeSeason, _ := strconv.Atoi(match[1])
+	episode, _ := strconv.Atoi(match[2])
+	if len(match[1]) < 2 || len(match[2]) < 2 {
+		problems = append(problems, fmt.Sprintf("pad the episode numbers to two digits, e.g. S%02dE%02d", episodeSeason, episode))
+	}
+	if season >= 0 && episodeSeason != season {
+		problems = append(problems, fmt.Sprintf("the file is season %d but is in the folder %q", episodeSeason, seasonFolder))
+	}
+
+	if folderMatch := jellyfinItemFolderPattern.FindStringSubmatch(filepath.Base(showFolder)); folderMatch != nil {
+		if show := folderMatch[1]; !strings.HasPrefix(name, show+" ") {
+			problems = append(problems, fmt.Sprintf("the file name should start with the show name, e.g. '%s %s'", show, match[0]))
+		}
+	}
+	return problems
+}
+
+// itemFolderProblems checks a movie or show folder name against
+// "Name (Year) [provider-id]"
+func itemFolderProblems(folder, kind string) []string {
+	var problems []string
+	match := jellyfinItemFolderPattern.FindStringSubmatch(folder)
+	if match == nil || match[2] == "" {
+		problems = append(problems, fmt.Sprintf("the %s folder %q has no year, name it like 'Name (Year)'", kind, folder))
+	}
+	if strings.ContainsAny(folder, "._") && !strings.Contains(folder, " ") {
+		problems = append(problems, fmt.Sprintf("the %s folder %q uses dots or underscores instead of spaces", kind, folder))
+	}
+	if releaseTagPattern.MatchString(folder) || releaseResolutionPattern.MatchString(folder) {
+		problems = append(problems, fmt.Sprintf("the %s folder %q still has release tags like quality or source, keep only the name and year", kind, folder))
+	}
+	return problems
+}
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type VerifyJellyfinIndexedInput struct {
+	IncludeCurrent bool `json:"include_current" jsonschema_description:"If true, the operations of the current session are verified. By default the previous run from the operation log is verified."`
+}
+
+var VerifyJellyfinIndexedInputSchema = GenerateSchema[VerifyJellyfinIndexedInput]()
+
+var VerifyJellyfinIndexedDefinition = ToolDefinition{
+	Name:        "verify_jellyfin_indexed",
+	Description: "Check with the Jellyfin API that every item moved or copied during a run (from the operation log) is now a recognized library item. Reports the targets Jellyfin hasn't indexed, which usually means wrong naming or that the library hasn't been scanned yet. Requires JELLYFIN_SERVER_URL and JELLYFIN_API_KEY. When Jellyfin sees the media under other paths, e.g. in a container, JELLYFIN_PATH_MAP translates them.",
+	InputSchema: VerifyJellyfinIndexedInputSchema,
+	Function:    VerifyJellyfinIndexed,
+}
+
+type VerifyJellyfinIndexedOutput struct {
+	RunID      string   `json:"run_id,omitempty"`
+	Recognized []string `json:"recognized"`
+	Missing    []string `json:"missing"`
+	Note       string   `json:"note,omitempty"`
+}
+
+func VerifyJellyfinIndexed(input json.RawMessage) (string, error) {
+	verifyInput := VerifyJellyfinIndexedInput{}
+	err := json.Unmarshal(input, &verifyInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	pathMap, err := jellyfinPathMap()
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := readLedger(ledgerPath())
+	if err != nil {
+		return "", fmt.Errorf("failed to read the operation log: %v", err)
+	}
+
+	skip := ""
+	if !verifyInput.IncludeCurrent {
+		skip = ledgerRunID
+	}
+	run := summarizeLastRun(entries, skip)
+
+	output := VerifyJellyfinIndexedOutput{RunID: run.RunID, Recognized: []string{}, Missing: []string{}}
+	targets := runTargets(run.Operations)
+	if len(targets) == 0 {
+		jsonData, err := json.Marshal(output)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal results: %w", err)
+		}
+		return string(jsonData), nil
+	}
+
+	items, err := jellyfin.Items([]string{"Movie", "Episode", "Series", "Season", "Video"})
+	if err != nil {
+		return "", err
+	}
+
+	for _, target := range targets {
+		if indexedByJellyfin(toJellyfinPath(target, pathMap), items) {
+			output.Recognized = append(output.Recognized, target)
+		} else {
+			output.Missing = append(output.Missing, target)
+		}
+	}
+
+	// Nothing matching at all usually means Jellyfin sees other paths
+	if len(output.Recognized) == 0 && len(items) > 0 && len(pathMap) == 0 {
+		output.Note = "None of the targets were found among the Jellyfin items. If Jellyfin sees the libraries under other paths than this machine, e.g. in a container, set JELLYFIN_PATH_MAP to local=server path prefixes"
+	}
+
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// pathMapping translates a local path prefix to the one Jellyfin uses
+type pathMapping struct {
+	local  string
+	server string
+}
+
+// jellyfinPathMap reads JELLYFIN_PATH_MAP, a comma separated list of
+// local=server path prefixes, like /mnt/media=/media. The longest matching
+// local prefix is listed first
+func jellyfinPathMap() ([]pathMapping, error) {
+	var mappings []pathMapping
+	for _, pair := range strings.Split(os.Getenv("JELLYFIN_PATH_MAP"), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		local, server, ok := strings.Cut(pair, "=")
+		local, server = strings.TrimSpace(local), strings.TrimSpace(server)
+		if !ok || local == "" || server == "" {
+			return nil, fmt.Errorf("invalid JELLYFIN_PATH_MAP entry %q, expected local=server", pair)
+		}
+		mappings = append(mappings, pathMapping{local: filepath.Clean(local), server: filepath.Clean(server)})
+	}
+
+	sort.SliceStable(mappings, func(i, j int) bool { return len(mappings[i].local) > len(mappings[j].local) })
+	return mappings, nil
+}
+
+// toJellyfinPath translates a local path to the path Jellyfin knows it by.
+// Paths no mapping covers are returned as they are
+func toJellyfinPath(path string, mappings []pathMapping) string {
+	for _, mapping := range mappings {
+		if !isWithinFolder(path, mapping.local) {
+			continue
+		}
+		rel, err := filepath.Rel(mapping.local, path)
+		if err != nil {
+			continue
+		}
+		return filepath.Join(mapping.server, rel)
+	}
+	return path
+}
+
+// runTargets returns where the media of a run ended up. Targets that were
+// moved again later in the run are followed to their final location, and
+// sidecars are left out since Jellyfin doesn't list them as items
+func runTargets(operations []LedgerEntry) []string {
+	final := map[string]bool{}
+	for _, operation := range operations {
+		if operation.Operation == "move" {
+			delete(final, operation.Source)
+		}
+		if operation.Target != "" {
+			final[operation.Target] = true
+		}
+	}
+
+	var targets []string
+	for target := range final {
+		if !isSidecarFile(target) {
+			targets = append(targets, target)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// indexedByJellyfin reports whether an item's path is the target or, for a
+// folder target, lies inside it
+func indexedByJellyfin(target string, items []JellyfinItem) bool {
+	for _, item := range items {
+		if item.Path != "" && isWithinFolder(item.Path, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSidecarFile reports whether a file accompanies a video rather than being
+// an item itself, like subtitles, NFOs and artwork
+func isSidecarFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	if isSubtitleFile(name) || ext == ".nfo" || ext == ".xml" {
+		return true
+	}
+	for _, imageExt := range imageExtensions {
+		if ext == imageExt {
+			return true
+		}
+	}
+	return false
+}
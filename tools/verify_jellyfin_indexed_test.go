@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeJellyfin stands in for the Jellyfin API with a fixed set of items
+type fakeJellyfin struct {
+	items []JellyfinItem
+}
+
+func (f fakeJellyfin) Items(types []string) ([]JellyfinItem, error) {
+	return f.items, nil
+}
+
+func (f fakeJellyfin) Search(term string, types []string, limit int) ([]JellyfinItem, error) {
+	return nil, nil
+}
+
+func useJellyfin(t *testing.T, library jellyfinLibrary) {
+	t.Helper()
+	previous := jellyfin
+	jellyfin = library
+	t.Cleanup(func() { jellyfin = previous })
+}
+
+func TestToJellyfinPath(t *testing.T) {
+	mappings := []pathMapping{
+		{local: "/mnt/nas/media/movies", server: "/data/movies"},
+		{local: "/mnt/nas/media", server: "/media"},
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/mnt/nas/media/shows/Dark (2017)", want: "/media/shows/Dark (2017)"},
+		{path: "/mnt/nas/media/movies/Heat (1995)/Heat (1995).mkv", want: "/data/movies/Heat (1995)/Heat (1995).mkv"},
+		{path: "/mnt/nas/media", want: "/media"},
+		{path: "/mnt/nas/media2/Heat (1995)", want: "/mnt/nas/media2/Heat (1995)"},
+		{path: "/downloads/Heat.1995.mkv", want: "/downloads/Heat.1995.mkv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := toJellyfinPath(tt.path, mappings); got != tt.want {
+				t.Errorf("toJellyfinPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJellyfinPathMap(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []pathMapping
+		wantErr bool
+	}{
+		{value: ""},
+		{value: "/mnt/media=/media", want: []pathMapping{{local: "/mnt/media", server: "/media"}}},
+		{
+			value: " /mnt/media/ = /media , /mnt/media/movies=/movies,",
+			want:  []pathMapping{{local: "/mnt/media/movies", server: "/movies"}, {local: "/mnt/media", server: "/media"}},
+		},
+		{value: "/mnt/media", wantErr: true},
+		{value: "=/media", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("JELLYFIN_PATH_MAP", tt.value)
+			got, err := jellyfinPathMap()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("jellyfinPathMap() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("jellyfinPathMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyJellyfinIndexed(t *testing.T) {
+	items := []JellyfinItem{
+		{Name: "Heat", Type: "Movie", Path: "/media/movies/Heat (1995)/Heat (1995).mkv"},
+		{Name: "Pilot", Type: "Episode", Path: "/media/shows/Dark (2017)/Season 01/Dark S01E01.mkv"},
+	}
+
+	tests := []struct {
+		name           string
+		pathMap        string
+		targets        []string
+		wantRecognized []string
+		wantMissing    []string
+		wantNote       bool
+	}{
+		{
+			name:           "same paths on both sides",
+			targets:        []string{"/media/movies/Heat (1995)/Heat (1995).mkv", "/media/shows/Dark (2017)", "/media/movies/Alien (1979)/Alien (1979).mkv", "/media/movies/Heat (1995)/Heat (1995).en.srt"},
+			wantRecognized: []string{"/media/movies/Heat (1995)/Heat (1995).mkv", "/media/shows/Dark (2017)"},
+			wantMissing:    []string{"/media/movies/Alien (1979)/Alien (1979).mkv"},
+		},
+		{
+			name:           "mapped paths",
+			pathMap:        "/mnt/nas=/media",
+			targets:        []string{"/mnt/nas/movies/Heat (1995)/Heat (1995).mkv", "/mnt/nas/movies/Alien (1979)/Alien (1979).mkv"},
+			wantRecognized: []string{"/mnt/nas/movies/Heat (1995)/Heat (1995).mkv"},
+			wantMissing:    []string{"/mnt/nas/movies/Alien (1979)/Alien (1979).mkv"},
+		},
+		{
+			name:           "unmapped paths",
+			targets:        []string{"/mnt/nas/movies/Heat (1995)/Heat (1995).mkv", "/mnt/nas/shows/Dark (2017)"},
+			wantRecognized: []string{},
+			wantMissing:    []string{"/mnt/nas/movies/Heat (1995)/Heat (1995).mkv", "/mnt/nas/shows/Dark (2017)"},
+			wantNote:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestLibrary(t)
+			t.Setenv("JELLYFIN_PATH_MAP", tt.pathMap)
+			useJellyfin(t, fakeJellyfin{items: items})
+
+			for _, target := range tt.targets {
+				entry := LedgerEntry{RunID: ledgerRunID, Time: time.Now(), Operation: "move", Source: "/downloads/item", Target: target}
+				if err := appendLedgerEntry(ledgerPath(), entry); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := VerifyJellyfinIndexed([]byte(`{"include_current":true}`))
+			if err != nil {
+				t.Fatalf("VerifyJellyfinIndexed: %v", err)
+			}
+
+			var output VerifyJellyfinIndexedOutput
+			if err := json.Unmarshal([]byte(result), &output); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(output.Recognized, tt.wantRecognized) {
+				t.Errorf("recognized = %v, want %v", output.Recognized, tt.wantRecognized)
+			}
+			if !reflect.DeepEqual(output.Missing, tt.wantMissing) {
+				t.Errorf("missing = %v, want %v", output.Missing, tt.wantMissing)
+			}
+			if (output.Note != "") != tt.wantNote {
+				t.Errorf("note = %q, want a note %v", output.Note, tt.wantNote)
+			}
+		})
+	}
+}
+
+func TestVerifyJellyfinIndexedInvalidPathMap(t *testing.T) {
+	setupTestLibrary(t)
+	t.Setenv("JELLYFIN_PATH_MAP", "/mnt/nas")
+	useJellyfin(t, fakeJellyfin{})
+
+	_, err := VerifyJellyfinIndexed([]byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "JELLYFIN_PATH_MAP") {
+		t.Errorf("error = %v, want an invalid JELLYFIN_PATH_MAP error", err)
+	}
+}
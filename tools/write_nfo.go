@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+type WriteNFOInput struct {
+	Directory string `json:"directory" jsonschema_description:"The movie or show folder to write the NFO into, e.g. '/media/movies/Heat (1995)'. Must be within JELLYFIN_SHOWS_FOLDER or JELLYFIN_MOVIES_FOLDER."`
+	MediaType string `json:"media_type" jsonschema_description:"'movie' writes movie.nfo, 'tvshow' writes tvshow.nfo."`
+	Title     string `json:"title" jsonschema_description:"The movie or show title."`
+	Year      int    `json:"year" jsonschema_description:"The release year, or 0 if unknown."`
+	IMDbID    string `json:"imdb_id" jsonschema_description:"The IMDb id, e.g. 'tt0113277'. Optional but recommended, it pins the identification for Jellyfin."`
+	Plot      string `json:"plot" jsonschema_description:"A short plot summary. Optional."`
+	Overwrite bool   `json:"overwrite" jsonschema_description:"If true, an existing NFO is replaced. Defaults to false, which fails when the NFO exists. Use diff_nfo to update single fields instead."`
+}
+
+var WriteNFOInputSchema = GenerateSchema[WriteNFOInput]()
+
+var WriteNFODefinition = ToolDefinition{
+	Name:        "write_nfo",
+	Description: "Write a Jellyfin/Kodi compatible movie.nfo or tvshow.nfo with the title, year, IMDb id and plot into a movie or show folder. This locks in the identification found via IMDb or TMDb, so Jellyfin doesn't have to guess from the folder name.",
+	InputSchema: WriteNFOInputSchema,
+	Function:    WriteNFO,
+}
+
+// nfoDocument is the subset of the Kodi NFO format write_nfo fills in. The
+// root element is movie or tvshow
+type nfoDocument struct {
+	XMLName   xml.Name
+	Title     string        `xml:"title"`
+	Year      int           `xml:"year,omitempty"`
+	Plot      string        `xml:"plot,omitempty"`
+	UniqueIDs []nfoUniqueID `xml:"uniqueid,omitempty"`
+	IMDbID    string        `xml:"imdbid,omitempty"`
+}
+
+type nfoUniqueID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr"`
+	Value   string `xml:",chardata"`
+}
+
+func WriteNFO(input json.RawMessage) (string, error) {
+	nfoInput := WriteNFOInput{}
+	err := json.Unmarshal(input, &nfoInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal input: %v", err)
+	}
+
+	if nfoInput.MediaType != "movie" && nfoInput.MediaType != "tvshow" {
+		return "", fmt.Errorf("media_type must be 'movie' or 'tvshow', got %q", nfoInput.MediaType)
+	}
+	title := strings.TrimSpace(nfoInput.Title)
+	if title == "" {
+		return "", fmt.Errorf("title is required")
+	}
+	imdbID := strings.TrimSpace(nfoInput.IMDbID)
+	if imdbID != "" && !imdbIDPattern.MatchString(imdbID) {
+		return "", fmt.Errorf("invalid IMDb id %q, expected something like tt0113277", imdbID)
+	}
+
+	target := filepath.Join(nfoInput.Directory, nfoInput.MediaType+".nfo")
+	if err := ValidateLibraryPath(target); err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+
+	fsys, err := GetMediaFS()
+	if err != nil {
+		return "", err
+	}
+	if info, err := fsys.Stat(nfoInput.Directory); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("directory does not exist: %s", nfoInput.Directory)
+	}
+	_, err = fsys.Stat(target)
+	exists := err == nil
+	if exists && !nfoInput.Overwrite {
+		return "", fmt.Errorf("target path already exists: %s", target)
+	}
+
+	doc := nfoDocument{
+		XMLName: xml.Name{Local: nfoInput.MediaType},
+		Title:   title,
+		Year:    nfoInput.Year,
+		Plot:    strings.TrimSpace(nfoInput.Plot),
+	}
+	if imdbID != "" {
+		doc.UniqueIDs = []nfoUniqueID{{Type: "imdb", Default: true, Value: imdbID}}
+		doc.IMDbID = imdbID
+	}
+	content, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to render NFO: %v", err)
+	}
+	content = append([]byte(xml.Header), append(content, '\n')...)
+
+	if DryRun {
+		return fmt.Sprintf("%swould write %s:\n%s", dryRunPrefix, target, content), nil
+	}
+
+	file, err := fsys.Create(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to create NFO: %v", err)
+	}
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write NFO: %v", err)
+	}
+	// Undoing a create removes the file, which must not happen to an NFO
+	// that was there before
+	if !exists {
+		recordOperation("create", "", target)
+	}
+
+	return fmt.Sprintf("Wrote %s", target), nil
+}
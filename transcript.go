@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Transcript logs the conversation so the decisions Claude made can be
+// audited after the session ends. It writes a human-readable log, a JSONL
+// history with one entry per line, or both
+type Transcript struct {
+	outputs []*transcriptOutput
+	secrets []string
+}
+
+type transcriptOutput struct {
+	file   *os.File
+	writer *bufio.Writer
+	jsonl  bool
+}
+
+// transcriptEntry is one line of a JSONL transcript
+type transcriptEntry struct {
+	Time       time.Time       `json:"time"`
+	Role       string          `json:"role"`
+	Content    string          `json:"content,omitempty"`
+	ToolName   string          `json:"tool_name,omitempty"`
+	ToolInput  json.RawMessage `json:"tool_input,omitempty"`
+	ToolResult string          `json:"tool_result,omitempty"`
+	IsError    bool            `json:"is_error,omitempty"`
+}
+
+var apiKeyPattern = regexp.MustCompile(`sk-ant-[A-Za-z0-9_\-]+`)
+
+// OpenTranscript opens a human-readable transcript at path and a JSONL
+// transcript in dir, named after the session start time. Either may be
+// empty, and nil is returned when both are
+func OpenTranscript(path, dir string) (*Transcript, error) {
+	t := &Transcript{secrets: secretEnvValues()}
+
+	if path != "" {
+		if err := t.open(path, false); err != nil {
+			return nil, err
+		}
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Close()
+			return nil, fmt.Errorf("failed to create transcript directory: %v", err)
+		}
+		name := "session-" + time.Now().Format("20060102-150405") + ".jsonl"
+		if err := t.open(filepath.Join(dir, name), true); err != nil {
+			t.Close()
+			return nil, err
+		}
+	}
+
+	if len(t.outputs) == 0 {
+		return nil, nil
+	}
+
+	t.record(transcriptEntry{Role: "session", Content: fmt.Sprintf("started at %s", time.Now().Format(time.RFC3339))})
+
+	return t, nil
+}
+
+func (t *Transcript) open(path string, jsonl bool) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open transcript file: %v", err)
+	}
+	t.outputs = append(t.outputs, &transcriptOutput{file: file, writer: bufio.NewWriter(file), jsonl: jsonl})
+	return nil
+}
+
+// credentialEnvVars are the env vars holding credentials. Names are listed
+// rather than matched, so settings like ANTHROPIC_MAX_TOKENS stay readable
+var credentialEnvVars = []string{
+	"ANTHROPIC_API_KEY",
+	"ANTHROPIC_API_KEYS",
+	"JELLYFIN_API_KEY",
+	"TMDB_API_KEY",
+	"OMDB_API_KEY",
+	"CHAPTERDB_API_KEY",
+	"SFTP_PASSWORD",
+}
+
+// secretEnvValues collects the credentials set in the environment,
+// including each rotated API key and the password in REMOTE_MEDIA_URL
+func secretEnvValues() []string {
+	var secrets []string
+	for _, name := range credentialEnvVars {
+		for _, value := range strings.Split(os.Getenv(name), ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				secrets = append(secrets, value)
+			}
+		}
+	}
+
+	if remoteURL, err := url.Parse(os.Getenv("REMOTE_MEDIA_URL")); err == nil && remoteURL.User != nil {
+		if password, ok := remoteURL.User.Password(); ok && password != "" {
+			secrets = append(secrets, password)
+		}
+	}
+	return secrets
+}
+
+func (t *Transcript) redact(text string) string {
+	for _, secret := range t.secrets {
+		text = strings.ReplaceAll(text, secret, "[REDACTED]")
+	}
+	return apiKeyPattern.ReplaceAllString(text, "[REDACTED]")
+}
+
+func (t *Transcript) record(entry transcriptEntry) {
+	if t == nil {
+		return
+	}
+	entry.Time = time.Now()
+
+	for _, output := range t.outputs {
+		if output.jsonl {
+			line, err := json.Marshal(entry)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(output.writer, "%s\n", t.redact(string(line)))
+			continue
+		}
+		fmt.Fprintf(output.writer, "[%s] %s: %s\n", entry.Time.Format("15:04:05"), humanRole(entry), t.redact(humanText(entry)))
+	}
+}
+
+// humanRole and humanText render an entry the way the human-readable
+// transcript has always shown it
+func humanRole(entry transcriptEntry) string {
+	switch entry.Role {
+	case "user":
+		return "You"
+	case "assistant":
+		return "Claude"
+	case "tool_call", "tool_result":
+		return "tool"
+	}
+	return entry.Role
+}
+
+func humanText(entry transcriptEntry) string {
+	switch {
+	case entry.Role == "tool_call":
+		return fmt.Sprintf("%s(%s)", entry.ToolName, entry.ToolInput)
+	case entry.Role == "tool_result" && entry.IsError:
+		return fmt.Sprintf("%s error: %s", entry.ToolName, entry.ToolResult)
+	case entry.Role == "tool_result":
+		return fmt.Sprintf("%s result: %s", entry.ToolName, entry.ToolResult)
+	}
+	return entry.Content
+}
+
+func (t *Transcript) User(text string) {
+	t.record(transcriptEntry{Role: "user", Content: text})
+}
+
+func (t *Transcript) Claude(text string) {
+	t.record(transcriptEntry{Role: "assistant", Content: text})
+}
+
+func (t *Transcript) ToolCall(name string, input json.RawMessage) {
+	t.record(transcriptEntry{Role: "tool_call", ToolName: name, ToolInput: input})
+}
+
+func (t *Transcript) ToolResult(name, result string, isError bool) {
+	t.record(transcriptEntry{Role: "tool_result", ToolName: name, ToolResult: result, IsError: isError})
+}
+
+// Flush writes buffered entries and syncs the files so the transcript
+// survives a crash. It is called after every turn
+func (t *Transcript) Flush() error {
+	if t == nil {
+		return nil
+	}
+	for _, output := range t.outputs {
+		if err := output.writer.Flush(); err != nil {
+			return err
+		}
+		if err := output.file.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Transcript) Close() error {
+	if t == nil {
+		return nil
+	}
+	t.record(transcriptEntry{Role: "session", Content: fmt.Sprintf("ended at %s", time.Now().Format(time.RFC3339))})
+	err := t.Flush()
+	for _, output := range t.outputs {
+		if closeErr := output.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// modelPrice is the USD price per million input and output tokens
+type modelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// modelPricing is keyed by model name prefix so dated and "latest" aliases
+// of the same model share a price
+var modelPricing = []struct {
+	prefix string
+	price  modelPrice
+}{
+	{"claude-3-7-sonnet", modelPrice{Input: 3, Output: 15}},
+	{"claude-3-5-sonnet", modelPrice{Input: 3, Output: 15}},
+	{"claude-3-5-haiku", modelPrice{Input: 0.8, Output: 4}},
+	{"claude-3-haiku", modelPrice{Input: 0.25, Output: 1.25}},
+	{"claude-3-sonnet", modelPrice{Input: 3, Output: 15}},
+	{"claude-3-opus", modelPrice{Input: 15, Output: 75}},
+	{"claude-sonnet-4", modelPrice{Input: 3, Output: 15}},
+	{"claude-4-sonnet", modelPrice{Input: 3, Output: 15}},
+	{"claude-opus-4", modelPrice{Input: 15, Output: 75}},
+	{"claude-4-opus", modelPrice{Input: 15, Output: 75}},
+}
+
+// priceForModel falls back to Sonnet pricing for unknown models
+func priceForModel(model anthropic.Model) modelPrice {
+	for _, entry := range modelPricing {
+		if strings.HasPrefix(string(model), entry.prefix) {
+			return entry.price
+		}
+	}
+	return modelPrice{Input: 3, Output: 15}
+}
+
+// Usage accumulates token counts across all inference calls of a session
+type Usage struct {
+	InputTokens              int64
+	OutputTokens             int64
+	CacheCreationInputTokens int64
+	CacheReadInputTokens     int64
+}
+
+func (u *Usage) Add(usage anthropic.Usage) {
+	u.InputTokens += usage.InputTokens
+	u.OutputTokens += usage.OutputTokens
+	u.CacheCreationInputTokens += usage.CacheCreationInputTokens
+	u.CacheReadInputTokens += usage.CacheReadInputTokens
+}
+
+// EstimatedCost returns the approximate USD cost of the usage. Cache writes
+// are billed at 1.25x and cache reads at 0.1x the input price
+func (u Usage) EstimatedCost(model anthropic.Model) float64 {
+	price := priceForModel(model)
+	input := float64(u.InputTokens) + 1.25*float64(u.CacheCreationInputTokens) + 0.1*float64(u.CacheReadInputTokens)
+	return (input*price.Input + float64(u.OutputTokens)*price.Output) / 1_000_000
+}
+
+// TotalInputTokens counts cached and uncached input tokens alike
+func (u Usage) TotalInputTokens() int64 {
+	return u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+func (u Usage) TotalTokens() int64 {
+	return u.TotalInputTokens() + u.OutputTokens
+}
+
+func (u Usage) Summary(model anthropic.Model) string {
+	return fmt.Sprintf("%d tokens (%d input, %d output), estimated cost $%.4f",
+		u.TotalTokens(), u.TotalInputTokens(), u.OutputTokens, u.EstimatedCost(model))
+}